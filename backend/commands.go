@@ -0,0 +1,180 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+)
+
+const usage = `usage: project-tracker [command]
+
+With no command the HTTP server is started.
+
+Commands:
+  migrate status     list migrations and whether they are applied
+  migrate up         apply all pending migrations
+  migrate down [n]   revert the last n applied migrations (default 1)
+  bootstrap-admin <email> [name]
+                     create the first admin user; the password is read from
+                     ADMIN_PASSWORD or, if unset, the first line of stdin
+  search rebuild     reindex every project for full-text search
+  verify-audit       check the audit log hash chain; exits non-zero and
+                     reports the first broken entry if it was tampered with`
+
+// runCommand executes a maintenance command given on the command line
+// instead of starting the server
+func runCommand(args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(args[1:])
+	case "bootstrap-admin":
+		return runBootstrapAdmin(args[1:])
+	case "search":
+		return runSearch(args[1:])
+	case "verify-audit":
+		return runVerifyAudit()
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New("migrate needs a subcommand: status, up or down")
+	}
+
+	if err := db.Open(dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "status":
+		states, err := db.MigrationStatus()
+		if err != nil {
+			return err
+		}
+		for _, s := range states {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = "applied " + *s.AppliedAt
+			}
+			fmt.Printf("%03d  %-30s %s\n", s.Version, s.Name, appliedAt)
+		}
+		return nil
+
+	case "up":
+		if err := db.Migrate(); err != nil {
+			return err
+		}
+		fmt.Println("Migrations are up to date")
+		return nil
+
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n <= 0 {
+				return errors.New("migrate down expects a positive number of steps")
+			}
+			steps = n
+		}
+		if err := db.MigrateDown(steps); err != nil {
+			return err
+		}
+		fmt.Printf("Reverted up to %d migration(s)\n", steps)
+		return nil
+
+	default:
+		return fmt.Errorf("unknown migrate subcommand %q", args[0])
+	}
+}
+
+func runBootstrapAdmin(args []string) error {
+	if len(args) == 0 || strings.TrimSpace(args[0]) == "" {
+		return errors.New("bootstrap-admin needs an email address")
+	}
+	email := strings.TrimSpace(args[0])
+	name := email
+	if len(args) > 1 {
+		name = strings.Join(args[1:], " ")
+	}
+
+	password, ok := os.LookupEnv("ADMIN_PASSWORD")
+	if !ok {
+		line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && line == "" {
+			return errors.New("no password given on stdin or in ADMIN_PASSWORD")
+		}
+		password = strings.TrimRight(line, "\r\n")
+	}
+
+	hash, err := models.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	if err := db.InitDB(dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.BootstrapAdmin(uuid.New().String(), email, name, hash, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		return err
+	}
+
+	fmt.Printf("Created admin user %s\n", email)
+	return nil
+}
+
+func runSearch(args []string) error {
+	if len(args) == 0 || args[0] != "rebuild" {
+		return errors.New("search needs a subcommand: rebuild")
+	}
+
+	if err := db.InitDB(dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := db.RebuildSearchIndex(); err != nil {
+		return err
+	}
+	fmt.Println("Search index rebuilt")
+	return nil
+}
+
+func runVerifyAudit() error {
+	if err := db.InitDB(dbPath); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	result, err := db.VerifyAuditChain()
+	if err != nil {
+		return err
+	}
+
+	if !result.Valid {
+		return fmt.Errorf("audit log chain is broken at entry %d (%s): %s; %d entries checked",
+			result.Break.Seq, result.Break.ID, result.Break.Reason, result.Entries)
+	}
+
+	fmt.Printf("Audit log chain is intact: %d entries\n", result.Entries)
+	if result.HeadHash != "" {
+		fmt.Printf("Head hash: %s\n", result.HeadHash)
+	}
+	return nil
+}
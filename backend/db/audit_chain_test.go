@@ -0,0 +1,165 @@
+package db
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"project-tracker/models"
+)
+
+// writeAuditEntries migrates a fresh database and writes n audit entries,
+// a1 to an, each in its own transaction
+func writeAuditEntries(t *testing.T, n int) {
+	t.Helper()
+	openTestDB(t)
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	for i := 1; i <= n; i++ {
+		tx, err := DB.Begin()
+		if err != nil {
+			t.Fatalf("begin: %v", err)
+		}
+		field, value := "name", fmt.Sprintf("Name %d", i)
+		createdAt := fmt.Sprintf("2026-01-01T10:00:%02dZ", i)
+		if err := InsertAuditLog(tx, models.SystemActor, fmt.Sprintf("a%d", i), "p1", "PROJECT_UPDATED",
+			&field, nil, &value, createdAt); err != nil {
+			tx.Rollback()
+			t.Fatalf("insert audit entry %d: %v", i, err)
+		}
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("commit: %v", err)
+		}
+	}
+}
+
+func TestVerifyAuditChain(t *testing.T) {
+	t.Run("empty", func(t *testing.T) {
+		writeAuditEntries(t, 0)
+		result, err := VerifyAuditChain()
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		if !result.Valid || result.Entries != 0 || result.HeadHash != "" || result.Break != nil {
+			t.Errorf("got %+v, want a valid chain of no entries", result)
+		}
+	})
+
+	t.Run("intact", func(t *testing.T) {
+		writeAuditEntries(t, 3)
+		result, err := VerifyAuditChain()
+		if err != nil {
+			t.Fatalf("verify: %v", err)
+		}
+		var head string
+		if err := DB.QueryRow(`SELECT hash FROM audit_logs WHERE seq = 3`).Scan(&head); err != nil {
+			t.Fatalf("read head: %v", err)
+		}
+		if !result.Valid || result.Entries != 3 || result.HeadHash != head || result.Break != nil {
+			t.Errorf("got %+v, want 3 valid entries ending in %s", result, head)
+		}
+	})
+
+	tests := []struct {
+		name    string
+		tamper  string
+		seq     int64
+		id      string
+		entries int
+		reason  string
+	}{
+		{
+			name:    "edited contents",
+			tamper:  `UPDATE audit_logs SET new_value = 'Forged' WHERE id = 'a2'`,
+			seq:     2,
+			id:      "a2",
+			entries: 2,
+			reason:  "contents do not match the stored hash",
+		},
+		{
+			name:    "previous hash replaced",
+			tamper:  `UPDATE audit_logs SET prev_hash = 'f00d' WHERE id = 'a3'`,
+			seq:     3,
+			id:      "a3",
+			entries: 3,
+			reason:  "previous hash does not match the preceding entry",
+		},
+		{
+			name:    "entry removed",
+			tamper:  `DELETE FROM audit_logs WHERE id = 'a2'`,
+			seq:     3,
+			id:      "a3",
+			entries: 2,
+			reason:  "expected entry 2; entries are missing or out of order",
+		},
+		{
+			name: "entry inserted outside the chain",
+			tamper: `INSERT INTO audit_logs (id, project_id, action, created_at)
+				VALUES ('forged', 'p1', 'PROJECT_DELETED', '2026-01-01T10:00:09Z')`,
+			seq:     0,
+			id:      "forged",
+			entries: 4,
+			reason:  "entry is not part of the chain",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writeAuditEntries(t, 3)
+			if _, err := DB.Exec(tt.tamper); err != nil {
+				t.Fatalf("tamper: %v", err)
+			}
+
+			result, err := VerifyAuditChain()
+			if err != nil {
+				t.Fatalf("verify: %v", err)
+			}
+			if result.Valid || result.Break == nil {
+				t.Fatalf("got %+v, want a broken chain", result)
+			}
+			want := AuditBreak{Seq: tt.seq, ID: tt.id, Reason: tt.reason}
+			if *result.Break != want || result.Entries != tt.entries {
+				t.Errorf("break = %+v after %d entries, want %+v after %d", *result.Break, result.Entries, want, tt.entries)
+			}
+			if result.HeadHash != "" {
+				t.Errorf("head hash %q reported for a broken chain", result.HeadHash)
+			}
+		})
+	}
+}
+
+func TestVerifyAuditChainDuringAWrite(t *testing.T) {
+	writeAuditEntries(t, 2)
+
+	// A write in progress holds the write lock until it commits
+	tx, err := DB.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	field := "name"
+	if err := InsertAuditLog(tx, models.SystemActor, "a3", "p1", "PROJECT_UPDATED", &field, nil, nil, "2026-01-01T10:00:03Z"); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	type verification struct {
+		result AuditVerification
+		err    error
+	}
+	done := make(chan verification, 1)
+	go func() {
+		result, err := VerifyAuditChain()
+		done <- verification{result, err}
+	}()
+
+	select {
+	case v := <-done:
+		if v.err != nil || !v.result.Valid || v.result.Entries != 2 {
+			t.Errorf("got %+v (err %v), want the 2 committed entries", v.result, v.err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("verify waited for the write lock")
+	}
+}
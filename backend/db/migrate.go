@@ -0,0 +1,391 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"project-tracker/migrations"
+)
+
+// Migration is one numbered schema change loaded from the migrations directory
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationState pairs a migration with the time it was applied, if it has been
+type MigrationState struct {
+	Migration
+	AppliedAt *string
+}
+
+// legacyBaselineVersion is the last migration that databases created before
+// schema_migrations existed already contain; see baselineLegacySchema
+const legacyBaselineVersion = 4
+
+// migrationHooks run in Go after the up script of the migration with the
+// matching version, in the same transaction, for work SQL cannot do
+var migrationHooks = map[int]func(tx *sql.Tx) error{
+	12: sealAuditLog,
+	16: snapshotProjects,
+}
+
+var migrationFilePattern = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// LoadMigrations reads the embedded migration files, sorted by version
+func LoadMigrations() ([]Migration, error) {
+	entries, err := fs.ReadDir(migrations.FS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		if entry.IsDir() || entry.Name() == "embed.go" {
+			continue
+		}
+
+		match := migrationFilePattern.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s: file name must look like 001_name.up.sql", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(migrations.FS, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration %d: conflicting names %q and %q", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	result := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Up == "" {
+			return nil, fmt.Errorf("migration %03d_%s: missing up file", m.Version, m.Name)
+		}
+		result = append(result, *m)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Version < result[j].Version })
+
+	return result, nil
+}
+
+// Migrate applies every pending migration in order, each in its own transaction
+func Migrate() error {
+	all, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if err := prepareMigrationTable(); err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for _, m := range all {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if err := applyMigration(m, m.Up, true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the most recently applied migrations, newest first
+func MigrateDown(steps int) error {
+	all, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	if err := prepareMigrationTable(); err != nil {
+		return err
+	}
+
+	applied, err := appliedMigrations()
+	if err != nil {
+		return err
+	}
+
+	for i := len(all) - 1; i >= 0 && steps > 0; i-- {
+		m := all[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if m.Down == "" {
+			return fmt.Errorf("migration %03d_%s: no down file, cannot revert", m.Version, m.Name)
+		}
+		if err := applyMigration(m, m.Down, false); err != nil {
+			return err
+		}
+		steps--
+	}
+
+	return nil
+}
+
+// MigrationStatus reports every known migration and whether it has been applied
+func MigrationStatus() ([]MigrationState, error) {
+	all, err := LoadMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := prepareMigrationTable(); err != nil {
+		return nil, err
+	}
+
+	applied, err := appliedMigrations()
+	if err != nil {
+		return nil, err
+	}
+
+	states := make([]MigrationState, 0, len(all))
+	for _, m := range all {
+		state := MigrationState{Migration: m}
+		if appliedAt, ok := applied[m.Version]; ok {
+			state.AppliedAt = &appliedAt
+		}
+		states = append(states, state)
+	}
+	return states, nil
+}
+
+// applyMigration runs one migration script and records (or removes) its
+// version in the same transaction, so a failing script leaves no trace
+func applyMigration(m Migration, script string, up bool) error {
+	direction := "down"
+	if up {
+		direction = "up"
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(script); err != nil {
+		return fmt.Errorf("migration %03d_%s %s: %w", m.Version, m.Name, direction, err)
+	}
+
+	if hook, ok := migrationHooks[m.Version]; ok && up {
+		if err := hook(tx); err != nil {
+			return fmt.Errorf("migration %03d_%s %s: %w", m.Version, m.Name, direction, err)
+		}
+	}
+
+	if up {
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+			m.Version, m.Name, time.Now().UTC().Format(time.RFC3339))
+	} else {
+		_, err = tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, m.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("migration %03d_%s %s: record version: %w", m.Version, m.Name, direction, err)
+	}
+
+	return tx.Commit()
+}
+
+// appliedMigrations returns applied versions mapped to their applied_at time
+func appliedMigrations() (map[int]string, error) {
+	rows, err := DB.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := map[int]string{}
+	for rows.Next() {
+		var version int
+		var appliedAt string
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// prepareMigrationTable creates schema_migrations, baselining databases that
+// predate it so their existing tables are not created twice
+func prepareMigrationTable() error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	trackerExists, err := tableExists(tx, "schema_migrations")
+	if err != nil {
+		return err
+	}
+	if trackerExists {
+		return nil
+	}
+
+	legacy, err := tableExists(tx, "projects")
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`
+		CREATE TABLE schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			applied_at TEXT NOT NULL
+		)
+	`); err != nil {
+		return err
+	}
+
+	if legacy {
+		if err := baselineLegacySchema(tx); err != nil {
+			return fmt.Errorf("baseline existing database: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// baselineLegacySchema upgrades a database created by the old inline schema
+// code to the state of migrations up to legacyBaselineVersion, then records
+// those versions as applied. The baseline scripts only use IF NOT EXISTS, so
+// re-running them is safe; the column changes they cannot express are done here.
+func baselineLegacySchema(tx *sql.Tx) error {
+	all, err := LoadMigrations()
+	if err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for _, m := range all {
+		if m.Version > legacyBaselineVersion {
+			break
+		}
+
+		if _, err := tx.Exec(m.Up); err != nil {
+			return fmt.Errorf("migration %03d_%s: %w", m.Version, m.Name, err)
+		}
+
+		switch m.Version {
+		case 1:
+			for _, column := range []string{"linksReleasedAt", "linksReleasedBy"} {
+				if err := addColumnIfMissing(tx, "projects", column, "TEXT"); err != nil {
+					return err
+				}
+			}
+		case 4:
+			if err := migrateLegacyShares(tx); err != nil {
+				return err
+			}
+		}
+
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+			m.Version, m.Name, now); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateLegacyShares moves the old fixed share columns into partner_payouts
+// rows and drops them. Each legacy column pair becomes a partner with a stable id.
+func migrateLegacyShares(tx *sql.Tx) error {
+	legacyShares := []struct {
+		partnerID, partnerName, amountColumn, dateColumn string
+	}{
+		{"partner", "Partner", "partnerShareGiven", "partnerShareDate"},
+		{"harshk", "Harshk", "harshk_share_given", "harshk_share_date"},
+		{"nikku", "Nikku", "nikku_share_given", "nikku_share_date"},
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+
+	for _, share := range legacyShares {
+		exists, err := columnExists(tx, "projects", share.amountColumn)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			continue
+		}
+
+		if _, err := tx.Exec(`
+			INSERT OR IGNORE INTO partners (id, name, created_at) VALUES (?, ?, ?)
+		`, share.partnerID, share.partnerName, now); err != nil {
+			return err
+		}
+
+		if _, err := tx.Exec(`
+			INSERT INTO partner_payouts (id, project_id, partner_id, amount, paid_at, created_at)
+			SELECT lower(hex(randomblob(16))), id, ?, `+share.amountColumn+`, `+share.dateColumn+`, ?
+			FROM projects
+			WHERE COALESCE(`+share.amountColumn+`, 0) > 0
+		`, share.partnerID, now); err != nil {
+			return fmt.Errorf("migrate %s: %w", share.amountColumn, err)
+		}
+
+		for _, column := range []string{share.amountColumn, share.dateColumn} {
+			if _, err := tx.Exec(`ALTER TABLE projects DROP COLUMN ` + column); err != nil {
+				return fmt.Errorf("drop %s: %w", column, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+func tableExists(tx *sql.Tx, table string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&count)
+	return count > 0, err
+}
+
+// columnExists reports whether table has a column with the given name
+func columnExists(tx *sql.Tx, table, column string) (bool, error) {
+	var count int
+	err := tx.QueryRow(`SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?`, table, column).Scan(&count)
+	return count > 0, err
+}
+
+// addColumnIfMissing adds a column to table unless it already exists, since
+// SQLite lacks IF NOT EXISTS for ADD COLUMN
+func addColumnIfMissing(tx *sql.Tx, table, column, definition string) error {
+	exists, err := columnExists(tx, table, column)
+	if err != nil || exists {
+		return err
+	}
+	_, err = tx.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + definition)
+	return err
+}
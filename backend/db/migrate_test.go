@@ -0,0 +1,309 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// openTestDB opens an empty database in a temporary directory as DB
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := Open(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { Close() })
+}
+
+// appliedVersions returns the versions recorded as applied, in order
+func appliedVersions(t *testing.T) []int {
+	t.Helper()
+	states, err := MigrationStatus()
+	if err != nil {
+		t.Fatalf("migration status: %v", err)
+	}
+	versions := []int{}
+	for _, s := range states {
+		if s.AppliedAt != nil {
+			versions = append(versions, s.Version)
+		}
+	}
+	return versions
+}
+
+func tableExistsInDB(t *testing.T, table string) bool {
+	t.Helper()
+	var n int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&n); err != nil {
+		t.Fatalf("look up table %s: %v", table, err)
+	}
+	return n > 0
+}
+
+func TestLoadMigrations(t *testing.T) {
+	all, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if len(all) < legacyBaselineVersion {
+		t.Fatalf("%d migrations, want at least the %d of the legacy baseline", len(all), legacyBaselineVersion)
+	}
+	for i, m := range all {
+		if m.Version != i+1 {
+			t.Errorf("migration %d is numbered %d; versions must run 1, 2, 3… without gaps", i+1, m.Version)
+		}
+		if m.Up == "" || m.Down == "" {
+			t.Errorf("migration %03d_%s needs both an up and a down file", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrateFreshDatabase(t *testing.T) {
+	openTestDB(t)
+
+	all, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrate up: %v", err)
+	}
+	if n := len(appliedVersions(t)); n != len(all) {
+		t.Fatalf("%d migrations applied, want %d", n, len(all))
+	}
+
+	// Running again has nothing left to do
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrate up again: %v", err)
+	}
+
+	// Every migration reverts, one step at a time, and applies again
+	for i := len(all); i > 0; i-- {
+		if err := MigrateDown(1); err != nil {
+			t.Fatalf("migrate down from %d: %v", i, err)
+		}
+		if n := len(appliedVersions(t)); n != i-1 {
+			t.Fatalf("%d migrations applied after reverting %d, want %d", n, i, i-1)
+		}
+	}
+	if tableExistsInDB(t, "projects") {
+		t.Error("projects table remains after reverting every migration")
+	}
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrate up after reverting: %v", err)
+	}
+	if n := len(appliedVersions(t)); n != len(all) {
+		t.Fatalf("%d migrations applied, want %d", n, len(all))
+	}
+}
+
+// legacySchema is the schema the inline setup code created before the
+// migration runner, with its fixed share columns
+const legacySchema = `
+CREATE TABLE projects (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	clientName TEXT,
+	description TEXT,
+	type TEXT CHECK(type IN ('software','hardware','mixed')) NOT NULL,
+	createdAt TEXT NOT NULL,
+	startDate TEXT,
+	deadline TEXT NOT NULL,
+	completedAt TEXT,
+	deliveredAt TEXT,
+	totalAmount REAL NOT NULL,
+	advanceReceived REAL NOT NULL DEFAULT 0,
+	totalReceived REAL NOT NULL DEFAULT 0,
+	partnerShareGiven REAL DEFAULT 0,
+	partnerShareDate TEXT,
+	completionVideoLink TEXT,
+	completionNotes TEXT,
+	repoLink TEXT,
+	liveLink TEXT,
+	deliveryNotes TEXT,
+	techStack TEXT,
+	deliverables TEXT,
+	internalNotes TEXT,
+	harshk_share_given REAL DEFAULT 0,
+	harshk_share_date TEXT,
+	nikku_share_given REAL DEFAULT 0,
+	nikku_share_date TEXT
+);
+CREATE TABLE audit_logs (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL,
+	action TEXT NOT NULL,
+	field_name TEXT,
+	old_value TEXT,
+	new_value TEXT,
+	created_at TEXT NOT NULL
+);
+INSERT INTO projects (id, name, clientName, type, createdAt, deadline, totalAmount, advanceReceived, totalReceived,
+	partnerShareGiven, partnerShareDate, harshk_share_given, harshk_share_date)
+VALUES ('p1', 'Legacy', 'Acme', 'software', '2024-01-05T10:00:00Z', '2024-03-01', 5000.5, 1000, 1000,
+	250.25, '2024-02-01', 100, '2024-02-02');
+INSERT INTO projects (id, name, clientName, type, createdAt, deadline, totalAmount)
+VALUES ('p2', 'Second', 'Acme', 'software', '2024-02-05T10:00:00Z', '2024-05-01', 100),
+	('p3', 'Third', 'ACME Pvt. Ltd.', 'hardware', '2024-03-05T10:00:00Z', '2024-06-01', 100),
+	('p4', 'Fourth', 'Globex', 'mixed', '2024-04-05T10:00:00Z', '2024-07-01', 100);
+INSERT INTO audit_logs (id, project_id, action, field_name, old_value, new_value, created_at)
+VALUES ('a1', 'p1', 'PROJECT_CREATED', NULL, NULL, NULL, '2024-01-05T10:00:00Z'),
+	('a2', 'p1', 'PROJECT_UPDATED', 'name', 'Old', 'Legacy', '2024-01-06T10:00:00Z');
+`
+
+// openLegacyDB opens a database laid out by the inline setup code, holding
+// a project with partner shares, three more under variously spelled client
+// names, and two audit entries
+func openLegacyDB(t *testing.T) {
+	t.Helper()
+	openTestDB(t)
+	if _, err := DB.Exec(legacySchema); err != nil {
+		t.Fatalf("create legacy schema: %v", err)
+	}
+}
+
+func TestMigrateLegacyDatabase(t *testing.T) {
+	openLegacyDB(t)
+
+	if err := Migrate(); err != nil {
+		t.Fatalf("migrate legacy database: %v", err)
+	}
+
+	all, err := LoadMigrations()
+	if err != nil {
+		t.Fatalf("load migrations: %v", err)
+	}
+	if n := len(appliedVersions(t)); n != len(all) {
+		t.Errorf("%d migrations applied, want %d", n, len(all))
+	}
+
+	// The fixed share columns became payouts to a partner each
+	rows, err := DB.Query(`SELECT partner_id, amount FROM partner_payouts WHERE project_id = 'p1'`)
+	if err != nil {
+		t.Fatalf("read payouts: %v", err)
+	}
+	payouts := map[string]int64{}
+	for rows.Next() {
+		var partnerID string
+		var amount int64
+		if err := rows.Scan(&partnerID, &amount); err != nil {
+			t.Fatalf("scan payout: %v", err)
+		}
+		payouts[partnerID] = amount
+	}
+	rows.Close()
+	if len(payouts) != 2 || payouts["partner"] != 25025 || payouts["harshk"] != 10000 {
+		t.Errorf("payouts = %v, want partner 25025 and harshk 10000 paise", payouts)
+	}
+
+	tx, err := DB.Begin()
+	if err != nil {
+		t.Fatalf("begin: %v", err)
+	}
+	defer tx.Rollback()
+	for _, column := range []string{"partnerShareGiven", "harshk_share_given", "nikku_share_date"} {
+		if exists, err := columnExists(tx, "projects", column); err != nil || exists {
+			t.Errorf("column %s still exists (err %v)", column, err)
+		}
+	}
+	for _, column := range []string{"linksReleasedAt", "linksReleasedBy"} {
+		if exists, err := columnExists(tx, "projects", column); err != nil || !exists {
+			t.Errorf("column %s was not added (err %v)", column, err)
+		}
+	}
+	tx.Rollback()
+
+	// The amount received before the ledger existed opens it
+	var opening int64
+	if err := DB.QueryRow(`SELECT amount FROM payments WHERE project_id = 'p1'`).Scan(&opening); err != nil || opening != 100000 {
+		t.Errorf("opening payment = %d paise (err %v), want 100000", opening, err)
+	}
+
+	// Rupee amounts became whole paise
+	var totalAmount, totalReceived int64
+	err = DB.QueryRow(`SELECT totalAmount, totalReceived FROM projects WHERE id = 'p1'`).Scan(&totalAmount, &totalReceived)
+	if err != nil {
+		t.Fatalf("read migrated project: %v", err)
+	}
+	if totalAmount != 500050 || totalReceived != 100000 {
+		t.Errorf("totalAmount %d, totalReceived %d paise, want 500050 and 100000", totalAmount, totalReceived)
+	}
+
+	// Entries from before actors were recorded are attributed to the system
+	var entries int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM audit_logs WHERE actor_type = 'system' AND actor_id IS NULL`).Scan(&entries); err != nil || entries != 2 {
+		t.Errorf("%d system audit entries (err %v), want the 2 existing ones", entries, err)
+	}
+
+	// and were sealed into the hash chain in the order they were written
+	chain, err := VerifyAuditChain()
+	if err != nil {
+		t.Fatalf("verify audit chain: %v", err)
+	}
+	if !chain.Valid || chain.Entries != 2 {
+		t.Errorf("audit chain after migration = %+v, want 2 valid entries", chain)
+	}
+	var first string
+	if err := DB.QueryRow(`SELECT id FROM audit_logs WHERE seq = 1`).Scan(&first); err != nil || first != "a1" {
+		t.Errorf("first chained entry %q (err %v), want a1", first, err)
+	}
+
+	// Client names that differ only in case and company suffix became one
+	// client, named as most of its projects spell it
+	rows, err = DB.Query(`
+		SELECT p.id, c.name FROM projects p JOIN clients c ON c.id = p.clientId
+		WHERE p.clientName = c.name ORDER BY p.id
+	`)
+	if err != nil {
+		t.Fatalf("read clients: %v", err)
+	}
+	clients := map[string]string{}
+	for rows.Next() {
+		var projectID, name string
+		if err := rows.Scan(&projectID, &name); err != nil {
+			t.Fatalf("scan client: %v", err)
+		}
+		clients[projectID] = name
+	}
+	rows.Close()
+	want := map[string]string{"p1": "Acme", "p2": "Acme", "p3": "Acme", "p4": "Globex"}
+	if len(clients) != len(want) {
+		t.Errorf("linked clients = %v, want %v", clients, want)
+	}
+	for projectID, name := range want {
+		if clients[projectID] != name {
+			t.Errorf("%s is linked to %q, want %q", projectID, clients[projectID], name)
+		}
+	}
+	var clientCount int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM clients`).Scan(&clientCount); err != nil || clientCount != 2 {
+		t.Errorf("%d clients (err %v), want 2", clientCount, err)
+	}
+
+	// Existing projects start their version history at the version they are at
+	var versions int
+	var name string
+	if err := DB.QueryRow(`
+		SELECT COUNT(*), (SELECT json_extract(v.snapshot, '$.name') FROM project_versions v JOIN projects p
+			ON p.id = v.project_id AND p.version = v.version WHERE p.id = 'p1')
+		FROM project_versions
+	`).Scan(&versions, &name); err != nil || versions != 4 || name != "Legacy" {
+		t.Errorf("%d versions, p1 snapshot named %q (err %v), want 4 and Legacy", versions, name, err)
+	}
+
+	// Existing projects were indexed for search
+	var found string
+	if err := DB.QueryRow(`
+		SELECT p.id FROM project_search JOIN projects p ON p.rowid = project_search.rowid
+		WHERE project_search MATCH 'legacy'
+	`).Scan(&found); err != nil || found != "p1" {
+		t.Errorf("search for an existing project found %q (err %v), want p1", found, err)
+	}
+
+	// Existing projects were priced without GST, so they are not taxed now
+	var taxed int
+	if err := DB.QueryRow(`SELECT COUNT(*) FROM projects WHERE gstRate != 0`).Scan(&taxed); err != nil || taxed != 0 {
+		t.Errorf("%d existing projects have a GST rate (err %v), want none", taxed, err)
+	}
+}
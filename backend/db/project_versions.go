@@ -0,0 +1,123 @@
+package db
+
+import (
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"project-tracker/models"
+)
+
+// InsertProjectVersion stores a snapshot of p as part of tx, keyed by the
+// version it is at. Call it after the change has been written and p re-read,
+// so the snapshot matches the row and its ETag.
+func InsertProjectVersion(tx *sql.Tx, actor models.Actor, p *models.Project, createdAt string) error {
+	snapshot, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return insertVersion(tx, actor, p.ID, p.Version, snapshot, createdAt)
+}
+
+// insertVersion stores an encoded project snapshot under version
+func insertVersion(tx *sql.Tx, actor models.Actor, projectID string, version int64, snapshot []byte, createdAt string) error {
+	_, err := tx.Exec(`
+		INSERT INTO project_versions (project_id, version, snapshot, created_at, actor_id, actor_type)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, projectID, version, string(snapshot), createdAt, actor.ID, actor.Type)
+	return err
+}
+
+// SnapshotProject re-reads project id within tx and stores it at the version
+// it is now at. Changes that reach a project indirectly, such as a payment
+// moving its totalReceived, bump the version through the trigger and use it
+// to keep the history complete.
+func SnapshotProject(tx *sql.Tx, actor models.Actor, id, createdAt string) error {
+	var p models.Project
+	if err := p.Scan(tx.QueryRow(`SELECT `+models.ProjectColumns+` FROM projects WHERE id = ?`, id)); err != nil {
+		return err
+	}
+	return InsertProjectVersion(tx, actor, &p, createdAt)
+}
+
+// snapshotColumns are the projects table columns as they stood after
+// migration 016, in projectV16 field order
+const snapshotColumns = `id, name, clientName, description, type, createdAt, startDate, deadline,
+	completedAt, deliveredAt, totalAmount, advanceReceived, totalReceived,
+	completionVideoLink, completionNotes, repoLink, liveLink, deliveryNotes,
+	techStack, deliverables, internalNotes, linksReleasedAt, linksReleasedBy, currency, clientId, version, deletedAt`
+
+// projectV16 is a project as migration 016 found it, encoded as models.Project
+// was at the time. The migration hook reads into it rather than the live
+// model, which keeps gaining columns the table did not have yet.
+type projectV16 struct {
+	ID                  string       `json:"id"`
+	Name                string       `json:"name"`
+	ClientName          *string      `json:"clientName,omitempty"`
+	Description         *string      `json:"description,omitempty"`
+	Type                string       `json:"type"`
+	CreatedAt           string       `json:"createdAt"`
+	StartDate           *string      `json:"startDate,omitempty"`
+	Deadline            string       `json:"deadline"`
+	CompletedAt         *string      `json:"completedAt,omitempty"`
+	DeliveredAt         *string      `json:"deliveredAt,omitempty"`
+	TotalAmount         models.Money `json:"totalAmount"`
+	AdvanceReceived     models.Money `json:"advanceReceived"`
+	TotalReceived       models.Money `json:"totalReceived"`
+	CompletionVideoLink *string      `json:"completionVideoLink,omitempty"`
+	CompletionNotes     *string      `json:"completionNotes,omitempty"`
+	RepoLink            *string      `json:"repoLink,omitempty"`
+	LiveLink            *string      `json:"liveLink,omitempty"`
+	DeliveryNotes       *string      `json:"deliveryNotes,omitempty"`
+	TechStack           *string      `json:"techStack,omitempty"`
+	Deliverables        *string      `json:"deliverables,omitempty"`
+	InternalNotes       *string      `json:"internalNotes,omitempty"`
+	LinksReleasedAt     *string      `json:"linksReleasedAt,omitempty"`
+	LinksReleasedBy     *string      `json:"linksReleasedBy,omitempty"`
+	Currency            string       `json:"currency"`
+	ClientID            *string      `json:"clientId,omitempty"`
+	Version             int64        `json:"version"`
+	DeletedAt           *string      `json:"deletedAt,omitempty"`
+}
+
+func (p *projectV16) fields() []interface{} {
+	return []interface{}{&p.ID, &p.Name, &p.ClientName, &p.Description, &p.Type, &p.CreatedAt, &p.StartDate, &p.Deadline,
+		&p.CompletedAt, &p.DeliveredAt, &p.TotalAmount, &p.AdvanceReceived, &p.TotalReceived,
+		&p.CompletionVideoLink, &p.CompletionNotes, &p.RepoLink, &p.LiveLink, &p.DeliveryNotes,
+		&p.TechStack, &p.Deliverables, &p.InternalNotes, &p.LinksReleasedAt, &p.LinksReleasedBy,
+		&p.Currency, &p.ClientID, &p.Version, &p.DeletedAt}
+}
+
+// snapshotProjects starts the history of every existing project, trashed ones
+// included, with its current state. It runs as part of migration 016.
+func snapshotProjects(tx *sql.Tx) error {
+	rows, err := tx.Query(`SELECT ` + snapshotColumns + ` FROM projects`)
+	if err != nil {
+		return err
+	}
+	projects := []projectV16{}
+	for rows.Next() {
+		var p projectV16
+		if err := rows.Scan(p.fields()...); err != nil {
+			rows.Close()
+			return err
+		}
+		projects = append(projects, p)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	now := time.Now().UTC().Format(time.RFC3339)
+	for _, p := range projects {
+		snapshot, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		if err := insertVersion(tx, models.SystemActor, p.ID, p.Version, snapshot, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,10 @@
+package db
+
+// RebuildSearchIndex reindexes every project for full-text search. The
+// triggers keep the index current, so this is only needed after the index
+// has been lost or the projects table was changed without them, such as by a
+// VACUUM renumbering rows or a hand edit with triggers disabled.
+func RebuildSearchIndex() error {
+	_, err := DB.Exec(`INSERT INTO project_search (project_search) VALUES ('rebuild')`)
+	return err
+}
@@ -5,20 +5,36 @@ import (
 	"os"
 	"path/filepath"
 
+	"project-tracker/models"
+
 	_ "modernc.org/sqlite"
 )
 
 var DB *sql.DB
 
+// InitDB opens the database and applies any pending migrations
 func InitDB(dbPath string) error {
+	if err := Open(dbPath); err != nil {
+		return err
+	}
+	return Migrate()
+}
+
+// Open connects to the database without touching the schema
+func Open(dbPath string) error {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return err
 	}
 
+	// Per-connection settings go in the DSN so every pooled connection gets them:
+	// foreign keys, a busy timeout, and BEGIN IMMEDIATE so write transactions
+	// take the lock up front instead of failing when they upgrade from a read
+	dsn := dbPath + "?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_txlock=immediate"
+
 	var err error
-	DB, err = sql.Open("sqlite", dbPath)
+	DB, err = sql.Open("sqlite", dsn)
 	if err != nil {
 		return err
 	}
@@ -32,106 +48,53 @@ func InitDB(dbPath string) error {
 		return err
 	}
 
-	// Enable foreign keys (for future use)
-	if _, err = DB.Exec(`PRAGMA foreign_keys = ON;`); err != nil {
-		return err
-	}
-
-	// Set busy timeout
-	if _, err = DB.Exec(`PRAGMA busy_timeout = 5000;`); err != nil {
-		return err
-	}
-
-	// Run migrations
-	if err = runMigrations(); err != nil {
-		return err
-	}
-
 	return nil
 }
 
-func runMigrations() error {
-	migrationSQL := `
-	CREATE TABLE IF NOT EXISTS projects (
-		id TEXT PRIMARY KEY,
-		name TEXT NOT NULL,
-		clientName TEXT,
-		description TEXT,
-		type TEXT CHECK(type IN ('software','hardware','mixed')) NOT NULL,
-		createdAt TEXT NOT NULL,
-		startDate TEXT,
-		deadline TEXT NOT NULL,
-		completedAt TEXT,
-		deliveredAt TEXT,
-		totalAmount REAL NOT NULL,
-		advanceReceived REAL NOT NULL DEFAULT 0,
-		totalReceived REAL NOT NULL DEFAULT 0,
-		partnerShareGiven REAL DEFAULT 0,
-		partnerShareDate TEXT,
-		completionVideoLink TEXT,
-		completionNotes TEXT,
-		repoLink TEXT,
-		liveLink TEXT,
-		deliveryNotes TEXT,
-		techStack TEXT,
-		deliverables TEXT,
-		internalNotes TEXT
-	);
-	`
-
-	// Initial schema creation
-	if _, err := DB.Exec(migrationSQL); err != nil {
-		return err
-	}
-
-	auditLogsSQL := `
-	CREATE TABLE IF NOT EXISTS audit_logs (
-		id TEXT PRIMARY KEY,
-		project_id TEXT NOT NULL,
-		action TEXT NOT NULL,
-		field_name TEXT,
-		old_value TEXT,
-		new_value TEXT,
-		created_at TEXT NOT NULL
-	);
-	CREATE INDEX IF NOT EXISTS idx_audit_logs_project_id ON audit_logs(project_id);
-	CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
-	`
-
-	if _, err := DB.Exec(auditLogsSQL); err != nil {
+// InsertAuditLog writes an audit entry as part of tx, so the entry commits or
+// rolls back together with the change it describes. actor says who made it.
+// The entry is numbered and hashed onto the end of the audit chain; writes are
+// serialized by the immediate transaction lock, so the chain cannot fork.
+func InsertAuditLog(tx *sql.Tx, actor models.Actor, id, projectID, action string, fieldName, oldValue, newValue *string, createdAt string) error {
+	headSeq, prevHash, err := auditChainHead(tx)
+	if err != nil {
 		return err
 	}
 
-	// Safe migration: Add new partner share columns if they don't exist
-	// SQLite lacks IF NOT EXISTS for ADD COLUMN, so we ignore specific errors
-	migrations := []string{
-		`ALTER TABLE projects ADD COLUMN harshk_share_given REAL DEFAULT 0;`,
-		`ALTER TABLE projects ADD COLUMN harshk_share_date TEXT;`,
-		`ALTER TABLE projects ADD COLUMN nikku_share_given REAL DEFAULT 0;`,
-		`ALTER TABLE projects ADD COLUMN nikku_share_date TEXT;`,
+	e := auditEntry{
+		Seq:       headSeq + 1,
+		ID:        id,
+		ProjectID: projectID,
+		Action:    action,
+		FieldName: fieldName,
+		OldValue:  oldValue,
+		NewValue:  newValue,
+		CreatedAt: createdAt,
+		ActorID:   actor.ID,
+		ActorType: actor.Type,
+		IP:        actor.IP,
+		UserAgent: actor.UserAgent,
 	}
 
-	for _, query := range migrations {
-		_, err := DB.Exec(query)
-		if err != nil {
-			// Check if error is due to duplicate column
-			// modernc.org/sqlite and others usually mention "duplicate column name"
-			// We'll trust that if it fails, it's likely because it exists, or we can check explicitly.
-			// For now, logging it might be better, but we don't have logger passed here.
-			// We will implicitly ignore it if it contains "duplicate column"
-			// Implementation: just continue. If it's a real format error, it's harmless to ignore for now as these are specific ADD COLUMNs.
-		}
-	}
-
-	return nil
-}
-
-func InsertAuditLog(id, projectID, action string, fieldName, oldValue, newValue *string, createdAt string) error {
 	query := `
-		INSERT INTO audit_logs (id, project_id, action, field_name, old_value, new_value, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?)
+		INSERT INTO audit_logs (` + auditChainColumns + `, prev_hash, hash)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
-	_, err := DB.Exec(query, id, projectID, action, fieldName, oldValue, newValue, createdAt)
+	_, err = tx.Exec(query, e.Seq, e.ID, e.ProjectID, e.Action, e.FieldName, e.OldValue, e.NewValue,
+		e.CreatedAt, e.ActorID, e.ActorType, e.IP, e.UserAgent, prevHash, e.hash(prevHash))
+	return err
+}
+
+// RecalculateTotalReceived sets a project's totalReceived to the sum of its
+// payments, counting TDS deducted by the client as received, and totalTds to
+// the TDS part of it.
+func RecalculateTotalReceived(tx *sql.Tx, projectID string) error {
+	_, err := tx.Exec(`
+		UPDATE projects
+		SET totalReceived = (SELECT COALESCE(SUM(amount + tds_amount), 0) FROM payments WHERE project_id = ?),
+			totalTds = (SELECT COALESCE(SUM(tds_amount), 0) FROM payments WHERE project_id = ?)
+		WHERE id = ?
+	`, projectID, projectID, projectID)
 	return err
 }
 
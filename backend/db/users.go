@@ -0,0 +1,33 @@
+package db
+
+import "errors"
+
+// ErrUsersExist is returned by BootstrapAdmin once any user has been created
+var ErrUsersExist = errors.New("users already exist; bootstrap only creates the first admin")
+
+// BootstrapAdmin creates the first user as an admin. It refuses to run when
+// the users table is not empty, so it cannot be used to take over an install.
+func BootstrapAdmin(id, email, name, passwordHash, createdAt string) error {
+	tx, err := DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var count int
+	if err := tx.QueryRow(`SELECT COUNT(*) FROM users`).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrUsersExist
+	}
+
+	if _, err := tx.Exec(`
+		INSERT INTO users (id, email, name, password_hash, role, created_at)
+		VALUES (?, ?, ?, ?, 'admin', ?)
+	`, id, email, name, passwordHash, createdAt); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
@@ -5,6 +5,7 @@ go 1.24.0
 require (
 	github.com/google/uuid v1.6.0
 	github.com/gorilla/mux v1.8.1
+	golang.org/x/crypto v0.42.0
 	modernc.org/sqlite v1.41.0
 )
 
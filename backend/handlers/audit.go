@@ -0,0 +1,330 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/gorilla/mux"
+)
+
+const auditColumns = `id, project_id, action, field_name, old_value, new_value, created_at, actor_id, actor_type, ip, user_agent, seq, prev_hash, hash`
+
+const (
+	defaultAuditLimit = 50
+	maxAuditLimit     = 500
+)
+
+// AuditPage is a page of audit entries along with the total matching count
+type AuditPage struct {
+	Entries []models.AuditLog `json:"entries"`
+	Total   int               `json:"total"`
+	Limit   int               `json:"limit"`
+	Offset  int               `json:"offset"`
+}
+
+// ClientIPHeader names a header set by a trusted reverse proxy that carries the
+// client's address, such as X-Forwarded-For or X-Real-IP. When empty the
+// connection's remote address is recorded instead. It is set from the
+// CLIENT_IP_HEADER environment variable at startup.
+var ClientIPHeader string
+
+// auditActor returns who is making r, for the audit entries it writes
+func auditActor(r *http.Request) models.Actor {
+	actor := models.Actor{Type: models.ActorSystem}
+
+	if tokenID, _ := r.Context().Value(tokenIDContextKey).(string); tokenID != "" {
+		actor.Type = models.ActorToken
+		actor.ID = &tokenID
+	} else if user := CurrentUser(r); user != nil {
+		actor.Type = models.ActorUser
+		actor.ID = &user.ID
+	}
+
+	if ip := clientIP(r); ip != "" {
+		actor.IP = &ip
+	}
+	if ua := r.UserAgent(); ua != "" {
+		actor.UserAgent = &ua
+	}
+	return actor
+}
+
+// clientIP returns the address a request came from. A proxy appends to
+// X-Forwarded-For, so its last entry is the one our proxy saw.
+func clientIP(r *http.Request) string {
+	if ClientIPHeader != "" {
+		if value := r.Header.Get(ClientIPHeader); value != "" {
+			parts := strings.Split(value, ",")
+			return strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func GetAuditLogs(w http.ResponseWriter, r *http.Request) {
+	listAuditLogs(w, r, "")
+}
+
+// GetProjectAuditLogs returns the audit trail of a single project. It does not
+// require the project to still exist, so deleted projects remain traceable.
+func GetProjectAuditLogs(w http.ResponseWriter, r *http.Request) {
+	listAuditLogs(w, r, mux.Vars(r)["id"])
+}
+
+// listAuditLogs applies the action, actor, field, from, to, limit and offset query
+// parameters and writes the matching page, newest first
+func listAuditLogs(w http.ResponseWriter, r *http.Request, projectID string) {
+	where, args, ok := parseAuditFilters(w, r, projectID)
+	if !ok {
+		return
+	}
+
+	limit, offset, ok := parsePagination(w, r, defaultAuditLimit, maxAuditLimit)
+	if !ok {
+		return
+	}
+
+	page := AuditPage{Entries: []models.AuditLog{}, Limit: limit, Offset: offset}
+
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM audit_logs `+where, args...).Scan(&page.Total); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count audit logs")
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT `+auditColumns+`
+		FROM audit_logs
+		`+where+`
+		ORDER BY seq DESC
+		LIMIT ? OFFSET ?
+	`, append(args, limit, offset)...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch audit logs")
+		return
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := entry.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan audit log")
+			return
+		}
+		page.Entries = append(page.Entries, entry)
+	}
+
+	respondJSON(w, http.StatusOK, page)
+}
+
+// parseAuditFilters reads the action, actor, field, from and to query
+// parameters into a WHERE clause, limited to projectID when it is set. It
+// writes a 400 and returns ok=false when a date is malformed.
+func parseAuditFilters(w http.ResponseWriter, r *http.Request, projectID string) (string, []interface{}, bool) {
+	q := r.URL.Query()
+
+	conditions := []string{}
+	args := []interface{}{}
+
+	if projectID != "" {
+		conditions = append(conditions, "project_id = ?")
+		args = append(args, projectID)
+	}
+	if action := q.Get("action"); action != "" {
+		conditions = append(conditions, "action = ?")
+		args = append(args, action)
+	}
+	if actor := q.Get("actor"); actor != "" {
+		conditions = append(conditions, "actor_id = ?")
+		args = append(args, actor)
+	}
+	if field := q.Get("field"); field != "" {
+		conditions = append(conditions, "field_name = ?")
+		args = append(args, field)
+	}
+	if from := q.Get("from"); from != "" {
+		if !validateISODate(from) {
+			respondError(w, http.StatusBadRequest, "from must be in ISO format (YYYY-MM-DD or RFC3339)")
+			return "", nil, false
+		}
+		conditions = append(conditions, "created_at >= ?")
+		args = append(args, from)
+	}
+	if to := q.Get("to"); to != "" {
+		if !validateISODate(to) {
+			respondError(w, http.StatusBadRequest, "to must be in ISO format (YYYY-MM-DD or RFC3339)")
+			return "", nil, false
+		}
+		// A bare date includes the whole day
+		if day, err := time.Parse("2006-01-02", to); err == nil {
+			conditions = append(conditions, "created_at < ?")
+			args = append(args, day.AddDate(0, 0, 1).Format("2006-01-02"))
+		} else {
+			conditions = append(conditions, "created_at <= ?")
+			args = append(args, to)
+		}
+	}
+
+	if len(conditions) == 0 {
+		return "", args, true
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args, true
+}
+
+// VerifyAuditLog walks the audit hash chain and reports the first broken link
+func VerifyAuditLog(w http.ResponseWriter, r *http.Request) {
+	result, err := db.VerifyAuditChain()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to verify audit log")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// parsePagination reads limit and offset query parameters, writing a 400 and
+// returning ok=false when either is malformed
+func parsePagination(w http.ResponseWriter, r *http.Request, defaultLimit, maxLimit int) (limit, offset int, ok bool) {
+	limit = defaultLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return 0, 0, false
+		}
+		limit = min(n, maxLimit)
+	}
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			respondError(w, http.StatusBadRequest, "offset must be a non-negative integer")
+			return 0, 0, false
+		}
+		offset = n
+	}
+
+	return limit, offset, true
+}
+
+// fieldChange is a single field difference between two versions of a project
+type fieldChange struct {
+	Field    string
+	OldValue *string
+	NewValue *string
+}
+
+// deletedProjectSnapshot is stored on PROJECT_DELETED entries so a deleted
+// project and its ledger can be recovered from the audit log
+type deletedProjectSnapshot struct {
+	Project  models.Project         `json:"project"`
+	Payments []models.Payment       `json:"payments"`
+	Payouts  []models.PartnerPayout `json:"payouts"`
+}
+
+// diffProjectFields compares every updatable field of two project versions and
+// returns the changes in field name order
+func diffProjectFields(old, new models.Project) []fieldChange {
+	oldValues := projectFieldValues(old)
+	newValues := projectFieldValues(new)
+
+	fields := make([]string, 0, len(projectFieldMap))
+	for field := range projectFieldMap {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	changes := []fieldChange{}
+	for _, field := range fields {
+		oldVal, newVal := oldValues[field], newValues[field]
+		if sameAuditValue(oldVal, newVal) {
+			continue
+		}
+		changes = append(changes, fieldChange{Field: field, OldValue: oldVal, NewValue: newVal})
+	}
+	return changes
+}
+
+// sameAuditValue reports whether two rendered field values are equal, with
+// nil equal only to nil
+func sameAuditValue(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// sameFieldValue reports whether a value from a partial update equals the
+// current rendering of field in values, as produced by projectFieldValues
+func sameFieldValue(values map[string]*string, field string, value interface{}) bool {
+	current := values[field]
+
+	var incoming *string
+	switch v := value.(type) {
+	case nil:
+	case string:
+		incoming = &v
+	case json.Number:
+		str := v.String()
+		if amount, err := models.ParseMoney(str); err == nil {
+			str = amount.String()
+		}
+		incoming = &str
+	default:
+		str := fmt.Sprint(v)
+		incoming = &str
+	}
+
+	if current == nil || incoming == nil {
+		return current == nil && incoming == nil
+	}
+	return *current == *incoming
+}
+
+// projectFieldValues renders a project's fields as audit strings keyed by JSON
+// field name; absent or null fields map to nil
+func projectFieldValues(p models.Project) map[string]*string {
+	values := map[string]*string{}
+
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return values
+	}
+	// Keep numbers as written so money values are recorded exactly
+	var fields map[string]interface{}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	if err := dec.Decode(&fields); err != nil {
+		return values
+	}
+
+	for field, value := range fields {
+		var str string
+		switch v := value.(type) {
+		case nil:
+			continue
+		case string:
+			str = v
+		case json.Number:
+			str = v.String()
+		default:
+			str = fmt.Sprint(v)
+		}
+		values[field] = &str
+	}
+	return values
+}
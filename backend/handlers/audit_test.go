@@ -0,0 +1,251 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/gorilla/mux"
+)
+
+// show renders an optional audit value for messages
+func show(s *string) string {
+	if s == nil {
+		return "<nil>"
+	}
+	return *s
+}
+
+func TestListAuditLogs(t *testing.T) {
+	openTestDB(t)
+	str := func(s string) *string { return &s }
+	tx, err := db.DB.Begin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range []struct {
+		id, project, action     string
+		field, oldValue, newVal *string
+		createdAt               string
+	}{
+		{"a1", "p1", "PROJECT_CREATED", nil, nil, nil, "2026-01-01T09:00:00Z"},
+		{"a2", "p1", "PROJECT_UPDATED", str("name"), str("Old"), str("New"), "2026-01-02T09:00:00Z"},
+		{"a3", "p1", "PROJECT_UPDATED", str("deadline"), str("2026-05-01"), str("2026-06-01"), "2026-01-02T18:00:00Z"},
+		{"a4", "p2", "PROJECT_CREATED", nil, nil, nil, "2026-01-03T09:00:00Z"},
+		{"a5", "p2", "PAYMENT_ADDED", str("amount"), nil, str("500"), "2026-01-04T09:00:00Z"},
+	} {
+		if err := db.InsertAuditLog(tx, models.SystemActor, e.id, e.project, e.action, e.field, e.oldValue, e.newVal, e.createdAt); err != nil {
+			t.Fatalf("seed audit logs: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		target  string
+		project string
+		ids     []string
+		total   int
+	}{
+		{"newest first", "/api/audit", "", []string{"a5", "a4", "a3", "a2", "a1"}, 5},
+		{"by action", "/api/audit?action=PROJECT_CREATED", "", []string{"a4", "a1"}, 2},
+		{"by field", "/api/audit?field=name", "", []string{"a2"}, 1},
+		{"a bare to date includes the day", "/api/audit?from=2026-01-02&to=2026-01-02", "", []string{"a3", "a2"}, 2},
+		{"from a time", "/api/audit?from=2026-01-02T12:00:00Z", "", []string{"a5", "a4", "a3"}, 3},
+		{"paged", "/api/audit?limit=2&offset=1", "", []string{"a4", "a3"}, 5},
+		{"one project", "/api/projects/p1/audit", "p1", []string{"a3", "a2", "a1"}, 3},
+		{"one project by action", "/api/projects/p2/audit?action=PAYMENT_ADDED", "p2", []string{"a5"}, 1},
+		{"a deleted project stays traceable", "/api/projects/p9/audit", "p9", []string{}, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler, vars := GetAuditLogs, map[string]string(nil)
+			if tt.project != "" {
+				handler, vars = GetProjectAuditLogs, map[string]string{"id": tt.project}
+			}
+
+			var page AuditPage
+			decode(t, serveGET(handler, tt.target, vars), http.StatusOK, &page)
+			ids := []string{}
+			for _, entry := range page.Entries {
+				ids = append(ids, entry.ID)
+			}
+			if len(ids) != len(tt.ids) || page.Total != tt.total {
+				t.Fatalf("got %v of %d, want %v of %d", ids, page.Total, tt.ids, tt.total)
+			}
+			for i := range ids {
+				if ids[i] != tt.ids[i] {
+					t.Fatalf("got %v, want %v", ids, tt.ids)
+				}
+			}
+		})
+	}
+
+	for _, target := range []string{"/api/audit?limit=0", "/api/audit?offset=-1", "/api/audit?from=yesterday", "/api/audit?to=31-12-2026"} {
+		if w := serveGET(GetAuditLogs, target, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestDiffProjectFields(t *testing.T) {
+	notes, other := "Call first", "Call later"
+	old := models.Project{ID: "p1", Name: "Site", Type: "software", Deadline: "2026-05-01", TotalAmount: 100000, InternalNotes: &notes}
+
+	tests := []struct {
+		name   string
+		change func(p *models.Project)
+		want   []string
+	}{
+		{"nothing", func(p *models.Project) {}, []string{}},
+		{"computed fields are not audited", func(p *models.Project) { p.TotalReceived = 50000; p.Status = "In Progress" }, []string{}},
+		{"one field", func(p *models.Project) { p.Deadline = "2026-06-01" }, []string{"deadline: 2026-05-01 -> 2026-06-01"}},
+		{"amounts", func(p *models.Project) { p.TotalAmount = 125050 }, []string{"totalAmount: 1000 -> 1250.5"}},
+		{"set", func(p *models.Project) { p.ClientName = &other }, []string{"clientName: <nil> -> Call later"}},
+		{"cleared", func(p *models.Project) { p.InternalNotes = nil }, []string{"internalNotes: Call first -> <nil>"}},
+		{"in field order", func(p *models.Project) { p.Name = "Shop"; p.InternalNotes = &other }, []string{
+			"internalNotes: Call first -> Call later",
+			"name: Site -> Shop",
+		}},
+	}
+
+	for _, tt := range tests {
+		updated := old
+		tt.change(&updated)
+		got := []string{}
+		for _, c := range diffProjectFields(old, updated) {
+			got = append(got, c.Field+": "+show(c.OldValue)+" -> "+show(c.NewValue))
+		}
+		if strings.Join(got, "; ") != strings.Join(tt.want, "; ") {
+			t.Errorf("%s: changes %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDeleteProjectKeepsSnapshot(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{"name": "Doomed", "totalReceived": 300}`)["id"].(string)
+	vars := map[string]string{"id": id}
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 200, "date": "2026-02-01"}`), http.StatusCreated, nil)
+
+	// The snapshot is taken when the project leaves the trash for good
+	decode(t, serve(DeleteProject, "DELETE", vars, ""), http.StatusOK, nil)
+	db.DB.Exec(`UPDATE projects SET deletedAt = '2026-01-01T00:00:00Z' WHERE id = ?`, id)
+	if n, err := PurgeTrash(24 * time.Hour); err != nil || n != 1 {
+		t.Fatalf("purge: %d projects, %v", n, err)
+	}
+
+	var page AuditPage
+	decode(t, serveGET(GetProjectAuditLogs, "/api/projects/"+id+"/audit?action=PROJECT_DELETED", vars), http.StatusOK, &page)
+	if len(page.Entries) != 1 || page.Entries[0].OldValue == nil {
+		t.Fatalf("PROJECT_DELETED entries = %+v, want one with a snapshot", page.Entries)
+	}
+
+	var snapshot deletedProjectSnapshot
+	if err := json.Unmarshal([]byte(*page.Entries[0].OldValue), &snapshot); err != nil {
+		t.Fatalf("decode snapshot: %v", err)
+	}
+	if snapshot.Project.Name != "Doomed" || len(snapshot.Payments) != 2 {
+		t.Errorf("snapshot = %+v, want the project with its 2 payments", snapshot)
+	}
+}
+
+func TestWritesRollBackWithoutTheirAuditEntry(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{"name": "Kept", "totalReceived": 100}`)["id"].(string)
+	vars := map[string]string{"id": id}
+
+	// A successful update has its entries written by the time it returns
+	decode(t, updateProject(testAdmin, vars, `{"name": "Renamed", "deadline": "2027-01-31"}`), http.StatusOK, nil)
+	var page AuditPage
+	decode(t, serveGET(GetProjectAuditLogs, "/api/projects/"+id+"/audit?action=PROJECT_UPDATED", vars), http.StatusOK, &page)
+	if page.Total != 2 {
+		t.Errorf("%d PROJECT_UPDATED entries, want 2", page.Total)
+	}
+
+	// With the audit log refusing entries, no change may be saved without one
+	if _, err := db.DB.Exec(`CREATE TRIGGER audit_refuses BEFORE INSERT ON audit_logs BEGIN SELECT RAISE(ABORT, 'refused'); END`); err != nil {
+		t.Fatalf("create trigger: %v", err)
+	}
+
+	writes := []struct {
+		name string
+		send func() *httptest.ResponseRecorder
+	}{
+		{"update", func() *httptest.ResponseRecorder { return updateProject(testAdmin, vars, `{"name": "Lost"}`) }},
+		{"payment", func() *httptest.ResponseRecorder { return serve(CreatePayment, "POST", vars, `{"amount": 50}`) }},
+		{"release", func() *httptest.ResponseRecorder { return serve(ReleaseLinks, "POST", vars, `{"releasedBy": "Priya"}`) }},
+		{"delete", func() *httptest.ResponseRecorder { return serve(DeleteProject, "DELETE", vars, "") }},
+	}
+	for _, write := range writes {
+		if w := write.send(); w.Code != http.StatusInternalServerError {
+			t.Errorf("%s: status %d, want 500", write.name, w.Code)
+		}
+	}
+
+	got := getProject(t, id)
+	if got["name"] != "Renamed" || got["totalReceived"] != 100.0 || got["linksReleasedAt"] != nil {
+		t.Errorf("project = %v, want it unchanged by the failed writes", got)
+	}
+	var payments []map[string]interface{}
+	decode(t, serve(GetPayments, "GET", vars, ""), http.StatusOK, &payments)
+	if len(payments) != 1 {
+		t.Errorf("%d payments, want only the opening balance", len(payments))
+	}
+}
+
+func TestAuditEntriesRecordTheActor(t *testing.T) {
+	openTestDB(t)
+	createAdmin(t, "admin@example.com", "correct horse")
+	session := login(t, "admin@example.com", "correct horse")
+	token := createToken(t, session, `{"name": "Sync", "scope": "write"}`)
+
+	project := createProject(t, `{}`)
+	vars := map[string]string{"id": project["id"].(string)}
+
+	// By session: the user, from the connection's address
+	r := httptest.NewRequest("PUT", "/", strings.NewReader(`{"name": "By user"}`))
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session})
+	r.Header.Set("User-Agent", "browser/1.0")
+	r.Header.Set("If-Match", "*")
+	r = mux.SetURLVars(r, vars)
+	RequireAuth(http.HandlerFunc(UpdateProject)).ServeHTTP(httptest.NewRecorder(), r)
+
+	// By API token, behind a proxy: the token, from the proxy's last hop
+	ClientIPHeader = "X-Forwarded-For"
+	t.Cleanup(func() { ClientIPHeader = "" })
+	r = httptest.NewRequest("PUT", "/", strings.NewReader(`{"name": "By token"}`))
+	r.Header.Set("Authorization", "Bearer "+token["token"].(string))
+	r.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7")
+	r.Header.Set("If-Match", "*")
+	r = mux.SetURLVars(r, vars)
+	RequireAuth(http.HandlerFunc(UpdateProject)).ServeHTTP(httptest.NewRecorder(), r)
+
+	var page AuditPage
+	decode(t, serveGET(GetProjectAuditLogs, "/?field=name", vars), http.StatusOK, &page)
+	if len(page.Entries) != 2 {
+		t.Fatalf("%d name changes audited, want 2", len(page.Entries))
+	}
+	byToken, byUser := page.Entries[0], page.Entries[1]
+
+	if byUser.ActorType != models.ActorUser || show(byUser.ActorID) != "u1" || show(byUser.IP) != "192.0.2.1" || show(byUser.UserAgent) != "browser/1.0" {
+		t.Errorf("session change actor = %s %s %s %s", byUser.ActorType, show(byUser.ActorID), show(byUser.IP), show(byUser.UserAgent))
+	}
+	if byToken.ActorType != models.ActorToken || show(byToken.ActorID) != token["id"] || show(byToken.IP) != "198.51.100.7" {
+		t.Errorf("token change actor = %s %s %s", byToken.ActorType, show(byToken.ActorID), show(byToken.IP))
+	}
+
+	decode(t, serveGET(GetAuditLogs, "/?actor=u1", nil), http.StatusOK, &page)
+	if page.Total != 1 || page.Entries[0].ID != byUser.ID {
+		t.Errorf("actor filter = %d entries, want the session change", page.Total)
+	}
+}
@@ -0,0 +1,296 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	sessionCookieName = "session"
+	sessionDuration   = 7 * 24 * time.Hour
+)
+
+const userColumns = `id, email, name, password_hash, role, created_at, partner_id`
+
+// SecureCookies marks session cookies Secure, so browsers only send them over
+// HTTPS. It is set from the COOKIE_SECURE environment variable at startup.
+var SecureCookies bool
+
+type contextKey string
+
+const (
+	userContextKey    contextKey = "user"
+	scopeContextKey   contextKey = "scope"
+	tokenIDContextKey contextKey = "tokenID"
+)
+
+// dummyHash is compared against when a login email is unknown, so that a
+// failed lookup takes as long as a wrong password
+var dummyHash = sync.OnceValue(func() []byte {
+	hash, _ := bcrypt.GenerateFromPassword([]byte("not a real password"), bcrypt.DefaultCost)
+	return hash
+})
+
+// CurrentUser returns the user authenticated by RequireAuth
+func CurrentUser(r *http.Request) *models.User {
+	user, _ := r.Context().Value(userContextKey).(*models.User)
+	return user
+}
+
+// hashToken returns the value stored for a session or API token in place of
+// the token itself
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// newSecret returns 32 random bytes encoded for use in a cookie or header
+func newSecret() (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
+
+// RequireAuth rejects requests that carry neither a valid session cookie nor a
+// valid Bearer API token, and stores the authenticated user on the request
+// context. Read-scoped tokens are limited to safe methods here; other scope
+// checks are made by the handlers that need them.
+func RequireAuth(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if header := r.Header.Get("Authorization"); header != "" {
+			token, ok := strings.CutPrefix(header, "Bearer ")
+			if !ok || token == "" {
+				respondError(w, http.StatusUnauthorized, "Authorization header must be a Bearer token")
+				return
+			}
+
+			user, tokenID, scope, ok := authenticateToken(w, token)
+			if !ok {
+				return
+			}
+			if scope == models.ScopeRead && !isSafeMethod(r.Method) {
+				respondError(w, http.StatusForbidden, "This API token is read-only")
+				return
+			}
+
+			ctx := context.WithValue(r.Context(), userContextKey, user)
+			ctx = context.WithValue(ctx, scopeContextKey, scope)
+			ctx = context.WithValue(ctx, tokenIDContextKey, tokenID)
+			next.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+
+		cookie, err := r.Cookie(sessionCookieName)
+		if err != nil || cookie.Value == "" {
+			respondError(w, http.StatusUnauthorized, "Authentication required")
+			return
+		}
+
+		var user models.User
+		err = user.Scan(db.DB.QueryRow(`
+			SELECT u.id, u.email, u.name, u.password_hash, u.role, u.created_at, u.partner_id
+			FROM sessions s
+			JOIN users u ON u.id = s.user_id
+			WHERE s.id = ? AND s.expires_at > ?
+		`, hashToken(cookie.Value), time.Now().UTC().Format(time.RFC3339)))
+
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusUnauthorized, "Session expired or invalid")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to check session")
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), userContextKey, &user)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// authenticateToken resolves a Bearer token to its user, id and scope,
+// recording the use. On failure it writes the response and returns ok=false.
+func authenticateToken(w http.ResponseWriter, token string) (user *models.User, tokenID, scope string, ok bool) {
+	var (
+		expiresAt *string
+		revokedAt *string
+		u         models.User
+	)
+	err := db.DB.QueryRow(`
+		SELECT t.id, t.scope, t.expires_at, t.revoked_at,
+		       u.id, u.email, u.name, u.password_hash, u.role, u.created_at, u.partner_id
+		FROM api_tokens t
+		JOIN users u ON u.id = t.user_id
+		WHERE t.token_hash = ?
+	`, hashToken(token)).Scan(&tokenID, &scope, &expiresAt, &revokedAt,
+		&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.PartnerID)
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusUnauthorized, "Invalid API token")
+		return nil, "", "", false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check API token")
+		return nil, "", "", false
+	}
+
+	now := time.Now().UTC()
+	if revokedAt != nil {
+		respondError(w, http.StatusUnauthorized, "API token has been revoked")
+		return nil, "", "", false
+	}
+	if expiresAt != nil && *expiresAt <= now.Format(time.RFC3339) {
+		respondError(w, http.StatusUnauthorized, "API token has expired")
+		return nil, "", "", false
+	}
+
+	// Record usage at most once a minute to keep busy scripts from turning
+	// every read into a write
+	if _, err := db.DB.Exec(`
+		UPDATE api_tokens SET last_used_at = ?
+		WHERE id = ? AND (last_used_at IS NULL OR last_used_at < ?)
+	`, now.Format(time.RFC3339), tokenID, now.Add(-time.Minute).Format(time.RFC3339)); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record API token use")
+		return nil, "", "", false
+	}
+
+	return &u, tokenID, scope, true
+}
+
+// tokenScope returns the scope of the API token that authenticated r, or ""
+// for a browser session
+func tokenScope(r *http.Request) string {
+	scope, _ := r.Context().Value(scopeContextKey).(string)
+	return scope
+}
+
+func isSafeMethod(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+func Login(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Email    string `json:"email"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if strings.TrimSpace(req.Email) == "" || req.Password == "" {
+		respondError(w, http.StatusBadRequest, "email and password are required")
+		return
+	}
+
+	var user models.User
+	err := user.Scan(db.DB.QueryRow(`
+		SELECT `+userColumns+`
+		FROM users
+		WHERE email = ? COLLATE NOCASE
+	`, strings.TrimSpace(req.Email)))
+
+	if err == sql.ErrNoRows {
+		bcrypt.CompareHashAndPassword(dummyHash(), []byte(req.Password))
+		respondError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+	if !user.CheckPassword(req.Password) {
+		respondError(w, http.StatusUnauthorized, "Invalid email or password")
+		return
+	}
+
+	token, err := newSecret()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	now := time.Now().UTC()
+	expires := now.Add(sessionDuration)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+	defer tx.Rollback()
+
+	// Expired sessions are only useful as clutter; drop them as we go
+	if _, err := tx.Exec(`DELETE FROM sessions WHERE expires_at <= ?`, now.Format(time.RFC3339)); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO sessions (id, user_id, created_at, expires_at)
+		VALUES (?, ?, ?, ?)
+	`, hashToken(token), user.ID, now.Format(time.RFC3339), expires.Format(time.RFC3339))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create session")
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		HttpOnly: true,
+		Secure:   SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	respondJSON(w, http.StatusOK, user)
+}
+
+func Logout(w http.ResponseWriter, r *http.Request) {
+	if cookie, err := r.Cookie(sessionCookieName); err == nil {
+		if _, err := db.DB.Exec(`DELETE FROM sessions WHERE id = ?`, hashToken(cookie.Value)); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to end session")
+			return
+		}
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     sessionCookieName,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Logged out"})
+}
+
+// GetCurrentUser returns the signed-in user
+func GetCurrentUser(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, CurrentUser(r))
+}
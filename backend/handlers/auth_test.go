@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+// createAdmin bootstraps the first user with the given credentials
+func createAdmin(t *testing.T, email, password string) {
+	t.Helper()
+	hash, err := models.HashPassword(password)
+	if err != nil {
+		t.Fatalf("hash password: %v", err)
+	}
+	if err := db.BootstrapAdmin("u1", email, "Admin", hash, time.Now().UTC().Format(time.RFC3339)); err != nil {
+		t.Fatalf("bootstrap admin: %v", err)
+	}
+}
+
+// serveAuthed sends a request through RequireAuth to handler, with the
+// session cookie given unless it is empty
+func serveAuthed(handler http.HandlerFunc, method, session string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", nil)
+	if session != "" {
+		r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session})
+	}
+	w := httptest.NewRecorder()
+	RequireAuth(handler).ServeHTTP(w, r)
+	return w
+}
+
+// login signs in and returns the session cookie value
+func login(t *testing.T, email, password string) string {
+	t.Helper()
+	w := serve(Login, "POST", nil, `{"email": "`+email+`", "password": "`+password+`"}`)
+	decode(t, w, http.StatusOK, nil)
+	for _, c := range w.Result().Cookies() {
+		if c.Name == sessionCookieName {
+			if !c.HttpOnly {
+				t.Error("session cookie is not HttpOnly")
+			}
+			return c.Value
+		}
+	}
+	t.Fatal("login set no session cookie")
+	return ""
+}
+
+func TestSessionAuthentication(t *testing.T) {
+	openTestDB(t)
+	createAdmin(t, "admin@example.com", "correct horse")
+
+	if err := db.BootstrapAdmin("u2", "other@example.com", "Other", "x", time.Now().UTC().Format(time.RFC3339)); !errors.Is(err, db.ErrUsersExist) {
+		t.Errorf("second bootstrap: %v, want ErrUsersExist", err)
+	}
+
+	for _, tc := range []struct{ name, body string }{
+		{"wrong password", `{"email": "admin@example.com", "password": "wrong horse"}`},
+		{"unknown email", `{"email": "nobody@example.com", "password": "correct horse"}`},
+	} {
+		if w := serve(Login, "POST", nil, tc.body); w.Code != http.StatusUnauthorized {
+			t.Errorf("login with %s: status %d, want 401", tc.name, w.Code)
+		}
+	}
+	if w := serve(Login, "POST", nil, `{"email": "admin@example.com"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("login without password: status %d, want 400", w.Code)
+	}
+
+	if w := serveAuthed(GetCurrentUser, "GET", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("no cookie: status %d, want 401", w.Code)
+	}
+	if w := serveAuthed(GetCurrentUser, "GET", "forged"); w.Code != http.StatusUnauthorized {
+		t.Errorf("unknown session: status %d, want 401", w.Code)
+	}
+
+	// The email is matched case-insensitively
+	session := login(t, "Admin@Example.com", "correct horse")
+
+	var me map[string]interface{}
+	decode(t, serveAuthed(GetCurrentUser, "GET", session), http.StatusOK, &me)
+	if me["email"] != "admin@example.com" || me["role"] != models.RoleAdmin {
+		t.Errorf("current user = %v", me)
+	}
+	if strings.Contains(serveAuthed(GetCurrentUser, "GET", session).Body.String(), "$2a$") {
+		t.Error("current user exposes the password hash")
+	}
+
+	// The cookie itself is never stored, only its hash
+	var stored int
+	db.DB.QueryRow(`SELECT COUNT(*) FROM sessions WHERE id = ?`, session).Scan(&stored)
+	if stored != 0 {
+		t.Error("session token stored in plain text")
+	}
+
+	if w := serveAuthed(Logout, "POST", session); w.Code != http.StatusOK {
+		t.Fatalf("logout: status %d: %s", w.Code, w.Body)
+	}
+	if w := serveAuthed(GetCurrentUser, "GET", session); w.Code != http.StatusUnauthorized {
+		t.Errorf("after logout: status %d, want 401", w.Code)
+	}
+
+	session = login(t, "admin@example.com", "correct horse")
+	db.DB.Exec(`UPDATE sessions SET expires_at = ?`, time.Now().UTC().Add(-time.Minute).Format(time.RFC3339))
+	if w := serveAuthed(GetCurrentUser, "GET", session); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired session: status %d, want 401", w.Code)
+	}
+}
@@ -0,0 +1,393 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const clientColumns = `id, name, email, phone, gstin, billing_address, notes, created_at, state, tan`
+
+// checkClientState fills in a client's GST state from its GSTIN when it has
+// none, returning a message when the state is unknown or contradicts the GSTIN
+func checkClientState(c *models.Client) string {
+	if c.State != nil {
+		state := strings.TrimSpace(*c.State)
+		if _, ok := models.GSTStates[state]; !ok {
+			return "state must be a two-digit GST state code, e.g. 29 for Karnataka"
+		}
+		c.State = &state
+	}
+	if c.GSTIN == nil {
+		return ""
+	}
+	code := (*c.GSTIN)[:2]
+	if c.State == nil {
+		c.State = &code
+	} else if *c.State != code {
+		return "state " + *c.State + " does not match the GSTIN, which is registered in " + code
+	}
+	return ""
+}
+
+// clientName returns the name of a client, or sql.ErrNoRows if it does not exist
+func clientName(q querier, id string) (string, error) {
+	var name string
+	err := q.QueryRow(`SELECT name FROM clients WHERE id = ?`, id).Scan(&name)
+	return name, err
+}
+
+func GetClients(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.DB.Query(`
+		SELECT ` + clientColumns + `
+		FROM clients
+		ORDER BY name COLLATE NOCASE ASC
+	`)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch clients")
+		return
+	}
+	defer rows.Close()
+
+	clients := []models.Client{}
+	for rows.Next() {
+		var c models.Client
+		if err := c.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan client")
+			return
+		}
+		clients = append(clients, c)
+	}
+
+	respondJSON(w, http.StatusOK, clients)
+}
+
+func GetClient(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["clientId"]
+
+	var c models.Client
+	err := c.Scan(db.DB.QueryRow(`
+		SELECT `+clientColumns+`
+		FROM clients
+		WHERE id = ?
+	`, id))
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Client not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch client")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// GetClientProjects lists a client's projects, newest first
+func GetClientProjects(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["clientId"]
+
+	if _, err := clientName(db.DB, id); err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Client not found")
+		return
+	} else if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch client")
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT `+projectColumns+`
+		FROM projects
+		WHERE clientId = ? AND deletedAt IS NULL
+		ORDER BY createdAt DESC
+	`, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch projects")
+		return
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		var p models.Project
+		if err := p.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan project")
+			return
+		}
+		presentProject(r, &p)
+		projects = append(projects, p)
+	}
+
+	respondJSON(w, http.StatusOK, projects)
+}
+
+func CreateClient(w http.ResponseWriter, r *http.Request) {
+	var c models.Client
+	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if c.GSTIN != nil {
+		gstin, ok := models.NormalizeGSTIN(*c.GSTIN)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "gstin must be a valid 15-character GSTIN")
+			return
+		}
+		c.GSTIN = &gstin
+	}
+	if msg := checkClientState(&c); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if c.TAN != nil {
+		tan, ok := models.NormalizeTAN(*c.TAN)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "tan must be a valid 10-character TAN")
+			return
+		}
+		c.TAN = &tan
+	}
+
+	c.ID = uuid.New().String()
+	c.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	_, err := db.DB.Exec(`
+		INSERT INTO clients (`+clientColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.ID, c.Name, c.Email, c.Phone, c.GSTIN, c.BillingAddress, c.Notes, c.CreatedAt, c.State, c.TAN)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A client with this name already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to create client")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, c)
+}
+
+// UpdateClient applies a partial update. Renaming a client also renames it on
+// every linked project, in the same transaction.
+func UpdateClient(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["clientId"]
+
+	updates, err := decodeUpdates(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(updates) == 0 {
+		respondError(w, http.StatusBadRequest, "No fields to update")
+		return
+	}
+
+	// Field mapping: JSON field name -> database column name
+	fieldMap := map[string]string{
+		"name":           "name",
+		"email":          "email",
+		"phone":          "phone",
+		"gstin":          "gstin",
+		"billingAddress": "billing_address",
+		"notes":          "notes",
+		"state":          "state",
+		"tan":            "tan",
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+
+	for jsonField, value := range updates {
+		if jsonField == "id" || jsonField == "createdAt" {
+			continue
+		}
+
+		dbField, ok := fieldMap[jsonField]
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Unknown field: "+jsonField)
+			return
+		}
+
+		switch jsonField {
+		case "name":
+			str, ok := value.(string)
+			if !ok || strings.TrimSpace(str) == "" {
+				respondError(w, http.StatusBadRequest, "name must be a non-empty string")
+				return
+			}
+			value = strings.TrimSpace(str)
+		case "gstin":
+			if value != nil {
+				str, ok := value.(string)
+				gstin, valid := models.NormalizeGSTIN(str)
+				if !ok || !valid {
+					respondError(w, http.StatusBadRequest, "gstin must be a valid 15-character GSTIN")
+					return
+				}
+				value = gstin
+			}
+		case "tan":
+			if value != nil {
+				str, ok := value.(string)
+				tan, valid := models.NormalizeTAN(str)
+				if !ok || !valid {
+					respondError(w, http.StatusBadRequest, "tan must be a valid 10-character TAN")
+					return
+				}
+				value = tan
+			}
+		case "state":
+			if _, ok := value.(string); value != nil && !ok {
+				respondError(w, http.StatusBadRequest, "state must be a string or null")
+				return
+			}
+		}
+
+		setParts = append(setParts, dbField+" = ?")
+		args = append(args, value)
+	}
+
+	if len(setParts) == 0 {
+		respondError(w, http.StatusBadRequest, "No valid fields to update")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update client")
+		return
+	}
+	defer tx.Rollback()
+
+	query := "UPDATE clients SET " + strings.Join(setParts, ", ") + " WHERE id = ?"
+	args = append(args, id)
+
+	result, err := tx.Exec(query, args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A client with this name already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to update client")
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		respondError(w, http.StatusNotFound, "Client not found")
+		return
+	}
+
+	var c models.Client
+	err = c.Scan(tx.QueryRow(`
+		SELECT `+clientColumns+`
+		FROM clients
+		WHERE id = ?
+	`, id))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated client")
+		return
+	}
+
+	// The state is checked against the GSTIN as they stand after the update,
+	// so either can be changed on its own
+	state := c.State
+	if msg := checkClientState(&c); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	if derefString(state) != derefString(c.State) {
+		if _, err := tx.Exec(`UPDATE clients SET state = ? WHERE id = ?`, c.State, id); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to update client")
+			return
+		}
+	}
+
+	// Every project whose name changes moves to a new version, which is
+	// recorded like any other change to it
+	rows, err := tx.Query(`SELECT id FROM projects WHERE clientId = ? AND clientName IS NOT ?`, id, c.Name)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update client projects")
+		return
+	}
+	renamed := []string{}
+	for rows.Next() {
+		var projectID string
+		if err := rows.Scan(&projectID); err != nil {
+			rows.Close()
+			respondError(w, http.StatusInternalServerError, "Failed to update client projects")
+			return
+		}
+		renamed = append(renamed, projectID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update client projects")
+		return
+	}
+
+	ts := time.Now().UTC().Format(time.RFC3339)
+	for _, projectID := range renamed {
+		if _, err := tx.Exec(`UPDATE projects SET clientName = ? WHERE id = ?`, c.Name, projectID); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to update client projects")
+			return
+		}
+		if err := db.SnapshotProject(tx, auditActor(r), projectID, ts); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to record project version")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update client")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+func DeleteClient(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["clientId"]
+
+	// Projects keep their client history, so linked clients cannot be removed;
+	// that includes projects in the trash, which may yet be restored
+	var projectCount int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM projects WHERE clientId = ?`, id).Scan(&projectCount); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check client projects")
+		return
+	}
+	if projectCount > 0 {
+		respondError(w, http.StatusConflict, "Client has projects (possibly in the trash) and cannot be deleted")
+		return
+	}
+
+	result, err := db.DB.Exec("DELETE FROM clients WHERE id = ?", id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete client")
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		respondError(w, http.StatusNotFound, "Client not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Client deleted"})
+}
@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestClientsOwnTheirProjectsName(t *testing.T) {
+	openTestDB(t)
+
+	var client map[string]interface{}
+	decode(t, serve(CreateClient, "POST", nil, `{"name": "Acme", "gstin": "29ABCDE1234F1Z5"}`), http.StatusCreated, &client)
+	clientID := client["id"].(string)
+	clientVars := map[string]string{"clientId": clientID}
+	decode(t, serve(CreateClient, "POST", nil, `{"name": "ACME"}`), http.StatusConflict, nil)
+	decode(t, serve(CreateClient, "POST", nil, `{"name": "Bad", "gstin": "12345"}`), http.StatusBadRequest, nil)
+	decode(t, serve(CreateProject, "POST", nil, `{"name": "X", "type": "software", "deadline": "2026-12-31", "totalAmount": 10, "clientId": "missing"}`), http.StatusBadRequest, nil)
+
+	linked := createProject(t, `{"clientId": "`+clientID+`", "clientName": "Typed name"}`)
+	if linked["clientName"] != "Acme" {
+		t.Errorf("linked project clientName = %v, want the client's name", linked["clientName"])
+	}
+	vars := map[string]string{"id": linked["id"].(string)}
+
+	// The linked name can be echoed back but not overwritten
+	decode(t, updateProject(testAdmin, vars, `{"clientName": "Acme", "name": "Renamed"}`), http.StatusOK, nil)
+	decode(t, updateProject(testAdmin, vars, `{"clientName": "Someone else"}`), http.StatusBadRequest, nil)
+
+	// Renaming the client renames it on its projects
+	decode(t, serve(UpdateClient, "PUT", clientVars, `{"name": "Acme Industries"}`), http.StatusOK, nil)
+	if got := getProject(t, linked["id"].(string))["clientName"]; got != "Acme Industries" {
+		t.Errorf("clientName after the rename = %v, want Acme Industries", got)
+	}
+
+	var projects []map[string]interface{}
+	decode(t, serve(GetClientProjects, "GET", clientVars, ""), http.StatusOK, &projects)
+	if len(projects) != 1 || projects[0]["id"] != linked["id"] {
+		t.Errorf("client projects = %v, want the linked project", projects)
+	}
+
+	// A client is kept while projects refer to it
+	decode(t, serve(DeleteClient, "DELETE", clientVars, ""), http.StatusConflict, nil)
+	var unlinked map[string]interface{}
+	decode(t, updateProject(testAdmin, vars, `{"clientId": null, "clientName": "Walk-in"}`), http.StatusOK, &unlinked)
+	if unlinked["clientId"] != nil || unlinked["clientName"] != "Walk-in" {
+		t.Errorf("unlinked project = %v, want a free-text client name", unlinked)
+	}
+	decode(t, serve(DeleteClient, "DELETE", clientVars, ""), http.StatusOK, nil)
+	decode(t, serve(GetClient, "GET", clientVars, ""), http.StatusNotFound, nil)
+}
@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"project-tracker/models"
+)
+
+// projectETag is the strong entity tag for a version of a project
+func projectETag(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+func setProjectETag(w http.ResponseWriter, p *models.Project) {
+	w.Header().Set("ETag", projectETag(p.Version))
+}
+
+// etagMatches reports whether an If-Match or If-None-Match header value names
+// the given version. "*" matches any version; weak tags never match, since
+// they cannot vouch for an exact version.
+func etagMatches(header string, version int64) bool {
+	want := projectETag(version)
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == want {
+			return true
+		}
+	}
+	return false
+}
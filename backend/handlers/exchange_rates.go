@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// BaseCurrency is the currency summary reports convert into. It is set from
+// the BASE_CURRENCY environment variable at startup.
+var BaseCurrency = models.DefaultCurrency
+
+const exchangeRateColumns = `id, base_currency, currency, rate_date, rate, created_at`
+
+// GetExchangeRates lists the stored rates into the base currency, optionally
+// filtered by ?currency=, newest first
+func GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	query := `SELECT ` + exchangeRateColumns + ` FROM exchange_rates WHERE base_currency = ?`
+	args := []interface{}{BaseCurrency}
+
+	if currency := r.URL.Query().Get("currency"); currency != "" {
+		query += ` AND currency = ?`
+		args = append(args, strings.ToUpper(currency))
+	}
+	query += ` ORDER BY rate_date DESC, currency ASC`
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch exchange rates")
+		return
+	}
+	defer rows.Close()
+
+	rates := []models.ExchangeRate{}
+	for rows.Next() {
+		var e models.ExchangeRate
+		if err := e.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan exchange rate")
+			return
+		}
+		rates = append(rates, e)
+	}
+
+	respondJSON(w, http.StatusOK, rates)
+}
+
+func CreateExchangeRate(w http.ResponseWriter, r *http.Request) {
+	var e models.ExchangeRate
+	if err := json.NewDecoder(r.Body).Decode(&e); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if !models.ValidCurrency(e.Currency) {
+		respondError(w, http.StatusBadRequest, "currency must be a supported ISO 4217 code")
+		return
+	}
+	if e.Currency == BaseCurrency {
+		respondError(w, http.StatusBadRequest, "currency must differ from the base currency ("+BaseCurrency+")")
+		return
+	}
+	if _, err := time.Parse("2006-01-02", e.Date); err != nil {
+		respondError(w, http.StatusBadRequest, "date must be in YYYY-MM-DD format")
+		return
+	}
+	if _, ok := models.ParseRate(e.Rate.String()); !ok {
+		respondError(w, http.StatusBadRequest, "rate must be a number greater than 0")
+		return
+	}
+
+	e.ID = uuid.New().String()
+	e.BaseCurrency = BaseCurrency
+	e.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	_, err := db.DB.Exec(`
+		INSERT INTO exchange_rates (`+exchangeRateColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, e.ID, e.BaseCurrency, e.Currency, e.Date, e.Rate.String(), e.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A rate for this currency and date already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to create exchange rate")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, e)
+}
+
+// UpdateExchangeRate corrects the rate of an existing entry; currency and date
+// identify the entry and cannot change
+func UpdateExchangeRate(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["rateId"]
+
+	var req struct {
+		Rate json.Number `json:"rate"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if _, ok := models.ParseRate(req.Rate.String()); !ok {
+		respondError(w, http.StatusBadRequest, "rate must be a number greater than 0")
+		return
+	}
+
+	result, err := db.DB.Exec(`UPDATE exchange_rates SET rate = ? WHERE id = ?`, req.Rate.String(), id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update exchange rate")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		respondError(w, http.StatusNotFound, "Exchange rate not found")
+		return
+	}
+
+	var e models.ExchangeRate
+	if err := e.Scan(db.DB.QueryRow(`SELECT `+exchangeRateColumns+` FROM exchange_rates WHERE id = ?`, id)); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated exchange rate")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, e)
+}
+
+func DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["rateId"]
+
+	result, err := db.DB.Exec(`DELETE FROM exchange_rates WHERE id = ?`, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete exchange rate")
+		return
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		respondError(w, http.StatusNotFound, "Exchange rate not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Exchange rate deleted"})
+}
+
+// rateOn returns the most recent rate for currency into the base currency on
+// or before date. It returns sql.ErrNoRows when no such rate is stored.
+func rateOn(q querier, currency, date string) (string, error) {
+	if currency == BaseCurrency {
+		return "1", nil
+	}
+
+	var rate string
+	err := q.QueryRow(`
+		SELECT rate
+		FROM exchange_rates
+		WHERE base_currency = ? AND currency = ? AND rate_date <= ?
+		ORDER BY rate_date DESC
+		LIMIT 1
+	`, BaseCurrency, currency, date).Scan(&rate)
+	return rate, err
+}
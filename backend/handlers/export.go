@@ -0,0 +1,307 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+// Exports stream straight from the database to the response, one row at a
+// time, so their size is not limited by memory. Once the first row is written
+// the status can no longer change: a failure part way through is logged and
+// the export ends early.
+//
+// CSV exports are meant to be opened in Excel and other spreadsheets: they
+// start with a UTF-8 byte order mark so non-ASCII text is read correctly, use
+// CRLF line endings, write amounts with two decimals and timestamps as
+// "YYYY-MM-DD hh:mm:ss" UTC, and neutralise text that a spreadsheet would
+// otherwise run as a formula.
+
+var projectExportHeader = []string{
+	"ID", "Name", "Client", "Client ID", "Type", "Status", "Currency",
+	"Total Amount", "Advance Received", "Total Received", "TDS", "Due", "GST Rate", "Tax Inclusive",
+	"Created At", "Start Date", "Deadline", "Completed At", "Delivered At",
+	"Description", "Tech Stack", "Deliverables", "Completion Notes", "Delivery Notes", "Internal Notes",
+	"Repo Link", "Live Link", "Completion Video Link",
+}
+
+var paymentExportHeader = []string{
+	"ID", "Project ID", "Project", "Client", "Date", "Currency",
+	"Amount", "TDS Amount", "TDS Section", "Gross Amount", "Method", "Reference", "Note", "Recorded At",
+}
+
+var auditExportHeader = []string{
+	"Seq", "ID", "Project ID", "Action", "Field", "Old Value", "New Value", "Created At",
+	"Actor Type", "Actor ID", "IP", "User Agent", "Hash",
+}
+
+// startCSVExport sends the headers of a spreadsheet CSV download named
+// filename, and returns a writer for its rows
+func startCSVExport(w http.ResponseWriter, filename string) *csv.Writer {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("\ufeff"))
+
+	out := csv.NewWriter(w)
+	out.UseCRLF = true
+	return out
+}
+
+// csvText writes text for a spreadsheet cell. Text starting with a character
+// that spreadsheets treat as the start of a formula is prefixed with an
+// apostrophe, so it is shown rather than evaluated. Every text column goes
+// through it, ids and codes included, as those can come from API clients or
+// older data too.
+func csvText(s *string) string {
+	if s == nil {
+		return ""
+	}
+	if *s != "" && strings.ContainsRune("=+-@\t\r", rune((*s)[0])) {
+		return "'" + *s
+	}
+	return *s
+}
+
+// csvAmount writes an amount in major units with exactly two decimals, as
+// spreadsheets and the GST tools expect
+func csvAmount(m models.Money) string {
+	sign := ""
+	if m < 0 {
+		sign, m = "-", -m
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, m/100, m%100)
+}
+
+// derefString returns the value of an optional string, or "" when it is unset
+func derefString(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// csvTime writes an RFC3339 timestamp in UTC in a form spreadsheets read as a
+// date and time. Bare dates and anything unparseable are written unchanged.
+func csvTime(s *string) string {
+	if s == nil {
+		return ""
+	}
+	t, err := time.Parse(time.RFC3339, *s)
+	if err != nil {
+		return csvText(s)
+	}
+	return t.UTC().Format("2006-01-02 15:04:05")
+}
+
+// exportProjects runs the project list query for an export, without its limit
+// and cursor: every project matching the filters is included
+func exportProjects(w http.ResponseWriter, r *http.Request) (*projectListQuery, bool) {
+	q, ok := parseProjectListQuery(w, r)
+	if !ok {
+		return nil, false
+	}
+	q.limit = 0
+	q.cursor = nil
+	return q, true
+}
+
+// ExportProjectsCSV downloads the projects matching the project list filters
+// as a spreadsheet CSV, redacted for the current user as the list is
+func ExportProjectsCSV(w http.ResponseWriter, r *http.Request) {
+	q, ok := exportProjects(w, r)
+	if !ok {
+		return
+	}
+
+	where, args := q.where(false)
+	rows, err := db.DB.Query(`SELECT `+projectColumns+` FROM projects `+where+` `+q.orderBy(), args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch projects")
+		return
+	}
+	defer rows.Close()
+
+	out := startCSVExport(w, "projects.csv")
+	out.Write(projectExportHeader)
+	for rows.Next() {
+		var p models.Project
+		if err := p.ScanRows(rows); err != nil {
+			log.Printf("project export: %v", err)
+			break
+		}
+		presentProject(r, &p)
+
+		out.Write([]string{
+			csvText(&p.ID), csvText(&p.Name), csvText(p.ClientName), csvText(p.ClientID), csvText(&p.Type), p.Status, csvText(&p.Currency),
+			csvAmount(p.TotalAmount), csvAmount(p.AdvanceReceived), csvAmount(p.TotalReceived), csvAmount(p.TotalTDS),
+			csvAmount(p.DueAmount()), p.GSTRate.String(), strconv.FormatBool(p.TaxInclusive),
+			csvTime(&p.CreatedAt), csvTime(p.StartDate), csvTime(&p.Deadline), csvTime(p.CompletedAt), csvTime(p.DeliveredAt),
+			csvText(p.Description), csvText(p.TechStack), csvText(p.Deliverables),
+			csvText(p.CompletionNotes), csvText(p.DeliveryNotes), csvText(p.InternalNotes),
+			csvText(p.RepoLink), csvText(p.LiveLink), csvText(p.CompletionVideoLink),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("project export: %v", err)
+	}
+	out.Flush()
+}
+
+// ExportProjectsJSON downloads the projects matching the project list filters
+// as a JSON array, in the same form GET /api/projects returns them
+func ExportProjectsJSON(w http.ResponseWriter, r *http.Request) {
+	q, ok := exportProjects(w, r)
+	if !ok {
+		return
+	}
+
+	where, args := q.where(false)
+	rows, err := db.DB.Query(`SELECT `+projectColumns+` FROM projects `+where+` `+q.orderBy(), args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch projects")
+		return
+	}
+	defer rows.Close()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", `attachment; filename="projects.json"`)
+	w.WriteHeader(http.StatusOK)
+
+	w.Write([]byte("["))
+	for first := true; rows.Next(); first = false {
+		var p models.Project
+		if err := p.ScanRows(rows); err != nil {
+			log.Printf("project export: %v", err)
+			break
+		}
+		presentProject(r, &p)
+
+		raw, err := json.Marshal(p)
+		if err != nil {
+			log.Printf("project export: %v", err)
+			break
+		}
+		if !first {
+			w.Write([]byte(","))
+		}
+		w.Write(raw)
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("project export: %v", err)
+	}
+	w.Write([]byte("]\n"))
+}
+
+// ExportPaymentsCSV downloads, as a spreadsheet CSV, the payments of the
+// projects matching the project list filters, oldest first. ?from= and ?to=
+// (YYYY-MM-DD, inclusive) limit it to payments made in that period.
+func ExportPaymentsCSV(w http.ResponseWriter, r *http.Request) {
+	q, ok := exportProjects(w, r)
+	if !ok {
+		return
+	}
+	projectWhere, args := q.where(false)
+
+	conditions := []string{}
+	for _, param := range []struct{ name, op string }{{"from", ">="}, {"to", "<="}} {
+		v := r.URL.Query().Get(param.name)
+		if v == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, param.name+" must be in YYYY-MM-DD format")
+			return
+		}
+		conditions = append(conditions, "substr(paid_at, 1, 10) "+param.op+" ?")
+		args = append(args, v)
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	// The filters name project columns, so they are applied inside the join
+	rows, err := db.DB.Query(`
+		SELECT `+paymentColumns+`, project_name, client_name
+		FROM payments
+		JOIN (SELECT id AS pid, name AS project_name, clientName AS client_name FROM projects `+projectWhere+`)
+			ON pid = project_id
+		`+where+`
+		ORDER BY paid_at, created_at
+	`, args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+	defer rows.Close()
+
+	out := startCSVExport(w, "payments.csv")
+	out.Write(paymentExportHeader)
+	for rows.Next() {
+		var p models.Payment
+		var projectName string
+		var clientName *string
+		err := rows.Scan(&p.ID, &p.ProjectID, &p.Amount, &p.Date, &p.Method, &p.Reference, &p.Note,
+			&p.CreatedAt, &p.Currency, &p.TDSAmount, &p.TDSSection, &projectName, &clientName)
+		if err != nil {
+			log.Printf("payment export: %v", err)
+			break
+		}
+		p.GrossAmount = p.Amount + p.TDSAmount
+
+		out.Write([]string{
+			csvText(&p.ID), csvText(&p.ProjectID), csvText(&projectName), csvText(clientName), csvTime(&p.Date), csvText(&p.Currency),
+			csvAmount(p.Amount), csvAmount(p.TDSAmount), csvText(p.TDSSection), csvAmount(p.GrossAmount),
+			csvText(p.Method), csvText(p.Reference), csvText(p.Note), csvTime(&p.CreatedAt),
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("payment export: %v", err)
+	}
+	out.Flush()
+}
+
+// ExportAuditCSV downloads the audit trail as a spreadsheet CSV, in chain
+// order. It takes the filters of GET /api/audit, plus ?projectId=.
+func ExportAuditCSV(w http.ResponseWriter, r *http.Request) {
+	where, args, ok := parseAuditFilters(w, r, r.URL.Query().Get("projectId"))
+	if !ok {
+		return
+	}
+
+	rows, err := db.DB.Query(`SELECT `+auditColumns+` FROM audit_logs `+where+` ORDER BY seq`, args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch audit logs")
+		return
+	}
+	defer rows.Close()
+
+	out := startCSVExport(w, "audit.csv")
+	out.Write(auditExportHeader)
+	for rows.Next() {
+		var entry models.AuditLog
+		if err := entry.ScanRows(rows); err != nil {
+			log.Printf("audit export: %v", err)
+			break
+		}
+
+		out.Write([]string{
+			strconv.FormatInt(entry.Seq, 10), csvText(&entry.ID), csvText(&entry.ProjectID), csvText(&entry.Action), csvText(entry.FieldName),
+			csvText(entry.OldValue), csvText(entry.NewValue), csvTime(&entry.CreatedAt),
+			csvText(&entry.ActorType), csvText(entry.ActorID), csvText(entry.IP), csvText(entry.UserAgent), entry.Hash,
+		})
+	}
+	if err := rows.Err(); err != nil {
+		log.Printf("audit export: %v", err)
+	}
+	out.Flush()
+}
@@ -0,0 +1,200 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+// readExport checks a spreadsheet CSV download and returns its rows,
+// header first
+func readExport(t *testing.T, w *httptest.ResponseRecorder) [][]string {
+	t.Helper()
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("export: status %d, %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	body := w.Body.String()
+	if !strings.HasPrefix(body, "\ufeff") {
+		t.Error("export does not start with a byte order mark")
+	}
+	if strings.Count(body, "\r\n") != strings.Count(body, "\n") {
+		t.Error("export does not use CRLF line endings")
+	}
+	rows, err := csv.NewReader(strings.NewReader(strings.TrimPrefix(body, "\ufeff"))).ReadAll()
+	if err != nil {
+		t.Fatalf("read export: %v", err)
+	}
+	return rows
+}
+
+// column returns the value in the named column of row
+func column(header, row []string, name string) string {
+	for i, h := range header {
+		if h == name {
+			return row[i]
+		}
+	}
+	return "no column " + name
+}
+
+func TestExportProjectsCSV(t *testing.T) {
+	openTestDB(t)
+	createProject(t, `{"name": "Plain", "type": "hardware", "totalAmount": 500}`)
+	createProject(t, `{
+		"name": "=HYPERLINK(\"http://evil.example\")", "clientName": "@Acme", "description": "+91 98765 43210",
+		"internalNotes": "-10% margin", "totalAmount": 1234.5, "totalReceived": 1000
+	}`)
+
+	rows := readExport(t, serveGET(ExportProjectsCSV, "/?type=software", nil))
+	if len(rows) != 2 {
+		t.Fatalf("%d rows, want the header and one project: %q", len(rows), rows)
+	}
+	header, row := rows[0], rows[1]
+	for name, want := range map[string]string{
+		"Name":           `'=HYPERLINK("http://evil.example")`,
+		"Client":         "'@Acme",
+		"Description":    "'+91 98765 43210",
+		"Internal Notes": "'-10% margin",
+		"Total Amount":   "1234.50",
+		"Total Received": "1000.00",
+		"Due":            "234.50",
+		"Deadline":       "2026-12-31",
+		"Tax Inclusive":  "false",
+	} {
+		if got := column(header, row, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if created := column(header, row, "Created At"); len(created) != len("2006-01-02 15:04:05") || strings.ContainsAny(created, "TZ") {
+		t.Errorf("Created At = %q, want a spreadsheet timestamp", created)
+	}
+
+	decode(t, serveGET(ExportProjectsCSV, "/?sort=color", nil), http.StatusBadRequest, nil)
+}
+
+func TestExportProjectsJSON(t *testing.T) {
+	openTestDB(t)
+	for _, name := range []string{"Beta", "Alpha", "Gamma"} {
+		createProject(t, `{"name": "`+name+`"}`)
+	}
+
+	w := serveGET(ExportProjectsJSON, "/?sort=name&order=asc&limit=1", nil)
+	var projects []models.Project
+	decode(t, w, http.StatusOK, &projects)
+	if len(projects) != 3 || projects[0].Name != "Alpha" || projects[2].Name != "Gamma" {
+		t.Errorf("exported %d projects, want all three by name, paging ignored", len(projects))
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="projects.json"` {
+		t.Errorf("Content-Disposition = %s", cd)
+	}
+
+	decode(t, serveGET(ExportProjectsJSON, "/?order=up", nil), http.StatusBadRequest, nil)
+	var none []models.Project
+	decode(t, serveGET(ExportProjectsJSON, "/?q=nothing", nil), http.StatusOK, &none)
+	if none == nil || len(none) != 0 {
+		t.Errorf("empty export = %v, want []", none)
+	}
+}
+
+func TestExportPaymentsCSV(t *testing.T) {
+	openTestDB(t)
+	design := createProject(t, `{"name": "Logo", "type": "hardware"}`)["id"].(string)
+	software := createProject(t, `{"name": "App", "clientName": "=Client"}`)["id"].(string)
+	for _, p := range []struct{ project, body string }{
+		{software, `{"amount": 900, "tdsAmount": 100, "tdsSection": "194J", "date": "2026-03-01", "reference": "-REF-1", "method": "upi"}`},
+		{software, `{"amount": 200, "date": "2026-01-15", "note": "@finance"}`},
+		{software, `{"amount": 300, "date": "2026-04-01"}`},
+		{design, `{"amount": 400, "date": "2026-02-01"}`},
+	} {
+		decode(t, serve(CreatePayment, "POST", map[string]string{"id": p.project}, p.body), http.StatusCreated, nil)
+	}
+
+	// Ids and codes are escaped too, whatever wrote them
+	if _, err := db.DB.Exec(`UPDATE payments SET id = '=1+2', method = '=bank' WHERE reference = '-REF-1'`); err != nil {
+		t.Fatal(err)
+	}
+
+	rows := readExport(t, serveGET(ExportPaymentsCSV, "/?type=software&from=2026-01-01&to=2026-03-31", nil))
+	if len(rows) != 3 {
+		t.Fatalf("%d rows, want the header and two payments: %q", len(rows), rows)
+	}
+	header := rows[0]
+	if got := column(header, rows[1], "Note"); got != "'@finance" {
+		t.Errorf("first payment note %q, want the January payment escaped", got)
+	}
+	for name, want := range map[string]string{
+		"ID":           "'=1+2",
+		"Project ID":   software,
+		"Project":      "App",
+		"Client":       "'=Client",
+		"Date":         "2026-03-01",
+		"Amount":       "900.00",
+		"TDS Amount":   "100.00",
+		"TDS Section":  "194J",
+		"Gross Amount": "1000.00",
+		"Method":       "'=bank",
+		"Reference":    "'-REF-1",
+	} {
+		if got := column(header, rows[2], name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	decode(t, serveGET(ExportPaymentsCSV, "/?from=01-01-2026", nil), http.StatusBadRequest, nil)
+}
+
+func TestExportAuditCSV(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"name": "Site"}`)
+	vars := map[string]string{"id": project["id"].(string)}
+	decode(t, updateProject(testAdmin, vars, `{"name": "=cmd|' /C calc'!A0"}`), http.StatusOK, nil)
+	createProject(t, `{"name": "Other"}`)
+
+	rows := readExport(t, serveGET(ExportAuditCSV, "/?projectId="+vars["id"], nil))
+	if len(rows) != 3 {
+		t.Fatalf("%d rows, want the header and two entries: %q", len(rows), rows)
+	}
+	header, changed := rows[0], rows[2]
+	if column(header, rows[1], "Action") != "PROJECT_CREATED" || column(header, changed, "Action") != "PROJECT_UPDATED" {
+		t.Errorf("entries out of chain order: %q", rows[1:])
+	}
+	for name, want := range map[string]string{
+		"Field":      "name",
+		"Old Value":  "Site",
+		"New Value":  "'=cmd|' /C calc'!A0",
+		"Actor Type": "user",
+		"Actor ID":   testAdmin.ID,
+	} {
+		if got := column(header, changed, name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+	if seq, hash := column(header, changed, "Seq"), column(header, changed, "Hash"); seq != "2" || len(hash) != 64 {
+		t.Errorf("seq %q, hash %q", seq, hash)
+	}
+}
+
+func TestExportsAreForStaff(t *testing.T) {
+	openTestDB(t)
+	createProject(t, `{"internalNotes": "Client pays late"}`)
+
+	for _, export := range []http.HandlerFunc{ExportProjectsCSV, ExportProjectsJSON, ExportPaymentsCSV, ExportAuditCSV} {
+		guarded := RequireRole(Staff, export)
+		for _, user := range []*models.User{testViewer, testPartnerUser("p1")} {
+			w := serveAs(user, guarded, "GET", "/", nil, "")
+			if w.Code != http.StatusForbidden || strings.Contains(w.Body.String(), "Client pays late") {
+				t.Errorf("%s export: status %d: %s", user.Role, w.Code, w.Body)
+			}
+		}
+	}
+
+	w := serveAs(testManager, RequireRole(Staff, ExportProjectsCSV), "GET", "/", nil, "")
+	if rows := readExport(t, w); column(rows[0], rows[1], "Internal Notes") != "Client pays late" {
+		t.Errorf("manager export lacks the internal notes: %q", rows)
+	}
+}
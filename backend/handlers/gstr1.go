@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+// gstr1Header follows the columns of the GSTR-1 offline tool's invoice sheets
+var gstr1Header = []string{
+	"Type", "GSTIN/UIN of Recipient", "Receiver Name", "Invoice Number", "Invoice Date", "Invoice Value",
+	"Place Of Supply", "Reverse Charge", "Rate", "Taxable Value", "Integrated Tax Amount",
+	"Central Tax Amount", "State/UT Tax Amount", "Cess Amount", "Currency",
+}
+
+// b2clThreshold is the invoice value above which an inter-state supply to an
+// unregistered client is reported invoice by invoice (B2CL) rather than in
+// the B2CS totals
+const b2clThreshold models.Money = 100000_00
+
+// gstr1Row is the part of an invoice taxed at one rate
+type gstr1Row struct {
+	Number, IssueDate, Currency  string
+	ClientGSTIN, ClientName      *string
+	PlaceOfSupply, SupplierState string
+	Value                        models.Money
+	Rate                         models.Percent
+	Taxable, IGST, CGST, SGST    models.Money
+	Section                      string
+}
+
+// GetGSTR1Report exports the tax invoices issued in ?month=YYYY-MM as a
+// GSTR-1 style CSV, one row per invoice and rate. Invoices to registered
+// clients are B2B; large inter-state ones to unregistered clients are B2CL,
+// and supplies abroad EXP; the rest are totalled by place of supply and rate
+// as B2CS. Voided invoices are left out.
+func GetGSTR1Report(w http.ResponseWriter, r *http.Request) {
+	month := r.URL.Query().Get("month")
+	if _, err := time.Parse("2006-01", month); err != nil {
+		respondError(w, http.StatusBadRequest, "month must be in YYYY-MM format")
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT i.number, i.issue_date, i.currency, i.client_gstin, i.client_name, i.place_of_supply,
+			i.supplier_state, i.total, it.gst_rate,
+			SUM(it.taxable_value), SUM(it.igst), SUM(it.cgst), SUM(it.sgst)
+		FROM invoices i
+		JOIN invoice_items it ON it.invoice_id = i.id
+		WHERE i.status IN (?, ?) AND i.supplier_gstin IS NOT NULL AND substr(i.issue_date, 1, 7) = ?
+		GROUP BY i.id, it.gst_rate
+		ORDER BY i.sequence, it.gst_rate
+	`, models.InvoiceSent, models.InvoicePaid, month)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoices")
+		return
+	}
+
+	sections := map[string][]gstr1Row{}
+	b2csIndex := map[string]int{}
+	for rows.Next() {
+		var row gstr1Row
+		err := rows.Scan(&row.Number, &row.IssueDate, &row.Currency, &row.ClientGSTIN, &row.ClientName,
+			&row.PlaceOfSupply, &row.SupplierState, &row.Value, &row.Rate,
+			&row.Taxable, &row.IGST, &row.CGST, &row.SGST)
+		if err != nil {
+			rows.Close()
+			respondError(w, http.StatusInternalServerError, "Failed to scan invoice")
+			return
+		}
+
+		switch {
+		case row.ClientGSTIN != nil:
+			row.Section = "B2B"
+		case row.PlaceOfSupply == "96":
+			row.Section = "EXP"
+		case row.PlaceOfSupply != row.SupplierState && row.Value > b2clThreshold:
+			row.Section = "B2CL"
+		default:
+			// Small supplies to unregistered clients are reported as totals
+			key := row.PlaceOfSupply + "/" + row.Rate.String() + "/" + row.Currency
+			if i, ok := b2csIndex[key]; ok {
+				total := &sections["B2CS"][i]
+				total.Taxable += row.Taxable
+				total.IGST += row.IGST
+				total.CGST += row.CGST
+				total.SGST += row.SGST
+				continue
+			}
+			b2csIndex[key] = len(sections["B2CS"])
+			row = gstr1Row{
+				Section: "B2CS", PlaceOfSupply: row.PlaceOfSupply, Rate: row.Rate, Currency: row.Currency,
+				Taxable: row.Taxable, IGST: row.IGST, CGST: row.CGST, SGST: row.SGST,
+			}
+		}
+		sections[row.Section] = append(sections[row.Section], row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoices")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="gstr1-`+month+`.csv"`)
+	w.WriteHeader(http.StatusOK)
+
+	out := csv.NewWriter(w)
+	out.Write(gstr1Header)
+	for _, section := range []string{"B2B", "B2CL", "EXP", "B2CS"} {
+		for _, row := range sections[section] {
+			record := []string{section, derefString(row.ClientGSTIN), derefString(row.ClientName), row.Number, "", "",
+				models.GSTStateLabel(row.PlaceOfSupply), "N", row.Rate.String(), csvAmount(row.Taxable),
+				csvAmount(row.IGST), csvAmount(row.CGST), csvAmount(row.SGST), csvAmount(0), row.Currency}
+			if section != "B2CS" {
+				issued, _ := time.Parse("2006-01-02", row.IssueDate)
+				record[4] = issued.Format("02-Jan-2006")
+				record[5] = csvAmount(row.Value)
+			}
+			out.Write(record)
+		}
+	}
+	out.Flush()
+}
@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// issueInvoice drafts an invoice of amount on the project and sends it on
+// issueDate, returning its id
+func issueInvoice(t *testing.T, projectID, amount, issueDate string) string {
+	t.Helper()
+	id := createDraft(t, projectID, `{"amount": `+amount+`}`).ID
+	decode(t, serve(SendInvoice, "POST", map[string]string{"invoiceId": id}, `{"issueDate": "`+issueDate+`"}`), http.StatusOK, nil)
+	return id
+}
+
+func TestGSTR1Report(t *testing.T) {
+	openTestDB(t)
+	issuer, sac := Issuer, DefaultSAC
+	t.Cleanup(func() { Issuer, DefaultSAC = issuer, sac })
+	Issuer = InvoiceIssuer{Name: "Handoff Studio", GSTIN: "29ABCDE1234F1Z5", State: "29"}
+	DefaultSAC = "998314"
+
+	// clientProject creates a client and a project billed to it
+	clientProject := func(client string) string {
+		var c map[string]interface{}
+		decode(t, serve(CreateClient, "POST", nil, client), http.StatusCreated, &c)
+		return createProject(t, `{"totalAmount": 1000000, "clientId": "`+c["id"].(string)+`"}`)["id"].(string)
+	}
+	registered := clientProject(`{"name": "Mumbai Traders", "gstin": "27AAACM1234A1Z5"}`)
+	local := clientProject(`{"name": "Corner Cafe", "state": "29"}`)
+	delhi := clientProject(`{"name": "Delhi Buyer", "state": "07"}`)
+	abroad := clientProject(`{"name": "Overseas Ltd", "state": "96"}`)
+
+	// Sent in issue date order, as numbering requires
+	issueInvoice(t, local, "100", "2026-04-30")
+	issueInvoice(t, registered, "1000", "2026-05-01")
+	issueInvoice(t, local, "500", "2026-05-10")
+	issueInvoice(t, delhi, "200", "2026-05-15")
+	issueInvoice(t, delhi, "200000", "2026-05-20")
+	voided := issueInvoice(t, local, "400", "2026-05-20")
+	issueInvoice(t, abroad, "3000", "2026-05-25")
+	issueInvoice(t, local, "300", "2026-05-31")
+	issueInvoice(t, local, "700", "2026-06-01")
+	decode(t, serve(VoidInvoice, "POST", map[string]string{"invoiceId": voided}, `{"reason": "Raised twice"}`), http.StatusOK, nil)
+
+	// A draft is not in any return
+	createDraft(t, local, `{"amount": 50, "issueDate": "2026-05-12"}`)
+
+	w := serveGET(GetGSTR1Report, "/?month=2026-05", nil)
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("status %d, %s: %s", w.Code, w.Header().Get("Content-Type"), w.Body)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `attachment; filename="gstr1-2026-05.csv"` {
+		t.Errorf("Content-Disposition = %s", cd)
+	}
+
+	want := []string{
+		"Type,GSTIN/UIN of Recipient,Receiver Name,Invoice Number,Invoice Date,Invoice Value,Place Of Supply,Reverse Charge,Rate,Taxable Value,Integrated Tax Amount,Central Tax Amount,State/UT Tax Amount,Cess Amount,Currency",
+		"B2B,27AAACM1234A1Z5,Mumbai Traders,INV/2026-27/0002,01-May-2026,1180.00,27-Maharashtra,N,18,1000.00,180.00,0.00,0.00,0.00,INR",
+		"B2CL,,Delhi Buyer,INV/2026-27/0005,20-May-2026,236000.00,07-Delhi,N,18,200000.00,36000.00,0.00,0.00,0.00,INR",
+		"EXP,,Overseas Ltd,INV/2026-27/0007,25-May-2026,3540.00,96-Other Country,N,18,3000.00,540.00,0.00,0.00,0.00,INR",
+		"B2CS,,,,,,29-Karnataka,N,18,800.00,0.00,72.00,72.00,0.00,INR",
+		"B2CS,,,,,,07-Delhi,N,18,200.00,36.00,0.00,0.00,0.00,INR",
+	}
+	got := strings.Split(strings.TrimSpace(strings.ReplaceAll(w.Body.String(), "\r\n", "\n")), "\n")
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("GSTR-1 for May\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Each month holds only its own invoices
+	for month, rows := range map[string]int{"2026-04": 1, "2026-06": 1, "2026-07": 0} {
+		w := serveGET(GetGSTR1Report, "/?month="+month, nil)
+		if n := strings.Count(w.Body.String(), "\n") - 1; w.Code != http.StatusOK || n != rows {
+			t.Errorf("%s: status %d with %d rows, want %d", month, w.Code, n, rows)
+		}
+	}
+
+	for _, target := range []string{"/", "/?month=2026-5", "/?month=May"} {
+		decode(t, serveGET(GetGSTR1Report, target, nil), http.StatusBadRequest, nil)
+	}
+}
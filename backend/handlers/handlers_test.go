@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/gorilla/mux"
+)
+
+// openTestDB sets up a fresh database in a temporary directory as db.DB
+func openTestDB(t *testing.T) {
+	t.Helper()
+	if err := db.InitDB(filepath.Join(t.TempDir(), "test.db")); err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+}
+
+// testAdmin is the signed-in user for requests made with serve and serveGET
+var testAdmin = &models.User{ID: "test-admin", Email: "admin@example.com", Name: "Admin", Role: models.RoleAdmin}
+
+// newRequest builds a request from user with the target, route variables and
+// JSON body given, as the router passes it on once RequireAuth has let it in
+func newRequest(user *models.User, method, target string, vars map[string]string, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r = mux.SetURLVars(r, vars)
+	return r.WithContext(context.WithValue(r.Context(), userContextKey, user))
+}
+
+// serveAs calls handler with a request from user built by newRequest
+func serveAs(user *models.User, handler http.HandlerFunc, method, target string, vars map[string]string, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	handler(w, newRequest(user, method, target, vars, body))
+	return w
+}
+
+// serve calls handler as testAdmin with the route variables and JSON body given
+func serve(handler http.HandlerFunc, method string, vars map[string]string, body string) *httptest.ResponseRecorder {
+	return serveAs(testAdmin, handler, method, "/", vars, body)
+}
+
+// serveGET calls handler as testAdmin with a GET of target, which carries the query
+func serveGET(handler http.HandlerFunc, target string, vars map[string]string) *httptest.ResponseRecorder {
+	return serveAs(testAdmin, handler, "GET", target, vars, "")
+}
+
+// updateProject sends a partial update of the project in vars from user, with
+// If-Match naming its current version as a client that had just loaded it would
+func updateProject(user *models.User, vars map[string]string, body string) *httptest.ResponseRecorder {
+	var version int64
+	db.DB.QueryRow(`SELECT version FROM projects WHERE id = ?`, vars["id"]).Scan(&version)
+
+	r := newRequest(user, "PUT", "/", vars, body)
+	r.Header.Set("If-Match", projectETag(version))
+	w := httptest.NewRecorder()
+	UpdateProject(w, r)
+	return w
+}
+
+// decode checks the response status and decodes its JSON body
+func decode(t *testing.T, w *httptest.ResponseRecorder, status int, v interface{}) {
+	t.Helper()
+	if w.Code != status {
+		t.Fatalf("status %d, want %d: %s", w.Code, status, w.Body)
+	}
+	if v != nil {
+		if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+			t.Fatalf("decode response: %v: %s", err, w.Body)
+		}
+	}
+}
+
+// createProject creates a project from a JSON body, filling in the required
+// fields it leaves out, and returns it
+func createProject(t *testing.T, body string) map[string]interface{} {
+	t.Helper()
+	fields := map[string]interface{}{
+		"name":        "Test project",
+		"type":        "software",
+		"deadline":    "2026-12-31",
+		"totalAmount": 10000,
+	}
+	if err := json.Unmarshal([]byte(body), &fields); err != nil {
+		t.Fatalf("project body: %v", err)
+	}
+	raw, _ := json.Marshal(fields)
+
+	var p map[string]interface{}
+	decode(t, serve(CreateProject, "POST", nil, string(raw)), http.StatusCreated, &p)
+	return p
+}
+
+// getProject fetches a project as the API returns it
+func getProject(t *testing.T, id string) map[string]interface{} {
+	t.Helper()
+	var p map[string]interface{}
+	decode(t, serve(GetProject, "GET", map[string]string{"id": id}, ""), http.StatusOK, &p)
+	return p
+}
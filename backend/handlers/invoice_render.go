@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"bytes"
+	"database/sql"
+	"html/template"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"project-tracker/db"
+	"project-tracker/models"
+	"project-tracker/templates"
+
+	"github.com/gorilla/mux"
+)
+
+// InvoiceIssuer is the business that invoices are issued by
+type InvoiceIssuer struct {
+	Name    string
+	Address string
+	Email   string
+	Phone   string
+	GSTIN   string // Empty unless registered for GST
+	State   string // GST state code, the first two digits of GSTIN
+}
+
+// Issuer is printed at the top of every invoice. It is set from the
+// BUSINESS_NAME, BUSINESS_ADDRESS, BUSINESS_EMAIL, BUSINESS_PHONE and
+// BUSINESS_GSTIN environment variables at startup.
+var Issuer InvoiceIssuer
+
+// invoiceDocument is the data an invoice template is executed with
+type invoiceDocument struct {
+	Issuer        InvoiceIssuer
+	Invoice       models.Invoice
+	Project       string // Empty once the project has been purged
+	Title         string
+	TaxInvoice    bool             // The invoice charges GST
+	PlaceOfSupply string           // e.g. 29-Karnataka, on tax invoices
+	TaxLines      []invoiceTaxLine // The tax by component and rate, on tax invoices
+}
+
+// invoiceTaxLine is one row of an invoice's tax summary, e.g. CGST @ 9%
+type invoiceTaxLine struct {
+	Label  string
+	Amount models.Money
+}
+
+var invoiceTemplateFuncs = template.FuncMap{
+	"money":   formatAmount,
+	"words":   models.AmountInWords,
+	"percent": formatPercent,
+	"lines":   textLines,
+	"inc":     func(i int) int { return i + 1 },
+}
+
+var invoiceTemplate = template.Must(parseInvoiceTemplate(mustReadDefaultInvoiceTemplate()))
+
+func mustReadDefaultInvoiceTemplate() []byte {
+	content, err := templates.FS.ReadFile("invoice.html")
+	if err != nil {
+		panic(err)
+	}
+	return content
+}
+
+func parseInvoiceTemplate(content []byte) (*template.Template, error) {
+	return template.New("invoice").Funcs(invoiceTemplateFuncs).Parse(string(content))
+}
+
+// LoadInvoiceTemplate replaces the built-in invoice template with the one at
+// path, which is executed with the same data (see templates/invoice.html)
+func LoadInvoiceTemplate(path string) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tmpl, err := parseInvoiceTemplate(content)
+	if err != nil {
+		return err
+	}
+	invoiceTemplate = tmpl
+	return nil
+}
+
+// formatAmount writes an amount in major units with two decimals and grouped
+// digits, in the Indian style (12,34,567.00) for rupees
+func formatAmount(m models.Money, currency string) string {
+	sign := ""
+	minor := int64(m)
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+
+	digits := strconv.FormatInt(minor/100, 10)
+	groups := []string{}
+	if len(digits) > 3 {
+		groups = append(groups, digits[len(digits)-3:])
+		digits = digits[:len(digits)-3]
+		size := 3
+		if currency == "INR" {
+			size = 2
+		}
+		for len(digits) > size {
+			groups = append([]string{digits[len(digits)-size:]}, groups...)
+			digits = digits[:len(digits)-size]
+		}
+	}
+	groups = append([]string{digits}, groups...)
+
+	return currency + " " + sign + strings.Join(groups, ",") + "." + strconv.FormatInt(100+minor%100, 10)[1:]
+}
+
+// formatPercent writes a rate as a percentage, e.g. 18%
+func formatPercent(p models.Percent) string {
+	return p.String() + "%"
+}
+
+// invoiceTaxLines sums the tax on an invoice by component and rate. Within a
+// state each rate is split evenly into CGST and SGST.
+func invoiceTaxLines(inv *models.Invoice) []invoiceTaxLine {
+	rates := []models.Percent{}
+	cgst, sgst, igst := map[models.Percent]models.Money{}, map[models.Percent]models.Money{}, map[models.Percent]models.Money{}
+	for _, item := range inv.Items {
+		if _, seen := cgst[item.GSTRate]; !seen {
+			rates = append(rates, item.GSTRate)
+		}
+		cgst[item.GSTRate] += item.CGST
+		sgst[item.GSTRate] += item.SGST
+		igst[item.GSTRate] += item.IGST
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i] < rates[j] })
+
+	lines := []invoiceTaxLine{}
+	for _, rate := range rates {
+		if inv.InterState() {
+			lines = append(lines, invoiceTaxLine{"IGST @ " + formatPercent(rate), igst[rate]})
+			continue
+		}
+		// Halving may leave a third decimal, as 0.25% does
+		half := strconv.FormatFloat(float64(rate)/200, 'f', -1, 64) + "%"
+		lines = append(lines,
+			invoiceTaxLine{"CGST @ " + half, cgst[rate]},
+			invoiceTaxLine{"SGST @ " + half, sgst[rate]},
+		)
+	}
+	return lines
+}
+
+// textLines splits a string, or a string pointer, into its non-empty lines
+func textLines(text interface{}) []string {
+	var s string
+	switch v := text.(type) {
+	case string:
+		s = v
+	case *string:
+		if v != nil {
+			s = *v
+		}
+	}
+
+	lines := []string{}
+	for _, line := range strings.Split(s, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// loadInvoiceDocument fetches an invoice and what is printed with it, writing
+// the error response and returning ok=false when it cannot
+func loadInvoiceDocument(w http.ResponseWriter, r *http.Request) (invoiceDocument, bool) {
+	doc := invoiceDocument{Issuer: Issuer, Title: "Invoice"}
+
+	inv, err := fetchInvoice(db.DB, mux.Vars(r)["invoiceId"])
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Invoice not found")
+		return doc, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoice")
+		return doc, false
+	}
+	doc.Invoice = inv
+
+	err = db.DB.QueryRow(`SELECT name FROM projects WHERE id = ?`, inv.ProjectID).Scan(&doc.Project)
+	if err != nil && err != sql.ErrNoRows {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return doc, false
+	}
+
+	if inv.SupplierGSTIN != nil {
+		doc.TaxInvoice = true
+		doc.Title = "Tax invoice"
+		doc.TaxLines = invoiceTaxLines(&inv)
+		if inv.PlaceOfSupply != nil {
+			doc.PlaceOfSupply = models.GSTStateLabel(*inv.PlaceOfSupply)
+		}
+	}
+	switch inv.Status {
+	case models.InvoiceDraft:
+		doc.Title = "Draft " + strings.ToLower(doc.Title)
+	case models.InvoiceVoid:
+		doc.Title = "Void " + strings.ToLower(doc.Title)
+	}
+	return doc, true
+}
+
+// invoiceFileName names a downloaded invoice after its number, or its id
+// while it is a draft
+func invoiceFileName(inv *models.Invoice, ext string) string {
+	name := "draft-" + inv.ID
+	if inv.Number != nil {
+		name = strings.ReplaceAll(*inv.Number, "/", "-")
+	}
+	return name + "." + ext
+}
+
+// RenderInvoiceHTML renders an invoice with the invoice template
+func RenderInvoiceHTML(w http.ResponseWriter, r *http.Request) {
+	doc, ok := loadInvoiceDocument(w, r)
+	if !ok {
+		return
+	}
+
+	// Render fully before writing, so a template error can still become a 500
+	var buf bytes.Buffer
+	if err := invoiceTemplate.Execute(&buf, &doc); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to render invoice")
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Content-Disposition", `inline; filename="`+invoiceFileName(&doc.Invoice, "html")+`"`)
+	w.WriteHeader(http.StatusOK)
+	buf.WriteTo(w)
+}
@@ -0,0 +1,971 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const invoiceColumns = `id, project_id, number, financial_year, status, client_id, client_name, billing_address,
+	currency, total, issue_date, due_date, notes, created_at, sent_at, paid_at, voided_at, void_reason,
+	tax_inclusive, supplier_gstin, supplier_state, client_gstin, place_of_supply, subtotal, taxable_value, cgst, sgst, igst`
+
+// InvoicePrefix starts every invoice number, as in INV/2025-26/0001. It is set
+// from the INVOICE_PREFIX environment variable at startup.
+var InvoicePrefix = "INV"
+
+// DefaultSAC is given to invoice items that do not name an HSN/SAC code. It is
+// set from the DEFAULT_SAC environment variable at startup.
+var DefaultSAC string
+
+// hsnSACPattern matches an HSN or SAC code, which is 4, 6 or 8 digits long
+var hsnSACPattern = regexp.MustCompile(`^(\d{4}|\d{6}|\d{8})$`)
+
+// defaultInvoiceDueDays is how long after issue an invoice falls due when it
+// is sent without a due date
+const defaultInvoiceDueDays = 15
+
+// invoiceReadOnlyFields are returned with an invoice but cannot be set through
+// UpdateInvoice; clients may echo them back
+var invoiceReadOnlyFields = map[string]bool{
+	"id": true, "projectId": true, "number": true, "financialYear": true, "status": true,
+	"clientId": true, "clientName": true, "billingAddress": true, "currency": true, "total": true,
+	"createdAt": true, "sentAt": true, "paidAt": true, "voidedAt": true, "voidReason": true,
+	"taxInclusive": true, "supplierGstin": true, "supplierState": true, "clientGstin": true, "placeOfSupply": true,
+	"subtotal": true, "taxableValue": true, "cgst": true, "sgst": true, "igst": true,
+}
+
+// invoiceItemRequest is an invoice item as sent by clients, who leave the
+// amounts and tax to the server. GSTRate defaults to the project's rate.
+type invoiceItemRequest struct {
+	Description string          `json:"description"`
+	HSNSAC      *string         `json:"hsnSac"`
+	Quantity    int64           `json:"quantity"`
+	UnitPrice   models.Money    `json:"unitPrice"`
+	GSTRate     *models.Percent `json:"gstRate"`
+
+	// Worked out by the server; accepted so an invoice's items can be sent back
+	Amount       *models.Money `json:"amount"`
+	TaxableValue *models.Money `json:"taxableValue"`
+	CGST         *models.Money `json:"cgst"`
+	SGST         *models.Money `json:"sgst"`
+	IGST         *models.Money `json:"igst"`
+}
+
+// decodeOptionalBody decodes a JSON body into v, treating an empty body as {}
+func decodeOptionalBody(r *http.Request, v interface{}) error {
+	err := json.NewDecoder(r.Body).Decode(v)
+	if errors.Is(err, io.EOF) {
+		return nil
+	}
+	return err
+}
+
+func fetchInvoiceItems(q querier, invoiceID string) ([]models.InvoiceItem, error) {
+	rows, err := q.Query(`
+		SELECT description, hsn_sac, quantity, unit_price, amount, gst_rate, taxable_value, cgst, sgst, igst
+		FROM invoice_items
+		WHERE invoice_id = ?
+		ORDER BY position
+	`, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []models.InvoiceItem{}
+	for rows.Next() {
+		var item models.InvoiceItem
+		err := rows.Scan(&item.Description, &item.HSNSAC, &item.Quantity, &item.UnitPrice, &item.Amount,
+			&item.GSTRate, &item.TaxableValue, &item.CGST, &item.SGST, &item.IGST)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, rows.Err()
+}
+
+// fetchInvoice returns an invoice with its items, or sql.ErrNoRows
+func fetchInvoice(q querier, id string) (models.Invoice, error) {
+	var inv models.Invoice
+	if err := inv.Scan(q.QueryRow(`SELECT `+invoiceColumns+` FROM invoices WHERE id = ?`, id)); err != nil {
+		return inv, err
+	}
+	items, err := fetchInvoiceItems(q, id)
+	if err != nil {
+		return inv, err
+	}
+	inv.Items = items
+	return inv, nil
+}
+
+// saveInvoiceItems works out the tax on an invoice, then replaces its items
+// and updates its GST details and totals
+func saveInvoiceItems(tx *sql.Tx, inv *models.Invoice) error {
+	applyGST(inv)
+
+	if _, err := tx.Exec(`DELETE FROM invoice_items WHERE invoice_id = ?`, inv.ID); err != nil {
+		return err
+	}
+	for i, item := range inv.Items {
+		_, err := tx.Exec(`
+			INSERT INTO invoice_items (invoice_id, position, description, hsn_sac, quantity, unit_price, amount,
+				gst_rate, taxable_value, cgst, sgst, igst)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, inv.ID, i+1, item.Description, item.HSNSAC, item.Quantity, item.UnitPrice, item.Amount,
+			item.GSTRate, item.TaxableValue, item.CGST, item.SGST, item.IGST)
+		if err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(`
+		UPDATE invoices
+		SET tax_inclusive = ?, supplier_gstin = ?, supplier_state = ?, client_gstin = ?, place_of_supply = ?,
+			subtotal = ?, taxable_value = ?, cgst = ?, sgst = ?, igst = ?, total = ?
+		WHERE id = ?
+	`, inv.TaxInclusive, inv.SupplierGSTIN, inv.SupplierState, inv.ClientGSTIN, inv.PlaceOfSupply,
+		inv.Subtotal, inv.TaxableValue, inv.CGST, inv.SGST, inv.IGST, inv.Total, inv.ID)
+	return err
+}
+
+// applyGST works out the tax on each item of inv and the invoice totals. Tax
+// is only charged when the issuer has a GSTIN; the place of supply is then
+// the client's state, or the issuer's own when the client's is not known.
+func applyGST(inv *models.Invoice) {
+	inv.SupplierGSTIN, inv.SupplierState = nil, nil
+	if Issuer.GSTIN != "" {
+		gstin, state := Issuer.GSTIN, Issuer.State
+		inv.SupplierGSTIN, inv.SupplierState = &gstin, &state
+		if inv.PlaceOfSupply == nil {
+			inv.PlaceOfSupply = &state
+		}
+	}
+
+	inv.Subtotal, inv.TaxableValue, inv.CGST, inv.SGST, inv.IGST = 0, 0, 0, 0, 0
+	for i := range inv.Items {
+		item := &inv.Items[i]
+		g := models.GSTAmounts{Taxable: item.Amount}
+		if inv.SupplierGSTIN != nil {
+			g = models.ComputeGST(item.Amount, item.GSTRate, inv.TaxInclusive, inv.InterState())
+		}
+		item.TaxableValue, item.CGST, item.SGST, item.IGST = g.Taxable, g.CGST, g.SGST, g.IGST
+
+		inv.Subtotal += item.Amount
+		inv.TaxableValue += g.Taxable
+		inv.CGST += g.CGST
+		inv.SGST += g.SGST
+		inv.IGST += g.IGST
+	}
+	inv.Total = inv.TaxableValue + inv.Tax()
+}
+
+// invoiceTotal sums the item amounts, which is what counts against the
+// project total
+func invoiceTotal(items []models.InvoiceItem) models.Money {
+	var total models.Money
+	for _, item := range items {
+		total += item.Amount
+	}
+	return total
+}
+
+// defaultHSNSAC returns DefaultSAC for an item without a code, or nil
+func defaultHSNSAC() *string {
+	if DefaultSAC == "" {
+		return nil
+	}
+	code := DefaultSAC
+	return &code
+}
+
+// validateInvoiceItems checks items from a request and works out their
+// amounts, taxing them at rate unless they give their own. It returns a
+// message for the first invalid item.
+func validateInvoiceItems(items []invoiceItemRequest, rate models.Percent) ([]models.InvoiceItem, string) {
+	if len(items) == 0 {
+		return nil, "items must not be empty"
+	}
+	valid := make([]models.InvoiceItem, len(items))
+	for i, req := range items {
+		item := models.InvoiceItem{
+			Description: strings.TrimSpace(req.Description),
+			HSNSAC:      req.HSNSAC,
+			Quantity:    req.Quantity,
+			UnitPrice:   req.UnitPrice,
+			GSTRate:     rate,
+		}
+		if item.Description == "" {
+			return nil, fmt.Sprintf("items[%d].description is required", i)
+		}
+		if item.HSNSAC == nil {
+			item.HSNSAC = defaultHSNSAC()
+		} else if code := strings.TrimSpace(*item.HSNSAC); !hsnSACPattern.MatchString(code) {
+			return nil, fmt.Sprintf("items[%d].hsnSac must be a 4, 6 or 8 digit HSN or SAC code", i)
+		} else {
+			item.HSNSAC = &code
+		}
+		if item.Quantity == 0 {
+			item.Quantity = 1
+		}
+		if item.Quantity < 0 {
+			return nil, fmt.Sprintf("items[%d].quantity must be greater than 0", i)
+		}
+		if item.UnitPrice <= 0 {
+			return nil, fmt.Sprintf("items[%d].unitPrice must be greater than 0", i)
+		}
+		if req.GSTRate != nil {
+			if !models.ValidGSTRate(*req.GSTRate) {
+				return nil, fmt.Sprintf("items[%d].gstRate must be one of the GST rates: 0, 0.25, 3, 5, 12, 18, 28 or 40", i)
+			}
+			item.GSTRate = *req.GSTRate
+		}
+		item.Amount = item.UnitPrice * models.Money(item.Quantity)
+		valid[i] = item
+	}
+	return valid, ""
+}
+
+// deliverableItems bills amount with one item per deliverable of the project,
+// split evenly with any remainder on the first item. Without deliverables the
+// project name is the only item.
+func deliverableItems(p *models.Project, amount models.Money) []models.InvoiceItem {
+	lines := deliverableLines(p.Deliverables)
+	if len(lines) == 0 {
+		lines = []string{p.Name}
+	}
+
+	share := amount / models.Money(len(lines))
+	items := make([]models.InvoiceItem, len(lines))
+	for i, line := range lines {
+		price := share
+		if i == 0 {
+			price = amount - share*models.Money(len(lines)-1)
+		}
+		items[i] = models.InvoiceItem{
+			Description: line,
+			HSNSAC:      defaultHSNSAC(),
+			Quantity:    1,
+			UnitPrice:   price,
+			Amount:      price,
+			GSTRate:     p.GSTRate,
+		}
+	}
+	return items
+}
+
+// deliverableLines lists a project's deliverables. They are stored as a
+// JSON-encoded array like techStack; plain text from before that is split
+// into lines, dropping any list bullets.
+func deliverableLines(deliverables *string) []string {
+	if deliverables == nil {
+		return nil
+	}
+
+	var entries []string
+	if err := json.Unmarshal([]byte(*deliverables), &entries); err != nil {
+		entries = strings.Split(*deliverables, "\n")
+	}
+
+	lines := []string{}
+	for _, entry := range entries {
+		entry = strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(entry), "-*•"))
+		if entry != "" {
+			lines = append(lines, entry)
+		}
+	}
+	return lines
+}
+
+// invoicedTotal sums the subtotals of the invoices of a project that have not
+// been voided, leaving out excludeID
+func invoicedTotal(q querier, projectID, excludeID string) (models.Money, error) {
+	var total models.Money
+	err := q.QueryRow(`
+		SELECT COALESCE(SUM(subtotal), 0)
+		FROM invoices
+		WHERE project_id = ? AND status != ? AND id != ?
+	`, projectID, models.InvoiceVoid, excludeID).Scan(&total)
+	return total, err
+}
+
+// billTo copies the client details to print on an invoice for p onto inv,
+// including the GSTIN and the state that is the place of supply
+func billTo(q querier, p *models.Project, inv *models.Invoice) error {
+	inv.ClientID, inv.ClientName, inv.BillingAddress = nil, p.ClientName, nil
+	inv.ClientGSTIN, inv.PlaceOfSupply = nil, nil
+	if p.ClientID == nil {
+		return nil
+	}
+	var c models.Client
+	if err := c.Scan(q.QueryRow(`SELECT `+clientColumns+` FROM clients WHERE id = ?`, *p.ClientID)); err != nil {
+		return err
+	}
+	inv.ClientID, inv.ClientName, inv.BillingAddress = &c.ID, &c.Name, c.BillingAddress
+	inv.ClientGSTIN, inv.PlaceOfSupply = c.GSTIN, c.State
+	return nil
+}
+
+// checkInvoiceDates validates issue and due dates, which are plain dates
+func checkInvoiceDates(issueDate, dueDate *string) string {
+	if issueDate != nil {
+		if _, err := time.Parse("2006-01-02", *issueDate); err != nil {
+			return "issueDate must be in YYYY-MM-DD format"
+		}
+	}
+	if dueDate != nil {
+		if _, err := time.Parse("2006-01-02", *dueDate); err != nil {
+			return "dueDate must be in YYYY-MM-DD format"
+		}
+	}
+	if issueDate != nil && dueDate != nil && *dueDate < *issueDate {
+		return "dueDate cannot be before issueDate"
+	}
+	return ""
+}
+
+func GetInvoices(w http.ResponseWriter, r *http.Request) {
+	listInvoices(w, r, r.URL.Query().Get("projectId"))
+}
+
+// GetProjectInvoices lists a project's invoices. Like the audit trail it does
+// not require the project to still exist.
+func GetProjectInvoices(w http.ResponseWriter, r *http.Request) {
+	listInvoices(w, r, mux.Vars(r)["id"])
+}
+
+// listInvoices writes the invoices matching projectID and the status query
+// parameter, issued ones by number and drafts first
+func listInvoices(w http.ResponseWriter, r *http.Request, projectID string) {
+	conditions := []string{}
+	args := []interface{}{}
+
+	if projectID != "" {
+		conditions = append(conditions, "project_id = ?")
+		args = append(args, projectID)
+	}
+	if status := r.URL.Query().Get("status"); status != "" {
+		switch status {
+		case models.InvoiceDraft, models.InvoiceSent, models.InvoicePaid, models.InvoiceVoid:
+		default:
+			respondError(w, http.StatusBadRequest, "status must be one of draft, sent, paid, void")
+			return
+		}
+		conditions = append(conditions, "status = ?")
+		args = append(args, status)
+	}
+
+	where := ""
+	if len(conditions) > 0 {
+		where = "WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT `+invoiceColumns+`
+		FROM invoices
+		`+where+`
+		ORDER BY number IS NOT NULL, financial_year DESC, sequence DESC, created_at DESC
+	`, args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoices")
+		return
+	}
+
+	invoices := []models.Invoice{}
+	for rows.Next() {
+		var inv models.Invoice
+		if err := inv.ScanRows(rows); err != nil {
+			rows.Close()
+			respondError(w, http.StatusInternalServerError, "Failed to scan invoice")
+			return
+		}
+		invoices = append(invoices, inv)
+	}
+	rows.Close()
+
+	for i := range invoices {
+		items, err := fetchInvoiceItems(db.DB, invoices[i].ID)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch invoice items")
+			return
+		}
+		invoices[i].Items = items
+	}
+
+	respondJSON(w, http.StatusOK, invoices)
+}
+
+func GetInvoice(w http.ResponseWriter, r *http.Request) {
+	inv, err := fetchInvoice(db.DB, mux.Vars(r)["invoiceId"])
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Invoice not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoice")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// CreateInvoice drafts an invoice for a project. Items may be given; otherwise
+// amount, by default whatever is left to invoice on the project, is billed
+// against the project's deliverables. Invoices that have not been voided can
+// never add up to more than the project total.
+func CreateInvoice(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["id"]
+
+	var req struct {
+		Amount    *models.Money        `json:"amount"`
+		Items     []invoiceItemRequest `json:"items"`
+		IssueDate *string              `json:"issueDate"`
+		DueDate   *string              `json:"dueDate"`
+		Notes     *string              `json:"notes"`
+	}
+	if err := decodeOptionalBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+	if req.Amount != nil && req.Items != nil {
+		respondError(w, http.StatusBadRequest, "Send either amount or items, not both")
+		return
+	}
+	if req.Amount != nil && *req.Amount <= 0 {
+		respondError(w, http.StatusBadRequest, "amount must be greater than 0")
+		return
+	}
+	if msg := checkInvoiceDates(req.IssueDate, req.DueDate); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create invoice")
+		return
+	}
+	defer tx.Rollback()
+
+	var p models.Project
+	err = p.Scan(tx.QueryRow(`SELECT `+projectColumns+` FROM projects WHERE id = ? AND deletedAt IS NULL`, projectID))
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+
+	invoiced, err := invoicedTotal(tx, projectID, "")
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoices")
+		return
+	}
+	remaining := p.TotalAmount - invoiced
+
+	var items []models.InvoiceItem
+	if req.Items != nil {
+		var msg string
+		if items, msg = validateInvoiceItems(req.Items, p.GSTRate); msg != "" {
+			respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+	} else {
+		amount := remaining
+		if req.Amount != nil {
+			amount = *req.Amount
+		}
+		if amount <= 0 {
+			respondError(w, http.StatusUnprocessableEntity, "The project has been fully invoiced")
+			return
+		}
+		items = deliverableItems(&p, amount)
+	}
+	if invoiceTotal(items) > remaining {
+		respondError(w, http.StatusUnprocessableEntity, "Invoice total exceeds the "+remaining.String()+" left to invoice on the project")
+		return
+	}
+
+	inv := models.Invoice{
+		ID:           uuid.New().String(),
+		ProjectID:    projectID,
+		Status:       models.InvoiceDraft,
+		Currency:     p.Currency,
+		IssueDate:    req.IssueDate,
+		DueDate:      req.DueDate,
+		Notes:        req.Notes,
+		Items:        items,
+		CreatedAt:    time.Now().UTC().Format(time.RFC3339),
+		TaxInclusive: p.TaxInclusive,
+	}
+	if err := billTo(tx, &p, &inv); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch client")
+		return
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO invoices (id, project_id, status, client_id, client_name, billing_address, currency, issue_date, due_date, notes, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, inv.ID, inv.ProjectID, inv.Status, inv.ClientID, inv.ClientName, inv.BillingAddress, inv.Currency,
+		inv.IssueDate, inv.DueDate, inv.Notes, inv.CreatedAt)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create invoice")
+		return
+	}
+	if err := saveInvoiceItems(tx, &inv); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save invoice items")
+		return
+	}
+
+	field := "invoice"
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "INVOICE_CREATED", &field, nil, &inv.ID, inv.CreatedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create invoice")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, inv)
+}
+
+// UpdateInvoice changes the dates, notes or items of a draft invoice. Items
+// are replaced as a whole.
+func UpdateInvoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["invoiceId"]
+
+	var updates map[string]json.RawMessage
+	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	if len(updates) == 0 {
+		respondError(w, http.StatusBadRequest, "No fields to update")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update invoice")
+		return
+	}
+	defer tx.Rollback()
+
+	inv, err := fetchInvoice(tx, id)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Invoice not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoice")
+		return
+	}
+	if inv.Status != models.InvoiceDraft {
+		respondError(w, http.StatusConflict, "Only draft invoices can be edited")
+		return
+	}
+
+	var itemRequests []invoiceItemRequest
+	for field, raw := range updates {
+		var err error
+		switch field {
+		case "issueDate":
+			inv.IssueDate = nil
+			err = json.Unmarshal(raw, &inv.IssueDate)
+		case "dueDate":
+			inv.DueDate = nil
+			err = json.Unmarshal(raw, &inv.DueDate)
+		case "notes":
+			inv.Notes = nil
+			err = json.Unmarshal(raw, &inv.Notes)
+		case "items":
+			if err = json.Unmarshal(raw, &itemRequests); err == nil && itemRequests == nil {
+				itemRequests = []invoiceItemRequest{}
+			}
+		default:
+			if !invoiceReadOnlyFields[field] {
+				respondError(w, http.StatusBadRequest, "Unknown field: "+field)
+				return
+			}
+		}
+		if err != nil {
+			respondError(w, http.StatusBadRequest, field+": "+bodyErrorMessage(err))
+			return
+		}
+	}
+	if msg := checkInvoiceDates(inv.IssueDate, inv.DueDate); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	if itemRequests != nil {
+		var projectTotal models.Money
+		var rate models.Percent
+		err := tx.QueryRow(`SELECT totalAmount, gstRate FROM projects WHERE id = ?`, inv.ProjectID).Scan(&projectTotal, &rate)
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusUnprocessableEntity, "The invoice's project no longer exists")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+			return
+		}
+		items, msg := validateInvoiceItems(itemRequests, rate)
+		if msg != "" {
+			respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		invoiced, err := invoicedTotal(tx, inv.ProjectID, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch invoices")
+			return
+		}
+		if invoiceTotal(items) > projectTotal-invoiced {
+			respondError(w, http.StatusUnprocessableEntity, "Invoice total exceeds the "+(projectTotal-invoiced).String()+" left to invoice on the project")
+			return
+		}
+		inv.Items = items
+		if err := saveInvoiceItems(tx, &inv); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to save invoice items")
+			return
+		}
+	}
+
+	_, err = tx.Exec(`UPDATE invoices SET issue_date = ?, due_date = ?, notes = ? WHERE id = ?`,
+		inv.IssueDate, inv.DueDate, inv.Notes, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update invoice")
+		return
+	}
+
+	if inv, err = fetchInvoice(tx, id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated invoice")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update invoice")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// DeleteInvoice removes a draft. Issued invoices keep their number and can
+// only be voided.
+func DeleteInvoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["invoiceId"]
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete invoice")
+		return
+	}
+	defer tx.Rollback()
+
+	var projectID, status string
+	err = tx.QueryRow(`SELECT project_id, status FROM invoices WHERE id = ?`, id).Scan(&projectID, &status)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Invoice not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoice")
+		return
+	}
+	if status != models.InvoiceDraft {
+		respondError(w, http.StatusConflict, "Issued invoices cannot be deleted; void them instead")
+		return
+	}
+
+	if _, err := tx.Exec(`DELETE FROM invoices WHERE id = ?`, id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete invoice")
+		return
+	}
+
+	field := "invoice"
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "INVOICE_DELETED", &field, &id, nil, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete invoice")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Invoice deleted"})
+}
+
+// SendInvoice issues a draft: it takes the next number of the financial year
+// its issue date falls in, and the client details are copied onto it. The
+// immediate transaction serializes sends, so numbers are gap-free, and they
+// run in issue date order within a year.
+func SendInvoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["invoiceId"]
+
+	var req struct {
+		IssueDate *string `json:"issueDate"`
+		DueDate   *string `json:"dueDate"`
+	}
+	if err := decodeOptionalBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to send invoice")
+		return
+	}
+	defer tx.Rollback()
+
+	inv, err := fetchInvoice(tx, id)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Invoice not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoice")
+		return
+	}
+	if inv.Status != models.InvoiceDraft {
+		respondError(w, http.StatusConflict, "Only draft invoices can be sent")
+		return
+	}
+	if len(inv.Items) == 0 {
+		respondError(w, http.StatusUnprocessableEntity, "Invoice has no items")
+		return
+	}
+
+	issueDate := time.Now().UTC().Format("2006-01-02")
+	if req.IssueDate != nil {
+		issueDate = *req.IssueDate
+	} else if inv.IssueDate != nil {
+		issueDate = *inv.IssueDate
+	}
+	if msg := checkInvoiceDates(&issueDate, nil); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+	issued, _ := time.Parse("2006-01-02", issueDate)
+
+	dueDate := issued.AddDate(0, 0, defaultInvoiceDueDays).Format("2006-01-02")
+	if req.DueDate != nil {
+		dueDate = *req.DueDate
+	} else if inv.DueDate != nil {
+		dueDate = *inv.DueDate
+	}
+	if msg := checkInvoiceDates(&issueDate, &dueDate); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	financialYear := models.FinancialYear(issued)
+
+	var lastIssueDate *string
+	var lastSequence int64
+	err = tx.QueryRow(`
+		SELECT MAX(issue_date), COALESCE(MAX(sequence), 0)
+		FROM invoices
+		WHERE financial_year = ?
+	`, financialYear).Scan(&lastIssueDate, &lastSequence)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoice numbers")
+		return
+	}
+	if lastIssueDate != nil && issueDate < *lastIssueDate {
+		respondError(w, http.StatusUnprocessableEntity, "issueDate cannot be before "+*lastIssueDate+", when the last invoice of "+financialYear+" was issued")
+		return
+	}
+
+	sequence := lastSequence + 1
+	number := fmt.Sprintf("%s/%s/%04d", InvoicePrefix, financialYear, sequence)
+
+	// Bill the client as it is now, if the project is still around; a purged
+	// project leaves the details copied when the draft was made
+	var p models.Project
+	err = p.Scan(tx.QueryRow(`SELECT `+projectColumns+` FROM projects WHERE id = ?`, inv.ProjectID))
+	if err != nil && err != sql.ErrNoRows {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if err == nil {
+		invoiced, err := invoicedTotal(tx, inv.ProjectID, id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch invoices")
+			return
+		}
+		if inv.Subtotal > p.TotalAmount-invoiced {
+			respondError(w, http.StatusUnprocessableEntity, "Invoice total exceeds the "+(p.TotalAmount-invoiced).String()+" left to invoice on the project")
+			return
+		}
+		if err := billTo(tx, &p, &inv); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch client")
+			return
+		}
+		inv.TaxInclusive = p.TaxInclusive
+	}
+
+	// A tax invoice must classify every item; the tax is worked out afresh
+	// for the client and GST registration as they are now
+	if Issuer.GSTIN != "" {
+		for i, item := range inv.Items {
+			if item.HSNSAC == nil {
+				respondError(w, http.StatusUnprocessableEntity, fmt.Sprintf("items[%d] needs an HSN/SAC code on a tax invoice", i))
+				return
+			}
+		}
+	}
+	if err := saveInvoiceItems(tx, &inv); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to save invoice items")
+		return
+	}
+
+	sentAt := time.Now().UTC().Format(time.RFC3339)
+	_, err = tx.Exec(`
+		UPDATE invoices
+		SET number = ?, financial_year = ?, sequence = ?, status = ?, issue_date = ?, due_date = ?,
+			sent_at = ?, client_id = ?, client_name = ?, billing_address = ?
+		WHERE id = ?
+	`, number, financialYear, sequence, models.InvoiceSent, issueDate, dueDate,
+		sentAt, inv.ClientID, inv.ClientName, inv.BillingAddress, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to send invoice")
+		return
+	}
+
+	// The entry ties the draft's id to the number it was issued under
+	field := "invoice"
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), inv.ProjectID, "INVOICE_SENT", &field, &id, &number, sentAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+
+	if inv, err = fetchInvoice(tx, id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch sent invoice")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to send invoice")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
+
+// MarkInvoicePaid records that a sent invoice has been settled, on paidAt or
+// today
+func MarkInvoicePaid(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["invoiceId"]
+
+	var req struct {
+		PaidAt *string `json:"paidAt"`
+	}
+	if err := decodeOptionalBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+	paidAt := time.Now().UTC().Format("2006-01-02")
+	if req.PaidAt != nil {
+		if _, err := time.Parse("2006-01-02", *req.PaidAt); err != nil {
+			respondError(w, http.StatusBadRequest, "paidAt must be in YYYY-MM-DD format")
+			return
+		}
+		paidAt = *req.PaidAt
+	}
+
+	changeInvoiceStatus(w, r, id, []string{models.InvoiceSent}, models.InvoicePaid, "INVOICE_PAID",
+		"Only sent invoices can be marked paid", `paid_at = ?`, paidAt)
+}
+
+// VoidInvoice cancels an issued invoice. It keeps its number, so the
+// sequence stays gap-free.
+func VoidInvoice(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["invoiceId"]
+
+	var req struct {
+		Reason *string `json:"reason"`
+	}
+	if err := decodeOptionalBody(r, &req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	changeInvoiceStatus(w, r, id, []string{models.InvoiceSent, models.InvoicePaid}, models.InvoiceVoid, "INVOICE_VOIDED",
+		"Only issued invoices can be voided; delete drafts instead", `voided_at = ?, void_reason = ?`,
+		time.Now().UTC().Format(time.RFC3339), req.Reason)
+}
+
+// changeInvoiceStatus moves an invoice in one of the from statuses to status,
+// setting the extra columns too, and audits it as action
+func changeInvoiceStatus(w http.ResponseWriter, r *http.Request, id string, from []string, status, action, conflict, set string, args ...interface{}) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update invoice")
+		return
+	}
+	defer tx.Rollback()
+
+	inv, err := fetchInvoice(tx, id)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Invoice not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch invoice")
+		return
+	}
+
+	allowed := false
+	for _, s := range from {
+		allowed = allowed || inv.Status == s
+	}
+	if !allowed {
+		respondError(w, http.StatusConflict, conflict)
+		return
+	}
+
+	args = append([]interface{}{status}, append(args, id)...)
+	if _, err := tx.Exec(`UPDATE invoices SET status = ?, `+set+` WHERE id = ?`, args...); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update invoice")
+		return
+	}
+
+	field := "invoice"
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), inv.ProjectID, action, &field, nil, inv.Number, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+
+	if inv, err = fetchInvoice(tx, id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated invoice")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update invoice")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inv)
+}
@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"project-tracker/models"
+)
+
+// createDraft drafts an invoice on the project from body and returns it
+func createDraft(t *testing.T, projectID, body string) models.Invoice {
+	t.Helper()
+	var inv models.Invoice
+	decode(t, serve(CreateInvoice, "POST", map[string]string{"id": projectID}, body), http.StatusCreated, &inv)
+	return inv
+}
+
+func TestSendInvoiceNumbering(t *testing.T) {
+	openTestDB(t)
+	projectID := createProject(t, `{"totalAmount": 100000}`)["id"].(string)
+
+	drafts := map[string]string{}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		drafts[name] = createDraft(t, projectID, `{"amount": 1000}`).ID
+	}
+
+	for _, step := range []struct {
+		name      string
+		draft     string
+		issueDate string
+		status    int
+		number    string
+	}{
+		{"first of the year", "a", "2026-04-10", http.StatusOK, "INV/2026-27/0001"},
+		{"next in the year", "b", "2026-04-12", http.StatusOK, "INV/2026-27/0002"},
+		{"before the last issued", "c", "2026-04-11", http.StatusUnprocessableEntity, ""},
+		{"previous year starts its own series", "c", "2026-03-31", http.StatusOK, "INV/2025-26/0001"},
+		{"already sent", "a", "2026-04-20", http.StatusConflict, ""},
+		{"same day as the last issued", "d", "2026-04-12", http.StatusOK, "INV/2026-27/0003"},
+		{"rejected sends leave no gap", "e", "2027-03-31", http.StatusOK, "INV/2026-27/0004"},
+	} {
+		w := serve(SendInvoice, "POST", map[string]string{"invoiceId": drafts[step.draft]}, `{"issueDate": "`+step.issueDate+`"}`)
+		if w.Code != step.status {
+			t.Fatalf("%s: status %d, want %d: %s", step.name, w.Code, step.status, w.Body)
+		}
+		if step.status != http.StatusOK {
+			continue
+		}
+
+		var inv models.Invoice
+		decode(t, w, http.StatusOK, &inv)
+		if inv.Number == nil || *inv.Number != step.number || inv.Status != models.InvoiceSent {
+			t.Errorf("%s: invoice %s is %s, want %s sent", step.name, show(inv.Number), inv.Status, step.number)
+		}
+	}
+}
+
+func TestInvoiceItemsFromDeliverables(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"name": "Shop", "totalAmount": 1000, "deliverables": "- Design\n* Storefront\n\n  • Launch  "}`)
+	projectID := project["id"].(string)
+
+	// The amount is split evenly, the odd paisa going to the first item, and
+	// each item takes the project's GST rate
+	inv := createDraft(t, projectID, `{"amount": 100}`)
+	want := []models.InvoiceItem{
+		{Description: "Design", Quantity: 1, UnitPrice: 3334, Amount: 3334, GSTRate: 1800, TaxableValue: 3334},
+		{Description: "Storefront", Quantity: 1, UnitPrice: 3333, Amount: 3333, GSTRate: 1800, TaxableValue: 3333},
+		{Description: "Launch", Quantity: 1, UnitPrice: 3333, Amount: 3333, GSTRate: 1800, TaxableValue: 3333},
+	}
+	if len(inv.Items) != len(want) || inv.Total != 10000 {
+		t.Fatalf("items = %+v, total %s", inv.Items, inv.Total)
+	}
+	for i := range want {
+		if inv.Items[i] != want[i] {
+			t.Errorf("item %d = %+v, want %+v", i, inv.Items[i], want[i])
+		}
+	}
+
+	// Without an amount, whatever is left to invoice is billed
+	rest := createDraft(t, projectID, "")
+	if rest.Total != 90000 || len(rest.Items) != 3 {
+		t.Errorf("remaining invoice total %s with %d items, want 900.00 over 3", rest.Total, len(rest.Items))
+	}
+	decode(t, serve(CreateInvoice, "POST", map[string]string{"id": projectID}, `{"amount": 1}`), http.StatusUnprocessableEntity, nil)
+
+	// The frontend stores deliverables as a JSON-encoded list
+	listed := createProject(t, `{"totalAmount": 1000, "deliverables": "[\"Wireframes\", \" \", \"Final designs\"]"}`)
+	inv = createDraft(t, listed["id"].(string), `{"amount": 100}`)
+	if len(inv.Items) != 2 || inv.Items[0].Description != "Wireframes" || inv.Items[1].Description != "Final designs" {
+		t.Errorf("items from a deliverables list = %+v", inv.Items)
+	}
+
+	// A project without deliverables is billed under its name
+	other := createProject(t, `{"name": "Support retainer", "totalAmount": 500}`)
+	inv = createDraft(t, other["id"].(string), "")
+	if len(inv.Items) != 1 || inv.Items[0].Description != "Support retainer" || inv.Items[0].Amount != 50000 {
+		t.Errorf("items = %+v, want one for the project name", inv.Items)
+	}
+}
+
+func TestRenderInvoiceHTML(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"name": "Tom & Jerry's <shop>", "totalAmount": 250000, "deliverables": "Design\nBuild"}`)
+	inv := createDraft(t, project["id"].(string), `{"amount": 123456.5}`)
+	vars := map[string]string{"invoiceId": inv.ID}
+
+	w := serve(RenderInvoiceHTML, "GET", vars, "")
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Fatalf("render: status %d, %s", w.Code, w.Header().Get("Content-Type"))
+	}
+	for _, s := range []string{"Draft invoice", "Design", "Build", "INR 1,23,456.50", "Tom &amp; Jerry&#39;s &lt;shop&gt;"} {
+		if !strings.Contains(w.Body.String(), s) {
+			t.Errorf("default template output lacks %q", s)
+		}
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `inline; filename="draft-`+inv.ID+`.html"` {
+		t.Errorf("draft Content-Disposition = %s", cd)
+	}
+
+	// A branded template replaces the built-in one and gets the same data
+	path := filepath.Join(t.TempDir(), "branded.html")
+	branded := `<h1>Acme</h1>{{.Title}} {{with .Invoice.Number}}{{.}}{{end}} for {{.Project}}:` +
+		`{{range $i, $item := .Invoice.Items}} {{inc $i}}. {{$item.Description}} {{money $item.Amount $.Invoice.Currency}};{{end}}` +
+		` total {{money .Invoice.Total .Invoice.Currency}}`
+	if err := os.WriteFile(path, []byte(branded), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	builtIn := invoiceTemplate
+	t.Cleanup(func() { invoiceTemplate = builtIn })
+	if err := LoadInvoiceTemplate(path); err != nil {
+		t.Fatalf("load template: %v", err)
+	}
+
+	decode(t, serve(SendInvoice, "POST", vars, `{"issueDate": "2026-05-04"}`), http.StatusOK, nil)
+	w = serve(RenderInvoiceHTML, "GET", vars, "")
+	want := `<h1>Acme</h1>Invoice INV/2026-27/0001 for Tom &amp; Jerry&#39;s &lt;shop&gt;: 1. Design INR 61,728.25; 2. Build INR 61,728.25; total INR 1,23,456.50`
+	if w.Body.String() != want {
+		t.Errorf("branded output\n%s\nwant\n%s", w.Body, want)
+	}
+	if cd := w.Header().Get("Content-Disposition"); cd != `inline; filename="INV-2026-27-0001.html"` {
+		t.Errorf("sent Content-Disposition = %s", cd)
+	}
+
+	// A broken template is rejected and the one in use is kept
+	os.WriteFile(path, []byte(`{{.Title`), 0o600)
+	if err := LoadInvoiceTemplate(path); err == nil {
+		t.Error("loaded a template that does not parse")
+	}
+	if err := LoadInvoiceTemplate(filepath.Join(t.TempDir(), "missing.html")); err == nil {
+		t.Error("loaded a missing template")
+	}
+	if w := serve(RenderInvoiceHTML, "GET", vars, ""); !strings.HasPrefix(w.Body.String(), "<h1>Acme</h1>") {
+		t.Errorf("after failed loads: %s", w.Body)
+	}
+
+	decode(t, serve(RenderInvoiceHTML, "GET", map[string]string{"invoiceId": "missing"}, ""), http.StatusNotFound, nil)
+}
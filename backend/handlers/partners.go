@@ -0,0 +1,573 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const partnerColumns = `id, name, email, notes, created_at`
+
+const payoutColumns = `id, project_id, partner_id, amount, paid_at, note, created_at`
+
+// partnerExists reports whether a partner with the given id is present
+func partnerExists(id string) (bool, error) {
+	var exists bool
+	err := db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM partners WHERE id = ?)`, id).Scan(&exists)
+	return exists, err
+}
+
+func GetPartners(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.DB.Query(`
+		SELECT ` + partnerColumns + `
+		FROM partners
+		ORDER BY name ASC
+	`)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch partners")
+		return
+	}
+	defer rows.Close()
+
+	partners := []models.Partner{}
+	for rows.Next() {
+		var p models.Partner
+		if err := p.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan partner")
+			return
+		}
+		partners = append(partners, p)
+	}
+
+	respondJSON(w, http.StatusOK, partners)
+}
+
+func GetPartner(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["partnerId"]
+
+	var p models.Partner
+	err := p.Scan(db.DB.QueryRow(`
+		SELECT `+partnerColumns+`
+		FROM partners
+		WHERE id = ?
+	`, id))
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Partner not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch partner")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func CreatePartner(w http.ResponseWriter, r *http.Request) {
+	var p models.Partner
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+
+	p.ID = uuid.New().String()
+	p.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	_, err := db.DB.Exec(`
+		INSERT INTO partners (`+partnerColumns+`)
+		VALUES (?, ?, ?, ?, ?)
+	`, p.ID, p.Name, p.Email, p.Notes, p.CreatedAt)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A partner with this name already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to create partner")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, p)
+}
+
+func UpdatePartner(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["partnerId"]
+
+	updates, err := decodeUpdates(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(updates) == 0 {
+		respondError(w, http.StatusBadRequest, "No fields to update")
+		return
+	}
+
+	// Field mapping: JSON field name -> database column name
+	fieldMap := map[string]string{
+		"name":  "name",
+		"email": "email",
+		"notes": "notes",
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+
+	for jsonField, value := range updates {
+		if jsonField == "id" || jsonField == "createdAt" {
+			continue
+		}
+
+		dbField, ok := fieldMap[jsonField]
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Unknown field: "+jsonField)
+			return
+		}
+
+		if jsonField == "name" {
+			str, ok := value.(string)
+			if !ok || strings.TrimSpace(str) == "" {
+				respondError(w, http.StatusBadRequest, "name must be a non-empty string")
+				return
+			}
+			value = strings.TrimSpace(str)
+		}
+
+		setParts = append(setParts, dbField+" = ?")
+		args = append(args, value)
+	}
+
+	if len(setParts) == 0 {
+		respondError(w, http.StatusBadRequest, "No valid fields to update")
+		return
+	}
+
+	query := "UPDATE partners SET " + strings.Join(setParts, ", ") + " WHERE id = ?"
+	args = append(args, id)
+
+	result, err := db.DB.Exec(query, args...)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A partner with this name already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to update partner")
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		respondError(w, http.StatusNotFound, "Partner not found")
+		return
+	}
+
+	var p models.Partner
+	err = p.Scan(db.DB.QueryRow(`
+		SELECT `+partnerColumns+`
+		FROM partners
+		WHERE id = ?
+	`, id))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated partner")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func DeletePartner(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["partnerId"]
+
+	// Payout history must be preserved, so partners with payouts cannot be removed
+	var payoutCount int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM partner_payouts WHERE partner_id = ?`, id).Scan(&payoutCount); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check partner payouts")
+		return
+	}
+	if payoutCount > 0 {
+		respondError(w, http.StatusConflict, "Partner has recorded payouts and cannot be deleted")
+		return
+	}
+
+	var userCount int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM users WHERE partner_id = ?`, id).Scan(&userCount); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to check partner users")
+		return
+	}
+	if userCount > 0 {
+		respondError(w, http.StatusConflict, "Partner is linked to a user and cannot be deleted")
+		return
+	}
+
+	result, err := db.DB.Exec("DELETE FROM partners WHERE id = ?", id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete partner")
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		respondError(w, http.StatusNotFound, "Partner not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Partner deleted"})
+}
+
+// GetPartnerPayouts lists a partner's payouts. Partner users may only list
+// their own.
+func GetPartnerPayouts(w http.ResponseWriter, r *http.Request) {
+	partnerID := mux.Vars(r)["partnerId"]
+
+	if user := CurrentUser(r); !models.CanSeeInternal(user.Role) {
+		if user.Role != models.RolePartner || user.PartnerID == nil || *user.PartnerID != partnerID {
+			respondError(w, http.StatusForbidden, "Your role cannot see these payouts")
+			return
+		}
+	}
+
+	exists, err := partnerExists(partnerID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch partner")
+		return
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, "Partner not found")
+		return
+	}
+
+	listPayouts(w, `WHERE partner_id = ? AND `+inActiveProject, partnerID)
+}
+
+// GetProjectPayouts lists a project's payouts. Partner users only see their
+// own share; viewers see none.
+func GetProjectPayouts(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["id"]
+
+	user := CurrentUser(r)
+	if !models.CanSeeInternal(user.Role) && user.Role != models.RolePartner {
+		respondError(w, http.StatusForbidden, "Your role cannot see payouts")
+		return
+	}
+
+	exists, err := projectExists(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	if user.Role == models.RolePartner {
+		if user.PartnerID == nil {
+			respondJSON(w, http.StatusOK, []models.PartnerPayout{})
+			return
+		}
+		listPayouts(w, `WHERE project_id = ? AND partner_id = ?`, projectID, *user.PartnerID)
+		return
+	}
+
+	listPayouts(w, `WHERE project_id = ?`, projectID)
+}
+
+// listPayouts writes the payouts matching the given WHERE clause as a JSON array
+func listPayouts(w http.ResponseWriter, where string, args ...interface{}) {
+	payouts, err := fetchPayouts(db.DB, where, args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payouts")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, payouts)
+}
+
+// fetchPayouts returns the payouts matching the given WHERE clause, oldest first
+func fetchPayouts(q querier, where string, args ...interface{}) ([]models.PartnerPayout, error) {
+	rows, err := q.Query(`
+		SELECT `+payoutColumns+`
+		FROM partner_payouts
+		`+where+`
+		ORDER BY created_at ASC
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payouts := []models.PartnerPayout{}
+	for rows.Next() {
+		var p models.PartnerPayout
+		if err := p.ScanRows(rows); err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, p)
+	}
+	return payouts, rows.Err()
+}
+
+func CreatePayout(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["id"]
+
+	var p models.PartnerPayout
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		respondError(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+
+	if p.PartnerID == "" {
+		respondError(w, http.StatusBadRequest, "partnerId is required")
+		return
+	}
+	if p.Amount <= 0 {
+		respondError(w, http.StatusBadRequest, "amount must be greater than 0")
+		return
+	}
+	if p.Date != nil && !validateISODate(*p.Date) {
+		respondError(w, http.StatusBadRequest, "date must be in ISO format (YYYY-MM-DD or RFC3339)")
+		return
+	}
+
+	exists, err := projectExists(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	exists, err = partnerExists(p.PartnerID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch partner")
+		return
+	}
+	if !exists {
+		respondError(w, http.StatusBadRequest, "Unknown partner: "+p.PartnerID)
+		return
+	}
+
+	p.ID = uuid.New().String()
+	p.ProjectID = projectID
+	p.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create payout")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO partner_payouts (`+payoutColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, p.ID, p.ProjectID, p.PartnerID, p.Amount, p.Date, p.Note, p.CreatedAt)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create payout")
+		return
+	}
+
+	field := p.PartnerID
+	newVal := p.Amount.String()
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYOUT_ADDED", &field, nil, &newVal, p.CreatedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create payout")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, p)
+}
+
+func UpdatePayout(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	payoutID := vars["payoutId"]
+
+	updates, err := decodeUpdates(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(updates) == 0 {
+		respondError(w, http.StatusBadRequest, "No fields to update")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update payout")
+		return
+	}
+	defer tx.Rollback()
+
+	var old models.PartnerPayout
+	err = old.Scan(tx.QueryRow(`
+		SELECT `+payoutColumns+`
+		FROM partner_payouts
+		WHERE id = ? AND project_id = ? AND `+inActiveProject+`
+	`, payoutID, projectID))
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Payout not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payout")
+		return
+	}
+
+	// Field mapping: JSON field name -> database column name
+	fieldMap := map[string]string{
+		"amount": "amount",
+		"date":   "paid_at",
+		"note":   "note",
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+
+	for jsonField, value := range updates {
+		if jsonField == "id" || jsonField == "projectId" || jsonField == "partnerId" || jsonField == "createdAt" {
+			continue
+		}
+
+		dbField, ok := fieldMap[jsonField]
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Unknown field: "+jsonField)
+			return
+		}
+
+		switch jsonField {
+		case "amount":
+			amount, ok := parseMoneyValue(value)
+			if !ok || amount <= 0 {
+				respondError(w, http.StatusBadRequest, "amount must be a number greater than 0 with at most 2 decimal places")
+				return
+			}
+			value = amount
+		case "date":
+			if value != nil {
+				if str, ok := value.(string); !ok || !validateISODate(str) {
+					respondError(w, http.StatusBadRequest, "date must be in ISO format (YYYY-MM-DD or RFC3339)")
+					return
+				}
+			}
+		}
+
+		setParts = append(setParts, dbField+" = ?")
+		args = append(args, value)
+	}
+
+	if len(setParts) == 0 {
+		respondError(w, http.StatusBadRequest, "No valid fields to update")
+		return
+	}
+
+	query := "UPDATE partner_payouts SET " + strings.Join(setParts, ", ") + " WHERE id = ? AND project_id = ?"
+	args = append(args, payoutID, projectID)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update payout")
+		return
+	}
+
+	var p models.PartnerPayout
+	err = p.Scan(tx.QueryRow(`
+		SELECT `+payoutColumns+`
+		FROM partner_payouts
+		WHERE id = ?
+	`, payoutID))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated payout")
+		return
+	}
+
+	if old.Amount != p.Amount {
+		field := p.PartnerID
+		oldVal := old.Amount.String()
+		newVal := p.Amount.String()
+		ts := time.Now().UTC().Format(time.RFC3339)
+		if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYOUT_UPDATED", &field, &oldVal, &newVal, ts); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update payout")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func DeletePayout(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	payoutID := vars["payoutId"]
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete payout")
+		return
+	}
+	defer tx.Rollback()
+
+	var old models.PartnerPayout
+	err = old.Scan(tx.QueryRow(`
+		SELECT `+payoutColumns+`
+		FROM partner_payouts
+		WHERE id = ? AND project_id = ? AND `+inActiveProject+`
+	`, payoutID, projectID))
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Payout not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payout")
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM partner_payouts WHERE id = ?", payoutID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete payout")
+		return
+	}
+
+	field := old.PartnerID
+	oldVal := old.Amount.String()
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYOUT_DELETED", &field, &oldVal, nil, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete payout")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Payout deleted"})
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPartnerPayouts(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"totalReceived": 4000}`)
+	projectVars := map[string]string{"id": project["id"].(string)}
+
+	var partner map[string]interface{}
+	decode(t, serve(CreatePartner, "POST", nil, `{"name": "Asha", "email": "asha@example.com"}`), http.StatusCreated, &partner)
+	partnerVars := map[string]string{"partnerId": partner["id"].(string)}
+	decode(t, serve(CreatePartner, "POST", nil, `{"name": "Asha"}`), http.StatusConflict, nil)
+
+	var payout map[string]interface{}
+	body := `{"partnerId": "` + partner["id"].(string) + `", "amount": 1200, "date": "2026-03-01"}`
+	decode(t, serve(CreatePayout, "POST", projectVars, body), http.StatusCreated, &payout)
+	decode(t, serve(CreatePayout, "POST", projectVars, `{"partnerId": "nobody", "amount": 10}`), http.StatusBadRequest, nil)
+
+	var payouts []map[string]interface{}
+	decode(t, serve(GetPartnerPayouts, "GET", partnerVars, ""), http.StatusOK, &payouts)
+	if len(payouts) != 1 || payouts[0]["amount"] != 1200.0 || payouts[0]["projectId"] != project["id"] {
+		t.Errorf("partner payouts = %v, want the 1200 payout", payouts)
+	}
+
+	// Payouts are internal splits; what the client paid is unchanged
+	if got := getProject(t, project["id"].(string))["totalReceived"]; got != 4000.0 {
+		t.Errorf("totalReceived = %v, want 4000", got)
+	}
+
+	// A partner with payouts is kept, so the history stays complete
+	decode(t, serve(DeletePartner, "DELETE", partnerVars, ""), http.StatusConflict, nil)
+
+	payoutVars := map[string]string{"id": project["id"].(string), "payoutId": payout["id"].(string)}
+	decode(t, serve(UpdatePayout, "PUT", payoutVars, `{"amount": 900}`), http.StatusOK, nil)
+	decode(t, serve(GetProjectPayouts, "GET", projectVars, ""), http.StatusOK, &payouts)
+	if len(payouts) != 1 || payouts[0]["amount"] != 900.0 {
+		t.Errorf("project payouts = %v, want the payout updated to 900", payouts)
+	}
+
+	decode(t, serve(DeletePayout, "DELETE", payoutVars, ""), http.StatusOK, nil)
+	decode(t, serve(DeletePartner, "DELETE", partnerVars, ""), http.StatusOK, nil)
+	decode(t, serve(GetPartner, "GET", partnerVars, ""), http.StatusNotFound, nil)
+}
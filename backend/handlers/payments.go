@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// validPaymentMethods lists the accepted values for Payment.Method
+var validPaymentMethods = map[string]bool{
+	"cash":          true,
+	"upi":           true,
+	"bank_transfer": true,
+	"cheque":        true,
+	"card":          true,
+	"other":         true,
+}
+
+const paymentColumns = `id, project_id, amount, paid_at, method, reference, note, created_at, currency, tds_amount, tds_section`
+
+// inActiveProject limits ledger queries to projects that are not in the trash
+const inActiveProject = `project_id IN (SELECT id FROM projects WHERE deletedAt IS NULL)`
+
+// projectExists reports whether a project with the given id is present and
+// not in the trash
+func projectExists(id string) (bool, error) {
+	var exists bool
+	err := db.DB.QueryRow(`SELECT EXISTS(SELECT 1 FROM projects WHERE id = ? AND deletedAt IS NULL)`, id).Scan(&exists)
+	return exists, err
+}
+
+// projectCurrency returns the currency of a project, or sql.ErrNoRows if it
+// does not exist or is in the trash
+func projectCurrency(q querier, id string) (string, error) {
+	var currency string
+	err := q.QueryRow(`SELECT currency FROM projects WHERE id = ? AND deletedAt IS NULL`, id).Scan(&currency)
+	return currency, err
+}
+
+// paymentAuditFields are the payment fields audited when they change, in the
+// order their entries are written
+var paymentAuditFields = []string{"amount", "tdsAmount", "tdsSection", "date", "method", "reference", "note"}
+
+// paymentFieldValues renders a payment's fields as audit strings keyed by
+// JSON field name; absent fields map to nil
+func paymentFieldValues(p models.Payment) map[string]*string {
+	amount, tdsAmount := p.Amount.String(), p.TDSAmount.String()
+	return map[string]*string{
+		"amount":     &amount,
+		"tdsAmount":  &tdsAmount,
+		"tdsSection": p.TDSSection,
+		"date":       &p.Date,
+		"method":     p.Method,
+		"reference":  p.Reference,
+		"note":       p.Note,
+	}
+}
+
+// checkPaymentTDS validates the TDS recorded on a payment and works out its
+// gross amount, returning a message when the TDS details are inconsistent
+func checkPaymentTDS(p *models.Payment) string {
+	if p.TDSAmount < 0 {
+		return "tdsAmount cannot be negative"
+	}
+	if p.TDSSection != nil {
+		section, ok := models.NormalizeTDSSection(*p.TDSSection)
+		if !ok {
+			return "tdsSection must be an Income Tax Act section, e.g. 194J"
+		}
+		p.TDSSection = &section
+	}
+	if p.TDSAmount > 0 && p.TDSSection == nil {
+		return "tdsSection is required when tdsAmount is set"
+	}
+	if p.TDSAmount == 0 && p.TDSSection != nil {
+		return "tdsSection requires a tdsAmount"
+	}
+	// TDS is an Indian tax, deducted from rupee payments only
+	if p.TDSAmount > 0 && p.Currency != "INR" {
+		return "TDS can only be recorded on INR payments"
+	}
+	p.GrossAmount = p.Amount + p.TDSAmount
+	return ""
+}
+
+func GetPayments(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["id"]
+
+	exists, err := projectExists(projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if !exists {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+
+	payments, err := fetchPayments(db.DB, projectID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, payments)
+}
+
+// fetchPayments returns a project's payments in ledger order
+func fetchPayments(q querier, projectID string) ([]models.Payment, error) {
+	rows, err := q.Query(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE project_id = ?
+		ORDER BY paid_at ASC, created_at ASC
+	`, projectID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	payments := []models.Payment{}
+	for rows.Next() {
+		var p models.Payment
+		if err := p.ScanRows(rows); err != nil {
+			return nil, err
+		}
+		payments = append(payments, p)
+	}
+	return payments, rows.Err()
+}
+
+func GetPayment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+
+	var p models.Payment
+	err := p.Scan(db.DB.QueryRow(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE id = ? AND project_id = ? AND `+inActiveProject+`
+	`, vars["paymentId"], vars["id"]))
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Payment not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func CreatePayment(w http.ResponseWriter, r *http.Request) {
+	projectID := mux.Vars(r)["id"]
+
+	var p models.Payment
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		respondError(w, http.StatusBadRequest, bodyErrorMessage(err))
+		return
+	}
+
+	// A payment may be given by its gross amount instead, leaving the amount
+	// received to be worked out from the TDS
+	if p.GrossAmount != 0 {
+		if p.Amount == 0 {
+			p.Amount = p.GrossAmount - p.TDSAmount
+		} else if p.Amount+p.TDSAmount != p.GrossAmount {
+			respondError(w, http.StatusBadRequest, "grossAmount must equal amount plus tdsAmount")
+			return
+		}
+	}
+	if p.Amount <= 0 {
+		respondError(w, http.StatusBadRequest, "amount must be greater than 0")
+		return
+	}
+	if p.Date == "" {
+		p.Date = time.Now().UTC().Format("2006-01-02")
+	} else if !validateISODate(p.Date) {
+		respondError(w, http.StatusBadRequest, "date must be in ISO format (YYYY-MM-DD or RFC3339)")
+		return
+	}
+	if p.Method != nil && !validPaymentMethods[*p.Method] {
+		respondError(w, http.StatusBadRequest, "Invalid payment method")
+		return
+	}
+
+	currency, err := projectCurrency(db.DB, projectID)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if p.Currency == "" {
+		p.Currency = currency
+	} else if p.Currency != currency {
+		respondError(w, http.StatusBadRequest, "Payment currency must match the project currency ("+currency+")")
+		return
+	}
+	if msg := checkPaymentTDS(&p); msg != "" {
+		respondError(w, http.StatusBadRequest, msg)
+		return
+	}
+
+	p.ID = uuid.New().String()
+	p.ProjectID = projectID
+	p.CreatedAt = time.Now().UTC().Format(time.RFC3339)
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create payment")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO payments (`+paymentColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.ID, p.ProjectID, p.Amount, p.Date, p.Method, p.Reference, p.Note, p.CreatedAt, p.Currency, p.TDSAmount, p.TDSSection)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create payment")
+		return
+	}
+
+	if err := db.RecalculateTotalReceived(tx, projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update project totals")
+		return
+	}
+	if err := db.SnapshotProject(tx, auditActor(r), projectID, p.CreatedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
+
+	field := "amount"
+	newVal := p.Amount.String()
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYMENT_ADDED", &field, nil, &newVal, p.CreatedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+	if p.TDSAmount != 0 {
+		field := "tdsAmount"
+		newVal := p.TDSAmount.String()
+		if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYMENT_ADDED", &field, nil, &newVal, p.CreatedAt); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create payment")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, p)
+}
+
+func UpdatePayment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	paymentID := vars["paymentId"]
+
+	updates, err := decodeUpdates(r)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if len(updates) == 0 {
+		respondError(w, http.StatusBadRequest, "No fields to update")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update payment")
+		return
+	}
+	defer tx.Rollback()
+
+	var old models.Payment
+	err = old.Scan(tx.QueryRow(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE id = ? AND project_id = ? AND `+inActiveProject+`
+	`, paymentID, projectID))
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Payment not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payment")
+		return
+	}
+
+	// Field mapping: JSON field name -> database column name
+	fieldMap := map[string]string{
+		"amount":     "amount",
+		"tdsAmount":  "tds_amount",
+		"tdsSection": "tds_section",
+		"date":       "paid_at",
+		"method":     "method",
+		"reference":  "reference",
+		"note":       "note",
+	}
+
+	setParts := []string{}
+	args := []interface{}{}
+
+	// The amount, TDS and gross amount depend on each other, so they are
+	// applied to a copy and checked together once all fields are read
+	updated := old
+	var grossAmount *models.Money
+	amountsChanged := false
+
+	for jsonField, value := range updates {
+		// Skip identity and bookkeeping fields (not updatable)
+		if jsonField == "id" || jsonField == "projectId" || jsonField == "createdAt" {
+			continue
+		}
+
+		// Payments are always in the project currency; accept it echoed back unchanged
+		if jsonField == "currency" {
+			if str, ok := value.(string); ok && str == old.Currency {
+				continue
+			}
+			respondError(w, http.StatusBadRequest, "Payment currency must match the project currency ("+old.Currency+")")
+			return
+		}
+
+		if jsonField == "grossAmount" {
+			amount, ok := parseMoneyValue(value)
+			if !ok || amount <= 0 {
+				respondError(w, http.StatusBadRequest, "grossAmount must be a number greater than 0 with at most 2 decimal places")
+				return
+			}
+			grossAmount = &amount
+			amountsChanged = true
+			continue
+		}
+
+		dbField, ok := fieldMap[jsonField]
+		if !ok {
+			respondError(w, http.StatusBadRequest, "Unknown field: "+jsonField)
+			return
+		}
+
+		switch jsonField {
+		case "amount":
+			amount, ok := parseMoneyValue(value)
+			if !ok || amount <= 0 {
+				respondError(w, http.StatusBadRequest, "amount must be a number greater than 0 with at most 2 decimal places")
+				return
+			}
+			updated.Amount = amount
+			amountsChanged = true
+			continue
+		case "tdsAmount":
+			amount, ok := parseMoneyValue(value)
+			if !ok {
+				respondError(w, http.StatusBadRequest, "tdsAmount must be a number with at most 2 decimal places")
+				return
+			}
+			updated.TDSAmount = amount
+			amountsChanged = true
+			continue
+		case "tdsSection":
+			updated.TDSSection = nil
+			if value != nil {
+				str, ok := value.(string)
+				if !ok {
+					respondError(w, http.StatusBadRequest, "tdsSection must be a string or null")
+					return
+				}
+				updated.TDSSection = &str
+			}
+			amountsChanged = true
+			continue
+		case "date":
+			if str, ok := value.(string); !ok || str == "" || !validateISODate(str) {
+				respondError(w, http.StatusBadRequest, "date must be in ISO format (YYYY-MM-DD or RFC3339)")
+				return
+			}
+		case "method":
+			if value != nil {
+				if str, ok := value.(string); !ok || !validPaymentMethods[str] {
+					respondError(w, http.StatusBadRequest, "Invalid payment method")
+					return
+				}
+			}
+		}
+
+		setParts = append(setParts, dbField+" = ?")
+		args = append(args, value)
+	}
+
+	if amountsChanged {
+		if grossAmount != nil {
+			if _, ok := updates["amount"]; !ok {
+				updated.Amount = *grossAmount - updated.TDSAmount
+			} else if updated.Amount+updated.TDSAmount != *grossAmount {
+				respondError(w, http.StatusBadRequest, "grossAmount must equal amount plus tdsAmount")
+				return
+			}
+			if updated.Amount <= 0 {
+				respondError(w, http.StatusBadRequest, "grossAmount must be greater than tdsAmount")
+				return
+			}
+		}
+		if msg := checkPaymentTDS(&updated); msg != "" {
+			respondError(w, http.StatusBadRequest, msg)
+			return
+		}
+		setParts = append(setParts, "amount = ?", "tds_amount = ?", "tds_section = ?")
+		args = append(args, updated.Amount, updated.TDSAmount, updated.TDSSection)
+	}
+
+	if len(setParts) == 0 {
+		respondError(w, http.StatusBadRequest, "No valid fields to update")
+		return
+	}
+
+	query := "UPDATE payments SET " + strings.Join(setParts, ", ") + " WHERE id = ? AND project_id = ?"
+	args = append(args, paymentID, projectID)
+
+	if _, err := tx.Exec(query, args...); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update payment")
+		return
+	}
+
+	if err := db.RecalculateTotalReceived(tx, projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update project totals")
+		return
+	}
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if err := db.SnapshotProject(tx, auditActor(r), projectID, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
+
+	var p models.Payment
+	err = p.Scan(tx.QueryRow(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE id = ?
+	`, paymentID))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated payment")
+		return
+	}
+
+	oldValues, newValues := paymentFieldValues(old), paymentFieldValues(p)
+	for _, field := range paymentAuditFields {
+		oldVal, newVal := oldValues[field], newValues[field]
+		if sameAuditValue(oldVal, newVal) {
+			continue
+		}
+		if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYMENT_UPDATED", &field, oldVal, newVal, ts); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update payment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, p)
+}
+
+func DeletePayment(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	projectID := vars["id"]
+	paymentID := vars["paymentId"]
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete payment")
+		return
+	}
+	defer tx.Rollback()
+
+	var old models.Payment
+	err = old.Scan(tx.QueryRow(`
+		SELECT `+paymentColumns+`
+		FROM payments
+		WHERE id = ? AND project_id = ? AND `+inActiveProject+`
+	`, paymentID, projectID))
+
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Payment not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payment")
+		return
+	}
+
+	if _, err := tx.Exec("DELETE FROM payments WHERE id = ?", paymentID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete payment")
+		return
+	}
+
+	if err := db.RecalculateTotalReceived(tx, projectID); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update project totals")
+		return
+	}
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if err := db.SnapshotProject(tx, auditActor(r), projectID, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
+
+	field := "amount"
+	oldVal := old.Amount.String()
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYMENT_DELETED", &field, &oldVal, nil, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+	if old.TDSAmount != 0 {
+		field := "tdsAmount"
+		oldVal := old.TDSAmount.String()
+		if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), projectID, "PAYMENT_DELETED", &field, &oldVal, nil, ts); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+			return
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete payment")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Payment deleted"})
+}
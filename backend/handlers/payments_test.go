@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPaymentLedgerMaintainsTotalReceived(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{"totalReceived": 1000}`)["id"].(string)
+	vars := map[string]string{"id": id}
+
+	// The amount received at creation opens the ledger
+	var payments []map[string]interface{}
+	decode(t, serve(GetPayments, "GET", vars, ""), http.StatusOK, &payments)
+	if len(payments) != 1 || payments[0]["amount"] != 1000.0 || payments[0]["note"] != "Opening balance" {
+		t.Fatalf("payments = %v, want the 1000 opening balance", payments)
+	}
+
+	var second map[string]interface{}
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 2500.5, "date": "2026-02-01", "method": "upi"}`), http.StatusCreated, &second)
+	if got := getProject(t, id)["totalReceived"]; got != 3500.5 {
+		t.Errorf("totalReceived = %v after a payment, want 3500.5", got)
+	}
+
+	paymentVars := map[string]string{"id": id, "paymentId": second["id"].(string)}
+	decode(t, serve(UpdatePayment, "PUT", paymentVars, `{"amount": 500}`), http.StatusOK, nil)
+	if got := getProject(t, id)["totalReceived"]; got != 1500.0 {
+		t.Errorf("totalReceived = %v after an update, want 1500", got)
+	}
+
+	decode(t, serve(DeletePayment, "DELETE", paymentVars, ""), http.StatusOK, nil)
+	if got := getProject(t, id)["totalReceived"]; got != 1000.0 {
+		t.Errorf("totalReceived = %v after a delete, want 1000", got)
+	}
+	decode(t, serve(GetPayment, "GET", paymentVars, ""), http.StatusNotFound, nil)
+}
+
+func TestTotalReceivedCannotBeOverwritten(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{"totalReceived": 1000}`)["id"].(string)
+	vars := map[string]string{"id": id}
+
+	// Echoing the current value back is harmless
+	decode(t, updateProject(testAdmin, vars, `{"name": "Renamed", "totalReceived": 1000}`), http.StatusOK, nil)
+	decode(t, updateProject(testAdmin, vars, `{"totalReceived": 5000}`), http.StatusBadRequest, nil)
+	if got := getProject(t, id)["totalReceived"]; got != 1000.0 {
+		t.Errorf("totalReceived = %v, want 1000", got)
+	}
+}
+
+func TestCreatePaymentValidation(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{}`)["id"].(string)
+
+	tests := []struct {
+		name   string
+		id     string
+		body   string
+		status int
+	}{
+		{"valid", id, `{"amount": 100, "date": "2026-01-15", "method": "cheque"}`, http.StatusCreated},
+		{"dated today by default", id, `{"amount": 100}`, http.StatusCreated},
+		{"zero amount", id, `{"amount": 0}`, http.StatusBadRequest},
+		{"negative amount", id, `{"amount": -5}`, http.StatusBadRequest},
+		{"bad date", id, `{"amount": 100, "date": "15/01/2026"}`, http.StatusBadRequest},
+		{"unknown method", id, `{"amount": 100, "method": "barter"}`, http.StatusBadRequest},
+		{"unknown project", "missing", `{"amount": 100}`, http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(CreatePayment, "POST", map[string]string{"id": tt.id}, tt.body)
+			if w.Code != tt.status {
+				t.Errorf("status %d, want %d: %s", w.Code, tt.status, w.Body)
+			}
+		})
+	}
+}
+
+func TestPaymentAmountsAreExact(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{"totalAmount": 1}`)["id"].(string)
+	vars := map[string]string{"id": id}
+
+	// Summed as float64 rupees, these would not come to 0.3
+	for _, amount := range []string{"0.1", "0.2"} {
+		decode(t, serve(CreatePayment, "POST", vars, `{"amount": `+amount+`}`), http.StatusCreated, nil)
+	}
+	p := getProject(t, id)
+	if p["totalReceived"] != 0.3 {
+		t.Errorf("totalReceived = %v, want 0.3", p["totalReceived"])
+	}
+	if p["status"] != "In Progress" {
+		t.Errorf("status = %v, want In Progress", p["status"])
+	}
+
+	for _, body := range []string{`{"amount": 10.005}`, `{"amount": "10"}`} {
+		if w := serve(CreatePayment, "POST", vars, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+	if w := updateProject(testAdmin, vars, `{"totalAmount": 99.999}`); w.Code != http.StatusBadRequest {
+		t.Errorf("totalAmount finer than a paisa: status %d, want 400", w.Code)
+	}
+}
+
+func TestPaymentsRecordTDS(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{"totalAmount": 100000}`)["id"].(string)
+	vars := map[string]string{"id": id}
+
+	// Given the amount received and the TDS, the payment settles their sum
+	var payment map[string]interface{}
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 45000, "tdsAmount": 5000, "tdsSection": " 194j "}`), http.StatusCreated, &payment)
+	if payment["grossAmount"] != 50000.0 || payment["tdsSection"] != "194J" {
+		t.Errorf("payment = %v, want a 50000 gross under 194J", payment)
+	}
+
+	// Given the gross amount, the amount received is worked out
+	var second map[string]interface{}
+	decode(t, serve(CreatePayment, "POST", vars, `{"grossAmount": 20000, "tdsAmount": 2000, "tdsSection": "194C"}`), http.StatusCreated, &second)
+	if second["amount"] != 18000.0 {
+		t.Errorf("amount = %v, want 18000", second["amount"])
+	}
+
+	project := getProject(t, id)
+	if project["totalReceived"] != 70000.0 || project["totalTds"] != 7000.0 {
+		t.Errorf("totalReceived %v, totalTds %v, want 70000 and 7000", project["totalReceived"], project["totalTds"])
+	}
+
+	// Changing the gross amount keeps the TDS and recomputes the amount
+	paymentVars := map[string]string{"id": id, "paymentId": second["id"].(string)}
+	decode(t, serve(UpdatePayment, "PUT", paymentVars, `{"grossAmount": 30000}`), http.StatusOK, &second)
+	if second["amount"] != 28000.0 || second["tdsAmount"] != 2000.0 {
+		t.Errorf("updated payment = %v", second)
+	}
+	decode(t, serve(UpdatePayment, "PUT", paymentVars, `{"tdsAmount": 0, "tdsSection": null}`), http.StatusOK, nil)
+	project = getProject(t, id)
+	if project["totalReceived"] != 78000.0 || project["totalTds"] != 5000.0 {
+		t.Errorf("totalReceived %v, totalTds %v, want 78000 and 5000", project["totalReceived"], project["totalTds"])
+	}
+
+	for _, body := range []string{
+		`{"amount": 100, "tdsAmount": 10}`,
+		`{"amount": 100, "tdsSection": "194J"}`,
+		`{"amount": 100, "tdsAmount": 10, "tdsSection": "80C"}`,
+		`{"amount": 100, "tdsAmount": -10, "tdsSection": "194J"}`,
+		`{"amount": 100, "grossAmount": 200, "tdsAmount": 10, "tdsSection": "194J"}`,
+	} {
+		if w := serve(CreatePayment, "POST", vars, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+	decode(t, serve(UpdatePayment, "PUT", paymentVars, `{"grossAmount": 10, "tdsAmount": 20, "tdsSection": "194J"}`), http.StatusBadRequest, nil)
+
+	usd := createProject(t, `{"totalAmount": 1000, "currency": "USD"}`)["id"].(string)
+	decode(t, serve(CreatePayment, "POST", map[string]string{"id": usd}, `{"amount": 90, "tdsAmount": 10, "tdsSection": "194J"}`), http.StatusBadRequest, nil)
+}
+
+func TestUpdatePaymentAuditsEveryField(t *testing.T) {
+	openTestDB(t)
+	id := createProject(t, `{"totalAmount": 100000}`)["id"].(string)
+	vars := map[string]string{"id": id}
+
+	var payment map[string]interface{}
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 9000, "tdsAmount": 1000, "tdsSection": "194J", "date": "2026-03-01", "method": "upi"}`), http.StatusCreated, &payment)
+	paymentVars := map[string]string{"id": id, "paymentId": payment["id"].(string)}
+	decode(t, serve(UpdatePayment, "PUT", paymentVars,
+		`{"tdsSection": "194C", "date": "2026-03-02", "method": "bank_transfer", "reference": "UTR-1", "note": "Second tranche", "amount": 9000}`), http.StatusOK, nil)
+
+	var page AuditPage
+	decode(t, serveGET(GetProjectAuditLogs, "/?action=PAYMENT_UPDATED", vars), http.StatusOK, &page)
+	got := map[string]string{}
+	for _, entry := range page.Entries {
+		got[show(entry.FieldName)] = show(entry.OldValue) + " -> " + show(entry.NewValue)
+	}
+	want := map[string]string{
+		"tdsSection": "194J -> 194C",
+		"date":       "2026-03-01 -> 2026-03-02",
+		"method":     "upi -> bank_transfer",
+		"reference":  "<nil> -> UTR-1",
+		"note":       "<nil> -> Second tranche",
+	}
+	if len(got) != len(want) {
+		t.Errorf("audited %v, want %v", got, want)
+	}
+	for field, change := range want {
+		if got[field] != change {
+			t.Errorf("%s audited as %q, want %q", field, got[field], change)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"project-tracker/models"
+)
+
+const (
+	defaultProjectLimit = 50
+	maxProjectLimit     = 500
+)
+
+// projectStatusSQL computes a project's status in SQL, so lists can be
+// filtered by it. It must agree with models.Project.ComputeStatus.
+const projectStatusSQL = `CASE
+	WHEN deliveredAt IS NOT NULL THEN '` + models.StatusDelivered + `'
+	WHEN completedAt IS NOT NULL AND totalReceived >= totalAmount THEN '` + models.StatusReadyToDeliver + `'
+	WHEN completedAt IS NOT NULL THEN '` + models.StatusPaymentPending + `'
+	WHEN totalReceived > 0 THEN '` + models.StatusInProgress + `'
+	ELSE '` + models.StatusNotStarted + `'
+END`
+
+// projectSort is a column projects can be listed by. value extracts the same
+// value from a scanned project, to build the cursor for the next page.
+type projectSort struct {
+	expr  string
+	value func(p *models.Project) interface{}
+}
+
+var projectSorts = map[string]projectSort{
+	"createdAt":   {"createdAt", func(p *models.Project) interface{} { return p.CreatedAt }},
+	"deadline":    {"deadline", func(p *models.Project) interface{} { return p.Deadline }},
+	"name":        {"name COLLATE NOCASE", func(p *models.Project) interface{} { return p.Name }},
+	"totalAmount": {"totalAmount", func(p *models.Project) interface{} { return int64(p.TotalAmount) }},
+	"due":         {"MAX(totalAmount - totalReceived, 0)", func(p *models.Project) interface{} { return int64(p.DueAmount()) }},
+}
+
+// projectCursor marks the last project of a page. It records the sort it was
+// made for, so it cannot be replayed against a different ordering.
+type projectCursor struct {
+	Sort  string      `json:"s"`
+	Order string      `json:"o"`
+	Value interface{} `json:"v"`
+	ID    string      `json:"id"`
+}
+
+func encodeProjectCursor(c projectCursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+func decodeProjectCursor(s string) (projectCursor, bool) {
+	var c projectCursor
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, false
+	}
+	dec := json.NewDecoder(strings.NewReader(string(raw)))
+	dec.UseNumber()
+	if err := dec.Decode(&c); err != nil || c.ID == "" || c.Value == nil {
+		return c, false
+	}
+	// Numeric sort values must go back to SQLite as integers
+	if n, ok := c.Value.(json.Number); ok {
+		v, err := n.Int64()
+		if err != nil {
+			return c, false
+		}
+		c.Value = v
+	}
+	return c, true
+}
+
+// projectListQuery is a parsed GET /api/projects request
+type projectListQuery struct {
+	conditions []string
+	args       []interface{}
+	sortName   string
+	sort       projectSort
+	order      string
+	limit      int // 0 returns every matching project
+	cursor     *projectCursor
+}
+
+// where returns the WHERE clause for the filters, plus the cursor position
+// when withCursor is set
+func (q *projectListQuery) where(withCursor bool) (string, []interface{}) {
+	conditions := append([]string{}, q.conditions...)
+	args := append([]interface{}{}, q.args...)
+
+	if withCursor && q.cursor != nil {
+		op := "<"
+		if q.order == "asc" {
+			op = ">"
+		}
+		conditions = append(conditions, "("+q.sort.expr+" "+op+" ? OR ("+q.sort.expr+" = ? AND id "+op+" ?))")
+		args = append(args, q.cursor.Value, q.cursor.Value, q.cursor.ID)
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return "WHERE " + strings.Join(conditions, " AND "), args
+}
+
+// orderBy sorts by the chosen column, then id, so every row has a fixed place
+func (q *projectListQuery) orderBy() string {
+	dir := " DESC"
+	if q.order == "asc" {
+		dir = " ASC"
+	}
+	return "ORDER BY " + q.sort.expr + dir + ", id" + dir
+}
+
+// parseProjectListQuery reads the filter, sort and pagination parameters of
+// GET /api/projects, writing a 400 and returning ok=false when one is invalid
+func parseProjectListQuery(w http.ResponseWriter, r *http.Request) (*projectListQuery, bool) {
+	params := r.URL.Query()
+	q := &projectListQuery{
+		conditions: []string{"deletedAt IS NULL"},
+		sortName:   "createdAt",
+		order:      "desc",
+	}
+
+	if v := params.Get("status"); v != "" {
+		statuses := strings.Split(v, ",")
+		placeholders := make([]string, len(statuses))
+		for i, status := range statuses {
+			status = strings.TrimSpace(status)
+			switch status {
+			case models.StatusNotStarted, models.StatusInProgress, models.StatusPaymentPending,
+				models.StatusReadyToDeliver, models.StatusDelivered:
+			default:
+				respondError(w, http.StatusBadRequest, "Unknown status: "+status)
+				return nil, false
+			}
+			placeholders[i] = "?"
+			q.args = append(q.args, status)
+		}
+		q.conditions = append(q.conditions, "("+projectStatusSQL+") IN ("+strings.Join(placeholders, ", ")+")")
+	}
+	if v := params.Get("type"); v != "" {
+		q.conditions = append(q.conditions, "type = ?")
+		q.args = append(q.args, v)
+	}
+	if v := params.Get("clientId"); v != "" {
+		q.conditions = append(q.conditions, "clientId = ?")
+		q.args = append(q.args, v)
+	}
+	if v := params.Get("deadlineFrom"); v != "" {
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, "deadlineFrom must be in YYYY-MM-DD format")
+			return nil, false
+		}
+		q.conditions = append(q.conditions, "substr(deadline, 1, 10) >= ?")
+		q.args = append(q.args, v)
+	}
+	if v := params.Get("deadlineTo"); v != "" {
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			respondError(w, http.StatusBadRequest, "deadlineTo must be in YYYY-MM-DD format")
+			return nil, false
+		}
+		q.conditions = append(q.conditions, "substr(deadline, 1, 10) <= ?")
+		q.args = append(q.args, v)
+	}
+	if v := params.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "overdue must be true or false")
+			return nil, false
+		}
+		// Overdue means past the deadline and not yet delivered
+		condition := "(deliveredAt IS NULL AND substr(deadline, 1, 10) < ?)"
+		if !overdue {
+			condition = "NOT " + condition
+		}
+		q.conditions = append(q.conditions, condition)
+		q.args = append(q.args, time.Now().UTC().Format("2006-01-02"))
+	}
+	if v := params.Get("hasDues"); v != "" {
+		hasDues, err := strconv.ParseBool(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "hasDues must be true or false")
+			return nil, false
+		}
+		if hasDues {
+			q.conditions = append(q.conditions, "totalAmount > totalReceived")
+		} else {
+			q.conditions = append(q.conditions, "totalAmount <= totalReceived")
+		}
+	}
+	if v := strings.TrimSpace(params.Get("q")); v != "" {
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(v)
+		q.conditions = append(q.conditions, `name LIKE ? ESCAPE '\'`)
+		q.args = append(q.args, "%"+escaped+"%")
+	}
+
+	if v := params.Get("sort"); v != "" {
+		q.sortName = v
+	}
+	sort, ok := projectSorts[q.sortName]
+	if !ok {
+		respondError(w, http.StatusBadRequest, "sort must be one of createdAt, deadline, name, totalAmount, due")
+		return nil, false
+	}
+	q.sort = sort
+
+	if v := params.Get("order"); v != "" {
+		if v != "asc" && v != "desc" {
+			respondError(w, http.StatusBadRequest, "order must be asc or desc")
+			return nil, false
+		}
+		q.order = v
+	}
+
+	// Without limit or cursor every match is returned, as before pagination
+	if v := params.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return nil, false
+		}
+		q.limit = min(n, maxProjectLimit)
+	}
+	if v := params.Get("cursor"); v != "" {
+		cursor, ok := decodeProjectCursor(v)
+		if !ok {
+			respondError(w, http.StatusBadRequest, "cursor is invalid")
+			return nil, false
+		}
+		if cursor.Sort != q.sortName || cursor.Order != q.order {
+			respondError(w, http.StatusBadRequest, "cursor was made for a different sort order")
+			return nil, false
+		}
+		q.cursor = &cursor
+		if q.limit == 0 {
+			q.limit = defaultProjectLimit
+		}
+	}
+
+	return q, true
+}
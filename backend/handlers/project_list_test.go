@@ -0,0 +1,161 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestListProjectsFiltersAndSorts(t *testing.T) {
+	openTestDB(t)
+
+	var client map[string]interface{}
+	decode(t, serve(CreateClient, "POST", nil, `{"name": "Initech"}`), http.StatusCreated, &client)
+
+	ids := map[string]string{}
+	for _, body := range []string{
+		`{"name": "Alpha", "type": "software", "deadline": "2020-01-01", "totalAmount": 1000}`,
+		`{"name": "beta", "type": "hardware", "deadline": "2099-06-01", "totalAmount": 5000, "totalReceived": 5000}`,
+		`{"name": "Gamma", "type": "mixed", "deadline": "2099-03-01", "totalAmount": 3000, "totalReceived": 1000}`,
+		`{"name": "delta", "type": "software", "deadline": "2099-09-01", "totalAmount": 2500, "clientId": "` + client["id"].(string) + `"}`,
+	} {
+		p := createProject(t, body)
+		ids[p["id"].(string)] = p["name"].(string)
+	}
+
+	tests := []struct {
+		name   string
+		target string
+		want   string
+	}{
+		{"every project by name", "/?sort=name&order=asc", "Alpha beta delta Gamma"},
+		{"by amount, largest first", "/?sort=totalAmount&order=desc", "beta Gamma delta Alpha"},
+		{"by type", "/?type=software&sort=name&order=asc", "Alpha delta"},
+		{"by status", "/?status=Not%20Started&sort=name&order=asc", "Alpha delta"},
+		{"by several statuses", "/?status=In%20Progress,Not%20Started&sort=name&order=asc", "Alpha beta delta Gamma"},
+		{"by client", "/?clientId=" + client["id"].(string), "delta"},
+		{"with dues, largest first", "/?hasDues=true&sort=due&order=desc", "delta Gamma Alpha"},
+		{"fully paid", "/?hasDues=false", "beta"},
+		{"overdue", "/?overdue=true", "Alpha"},
+		{"not overdue", "/?overdue=false&sort=deadline&order=asc", "Gamma beta delta"},
+		{"deadline range", "/?deadlineFrom=2099-01-01&deadlineTo=2099-06-30&sort=deadline&order=asc", "Gamma beta"},
+		{"name search", "/?q=TA&sort=name&order=asc", "beta delta"},
+		{"search wildcards are literal", "/?q=%25", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var projects []map[string]interface{}
+			w := serveGET(GetProjects, tt.target, nil)
+			decode(t, w, http.StatusOK, &projects)
+
+			names := []string{}
+			for _, p := range projects {
+				names = append(names, ids[p["id"].(string)])
+			}
+			if got := strings.Join(names, " "); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if total := w.Header().Get("X-Total-Count"); total != strconv.Itoa(len(names)) {
+				t.Errorf("X-Total-Count %s, want %d", total, len(names))
+			}
+			if next := w.Header().Get("X-Next-Cursor"); next != "" {
+				t.Errorf("unpaged list set X-Next-Cursor %q", next)
+			}
+		})
+	}
+
+	for _, target := range []string{
+		"/?status=Done",
+		"/?sort=owner",
+		"/?order=up",
+		"/?limit=0",
+		"/?limit=ten",
+		"/?cursor=junk",
+		"/?deadlineFrom=01-01-2099",
+		"/?overdue=maybe",
+		"/?hasDues=some",
+	} {
+		if w := serveGET(GetProjects, target, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, w.Code)
+		}
+	}
+}
+
+func TestListProjectsPagesWithCursor(t *testing.T) {
+	openTestDB(t)
+	for _, amount := range []string{"500", "100", "400", "200", "300"} {
+		createProject(t, `{"name": "P`+amount+`", "totalAmount": `+amount+`}`)
+	}
+
+	// Page through by amount, two at a time, with a filter applied throughout
+	names := []string{}
+	target := "/?sort=totalAmount&order=asc&limit=2&hasDues=true"
+	for pages := 0; target != ""; pages++ {
+		if pages > 3 {
+			t.Fatal("paging did not end")
+		}
+		var projects []map[string]interface{}
+		w := serveGET(GetProjects, target, nil)
+		decode(t, w, http.StatusOK, &projects)
+		if total := w.Header().Get("X-Total-Count"); total != "5" {
+			t.Errorf("X-Total-Count %s on page %d, want 5", total, pages+1)
+		}
+		for _, p := range projects {
+			names = append(names, p["name"].(string))
+		}
+
+		target = ""
+		if next := w.Header().Get("X-Next-Cursor"); next != "" {
+			target = "/?sort=totalAmount&order=asc&limit=2&hasDues=true&cursor=" + next
+
+			// A cursor only continues the ordering it was made for
+			if w := serveGET(GetProjects, "/?sort=name&order=asc&cursor="+next, nil); w.Code != http.StatusBadRequest {
+				t.Errorf("cursor reused for another sort: status %d, want 400", w.Code)
+			}
+		}
+	}
+
+	if got := strings.Join(names, " "); got != "P100 P200 P300 P400 P500" {
+		t.Errorf("paged through %q", got)
+	}
+}
+
+func TestProjectCursorRoundTrip(t *testing.T) {
+	tests := []projectCursor{
+		{Sort: "createdAt", Order: "desc", Value: "2026-04-01T10:00:00Z", ID: "p1"},
+		{Sort: "name", Order: "asc", Value: "Acme & Co", ID: "p2"},
+		{Sort: "totalAmount", Order: "desc", Value: int64(9007199254740993), ID: "p3"},
+		{Sort: "due", Order: "asc", Value: int64(0), ID: "p4"},
+	}
+
+	for _, want := range tests {
+		got, ok := decodeProjectCursor(encodeProjectCursor(want))
+		if !ok {
+			t.Errorf("cursor %+v did not decode", want)
+			continue
+		}
+		if got != want {
+			t.Errorf("decoded %+v (%T), want %+v (%T)", got, got.Value, want, want.Value)
+		}
+	}
+}
+
+func TestDecodeProjectCursorRejects(t *testing.T) {
+	encode := func(s string) string { return base64.RawURLEncoding.EncodeToString([]byte(s)) }
+
+	for _, cursor := range []string{
+		"",
+		"not base64!",
+		encode(`not json`),
+		encode(`{"s":"name","o":"asc","v":"Acme"}`),
+		encode(`{"s":"name","o":"asc","id":"p1"}`),
+		encode(`{"s":"totalAmount","o":"asc","v":12.5,"id":"p1"}`),
+	} {
+		if c, ok := decodeProjectCursor(cursor); ok {
+			t.Errorf("decodeProjectCursor(%q) = %+v, want it rejected", cursor, c)
+		}
+	}
+}
@@ -3,10 +3,12 @@ package handlers
 import (
 	"database/sql"
 	"encoding/json"
-	"fmt"
+	"errors"
 	"math/rand"
 	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"project-tracker/db"
@@ -16,6 +18,42 @@ import (
 	"github.com/gorilla/mux"
 )
 
+// projectColumns lists the projects table columns in models.Project scan order
+const projectColumns = models.ProjectColumns
+
+// projectFieldMap maps updatable JSON field names to database column names
+var projectFieldMap = map[string]string{
+	"name":                "name",
+	"clientName":          "clientName",
+	"clientId":            "clientId",
+	"description":         "description",
+	"type":                "type",
+	"startDate":           "startDate",
+	"deadline":            "deadline",
+	"completedAt":         "completedAt",
+	"deliveredAt":         "deliveredAt",
+	"totalAmount":         "totalAmount",
+	"advanceReceived":     "advanceReceived",
+	"currency":            "currency",
+	"taxInclusive":        "taxInclusive",
+	"gstRate":             "gstRate",
+	"completionVideoLink": "completionVideoLink",
+	"completionNotes":     "completionNotes",
+	"repoLink":            "repoLink",
+	"liveLink":            "liveLink",
+	"deliveryNotes":       "deliveryNotes",
+	"techStack":           "techStack",
+	"deliverables":        "deliverables",
+	"internalNotes":       "internalNotes",
+}
+
+// querier is satisfied by both *sql.DB and *sql.Tx, so read helpers can run
+// inside or outside a transaction
+type querier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
 // validateISODate validates that a date string is in ISO 8601 format (YYYY-MM-DD or RFC3339)
 // For internal tool, we trust frontend but validate format to prevent corruption
 func validateISODate(dateStr string) bool {
@@ -29,23 +67,73 @@ func validateISODate(dateStr string) bool {
 	return datePattern.MatchString(dateStr) || datetimePattern.MatchString(dateStr)
 }
 
+// parseMoneyValue converts a money field from a partial update, decoded with
+// UseNumber, into Money; amounts with sub-paise fractions are rejected
+func parseMoneyValue(value interface{}) (models.Money, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return 0, false
+	}
+	amount, err := models.ParseMoney(n.String())
+	return amount, err == nil
+}
+
+// bodyErrorMessage explains a request body decoding failure, passing money
+// validation errors through instead of the generic message
+func bodyErrorMessage(err error) string {
+	if errors.Is(err, models.ErrInvalidMoney) {
+		return err.Error()
+	}
+	return "Invalid request body"
+}
+
+// decodeUpdates decodes a partial update body into a map, keeping numbers as
+// json.Number so money fields can be parsed without float rounding
+func decodeUpdates(r *http.Request) (map[string]interface{}, error) {
+	var updates map[string]interface{}
+	dec := json.NewDecoder(r.Body)
+	dec.UseNumber()
+	err := dec.Decode(&updates)
+	return updates, err
+}
+
+// GetProjects lists projects matching the filters in the query string. The
+// body is always an array; the total number of matches is sent in the
+// X-Total-Count header, and when a limit is given and more projects remain,
+// X-Next-Cursor holds the cursor for the next page.
 func GetProjects(w http.ResponseWriter, r *http.Request) {
-	rows, err := db.DB.Query(`
-		SELECT id, name, clientName, description, type, createdAt, startDate, deadline,
-		       completedAt, deliveredAt, totalAmount, advanceReceived, totalReceived,
-		       partnerShareGiven, partnerShareDate, harshk_share_given, harshk_share_date,
-		       nikku_share_given, nikku_share_date, completionVideoLink, completionNotes,
-		       repoLink, liveLink, deliveryNotes, techStack, deliverables, internalNotes
+	q, ok := parseProjectListQuery(w, r)
+	if !ok {
+		return
+	}
+
+	where, args := q.where(false)
+	var total int
+	if err := db.DB.QueryRow(`SELECT COUNT(*) FROM projects `+where, args...).Scan(&total); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to count projects")
+		return
+	}
+
+	where, args = q.where(true)
+	query := `
+		SELECT ` + projectColumns + `
 		FROM projects
-		ORDER BY createdAt DESC
-	`)
+		` + where + `
+		` + q.orderBy()
+	if q.limit > 0 {
+		// Fetch one extra row to learn whether another page follows
+		query += ` LIMIT ?`
+		args = append(args, q.limit+1)
+	}
+
+	rows, err := db.DB.Query(query, args...)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to fetch projects")
 		return
 	}
 	defer rows.Close()
 
-	var projects []models.Project
+	projects := []models.Project{}
 	for rows.Next() {
 		var p models.Project
 		if err := p.ScanRows(rows); err != nil {
@@ -55,6 +143,23 @@ func GetProjects(w http.ResponseWriter, r *http.Request) {
 		projects = append(projects, p)
 	}
 
+	if q.limit > 0 && len(projects) > q.limit {
+		projects = projects[:q.limit]
+		last := &projects[len(projects)-1]
+		w.Header().Set("X-Next-Cursor", encodeProjectCursor(projectCursor{
+			Sort:  q.sortName,
+			Order: q.order,
+			Value: q.sort.value(last),
+			ID:    last.ID,
+		}))
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+
+	// Redact after the cursor is taken, which may depend on any field
+	for i := range projects {
+		presentProject(r, &projects[i])
+	}
+
 	respondJSON(w, http.StatusOK, projects)
 }
 
@@ -64,13 +169,9 @@ func GetProject(w http.ResponseWriter, r *http.Request) {
 
 	var p models.Project
 	err := p.Scan(db.DB.QueryRow(`
-		SELECT id, name, clientName, description, type, createdAt, startDate, deadline,
-		       completedAt, deliveredAt, totalAmount, advanceReceived, totalReceived,
-		       partnerShareGiven, partnerShareDate, harshk_share_given, harshk_share_date,
-		       nikku_share_given, nikku_share_date, completionVideoLink, completionNotes,
-		       repoLink, liveLink, deliveryNotes, techStack, deliverables, internalNotes
+		SELECT `+projectColumns+`
 		FROM projects
-		WHERE id = ?
+		WHERE id = ? AND deletedAt IS NULL
 	`, id))
 
 	if err == sql.ErrNoRows {
@@ -82,13 +183,20 @@ func GetProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	setProjectETag(w, &p)
+	if etagMatches(r.Header.Get("If-None-Match"), p.Version) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	presentProject(r, &p)
 	respondJSON(w, http.StatusOK, p)
 }
 
 func CreateProject(w http.ResponseWriter, r *http.Request) {
-	var p models.Project
+	p := models.Project{GSTRate: models.DefaultGSTRate}
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+		respondError(w, http.StatusBadRequest, bodyErrorMessage(err))
 		return
 	}
 
@@ -103,6 +211,32 @@ func CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// A linked client owns the name shown on the project
+	if p.ClientID != nil {
+		name, err := clientName(db.DB, *p.ClientID)
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusBadRequest, "clientId does not match a client")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch client")
+			return
+		}
+		p.ClientName = &name
+	}
+
+	if p.Currency == "" {
+		p.Currency = BaseCurrency
+	}
+	if !models.ValidCurrency(p.Currency) {
+		respondError(w, http.StatusBadRequest, "currency must be a supported ISO 4217 code")
+		return
+	}
+	if !models.ValidGSTRate(p.GSTRate) {
+		respondError(w, http.StatusBadRequest, "gstRate must be one of the GST rates: 0, 0.25, 3, 5, 12, 18, 28 or 40")
+		return
+	}
+
 	// Validate date formats (ISO 8601: YYYY-MM-DD or RFC3339 datetime)
 	// All dates must be ISO strings (YYYY-MM-DD or ISO datetime)
 	if !validateISODate(p.Deadline) {
@@ -121,18 +255,22 @@ func CreateProject(w http.ResponseWriter, r *http.Request) {
 		respondError(w, http.StatusBadRequest, "DeliveredAt must be in ISO format (YYYY-MM-DD or RFC3339)")
 		return
 	}
-	if p.PartnerShareDate != nil && !validateISODate(*p.PartnerShareDate) {
-		respondError(w, http.StatusBadRequest, "PartnerShareDate must be in ISO format (YYYY-MM-DD or RFC3339)")
-		return
-	}
-	if p.HarshkShareDate != nil && !validateISODate(*p.HarshkShareDate) {
-		respondError(w, http.StatusBadRequest, "HarshkShareDate must be in ISO format (YYYY-MM-DD or RFC3339)")
-		return
-	}
-	if p.NikkuShareDate != nil && !validateISODate(*p.NikkuShareDate) {
-		respondError(w, http.StatusBadRequest, "NikkuShareDate must be in ISO format (YYYY-MM-DD or RFC3339)")
+
+	if err := models.ValidateTransition(&models.Project{}, &p); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
 		return
 	}
+	p.Status = p.ComputeStatus()
+
+	// Links can only be released through ReleaseLinks
+	p.LinksReleasedAt = nil
+	p.LinksReleasedBy = nil
+	p.Version = 1
+	p.DeletedAt = nil
+
+	// An opening balance is recorded as received in full; TDS is only ever
+	// recorded on payments
+	p.TotalTDS = 0
 
 	// Set createdAt if not provided
 	if p.CreatedAt == "" {
@@ -144,21 +282,23 @@ func CreateProject(w http.ResponseWriter, r *http.Request) {
 		p.ID = generateID()
 	}
 
-	_, err := db.DB.Exec(`
-		INSERT INTO projects (
-			id, name, clientName, description, type, createdAt, startDate, deadline,
-			completedAt, deliveredAt, totalAmount, advanceReceived, totalReceived,
-			partnerShareGiven, partnerShareDate, harshk_share_given, harshk_share_date,
-			nikku_share_given, nikku_share_date, completionVideoLink, completionNotes,
-			repoLink, liveLink, deliveryNotes, techStack, deliverables, internalNotes
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create project")
+		return
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO projects (`+projectColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`,
 		p.ID, p.Name, p.ClientName, p.Description, p.Type, p.CreatedAt, p.StartDate,
 		p.Deadline, p.CompletedAt, p.DeliveredAt, p.TotalAmount, p.AdvanceReceived,
-		p.TotalReceived, p.PartnerShareGiven, p.PartnerShareDate, p.HarshkShareGiven,
-		p.HarshkShareDate, p.NikkuShareGiven, p.NikkuShareDate, p.CompletionVideoLink,
-		p.CompletionNotes, p.RepoLink, p.LiveLink, p.DeliveryNotes, p.TechStack,
-		p.Deliverables, p.InternalNotes,
+		p.TotalReceived, p.CompletionVideoLink, p.CompletionNotes, p.RepoLink,
+		p.LiveLink, p.DeliveryNotes, p.TechStack, p.Deliverables, p.InternalNotes,
+		p.LinksReleasedAt, p.LinksReleasedBy, p.Currency, p.ClientID, p.Version, p.DeletedAt,
+		p.TaxInclusive, p.GSTRate, p.TotalTDS,
 	)
 
 	if err != nil {
@@ -166,23 +306,37 @@ func CreateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Record any amount already received as the first entry in the payment ledger
+	if p.TotalReceived > 0 {
+		note := "Opening balance"
+		_, err = tx.Exec(`
+			INSERT INTO payments (id, project_id, amount, currency, paid_at, note, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, uuid.New().String(), p.ID, p.TotalReceived, p.Currency, time.Now().UTC().Format("2006-01-02"), note, p.CreatedAt)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to record opening payment")
+			return
+		}
+	}
+
 	// Audit Log
-	auditID := uuid.New().String()
-	// createdAt is already calculated in p.CreatedAt or set above, but prompt asked for:
-	// createdAt := time.Now().UTC().Format(time.RFC3339)
-	// We can reuse p.CreatedAt if it matches, or just follow the prompt strictly.
-	// The prompt said: createdAt := time.Now().UTC().Format(time.RFC3339)
-	// I will generate a fresh timestamp for the audit log as requested, although p.CreatedAt is likely same.
 	auditCreatedAt := time.Now().UTC().Format(time.RFC3339)
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), p.ID, "PROJECT_CREATED", nil, nil, nil, auditCreatedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+	if err := db.InsertProjectVersion(tx, auditActor(r), &p, auditCreatedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
 
-	_ = db.InsertAuditLog(
-		auditID,
-		p.ID,
-		"PROJECT_CREATED",
-		nil, nil, nil,
-		auditCreatedAt,
-	)
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create project")
+		return
+	}
 
+	presentProject(r, &p)
+	setProjectETag(w, &p)
 	respondJSON(w, http.StatusCreated, p)
 }
 
@@ -190,9 +344,17 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
+	// Edits must name the version they were based on, so one tab cannot
+	// silently overwrite another's changes
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		respondError(w, http.StatusPreconditionRequired, "If-Match header with the project's ETag is required")
+		return
+	}
+
 	// Decode partial update as map to handle only provided fields
-	var updates map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
+	updates, err := decodeUpdates(r)
+	if err != nil {
 		respondError(w, http.StatusBadRequest, "Invalid request body")
 		return
 	}
@@ -202,17 +364,22 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Run the whole update in a transaction so the resulting state can be
+	// checked against the status rules, and audited, before it becomes visible
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update project")
+		return
+	}
+	defer tx.Rollback()
+
 	// Check if project exists
 	// Step 1: Fetch old project state
 	var oldProject models.Project
-	err := oldProject.Scan(db.DB.QueryRow(`
-		SELECT id, name, clientName, description, type, createdAt, startDate, deadline,
-		       completedAt, deliveredAt, totalAmount, advanceReceived, totalReceived,
-		       partnerShareGiven, partnerShareDate, harshk_share_given, harshk_share_date,
-		       nikku_share_given, nikku_share_date, completionVideoLink, completionNotes,
-		       repoLink, liveLink, deliveryNotes, techStack, deliverables, internalNotes
+	err = oldProject.Scan(tx.QueryRow(`
+		SELECT `+projectColumns+`
 		FROM projects
-		WHERE id = ?
+		WHERE id = ? AND deletedAt IS NULL
 	`, id))
 
 	if err == sql.ErrNoRows {
@@ -224,54 +391,108 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The immediate transaction holds the write lock, so the version cannot
+	// move between this check and the update
+	if !etagMatches(ifMatch, oldProject.Version) {
+		presentProject(r, &oldProject)
+		setProjectETag(w, &oldProject)
+		respondJSON(w, http.StatusPreconditionFailed, map[string]interface{}{
+			"error":   "Project has been changed since it was loaded",
+			"project": oldProject,
+		})
+		return
+	}
+
+	// Resolve the client the project will be linked to, since its name takes
+	// precedence over any clientName in the same request
+	linkedClientName := oldProject.ClientName
+	if oldProject.ClientID == nil {
+		linkedClientName = nil
+	}
+	if value, ok := updates["clientId"]; ok {
+		linkedClientName = nil
+		if value != nil {
+			clientID, ok := value.(string)
+			if !ok {
+				respondError(w, http.StatusBadRequest, "clientId must be a string or null")
+				return
+			}
+			name, err := clientName(tx, clientID)
+			if err == sql.ErrNoRows {
+				respondError(w, http.StatusBadRequest, "clientId does not match a client")
+				return
+			}
+			if err != nil {
+				respondError(w, http.StatusInternalServerError, "Failed to fetch client")
+				return
+			}
+			linkedClientName = &name
+		}
+	}
+
+	role := CurrentUser(r).Role
+	oldValues := projectFieldValues(oldProject)
+
 	// Build dynamic UPDATE query with only provided fields
 	setParts := []string{}
 	args := []interface{}{}
 
-	// Field mapping: JSON field name -> database column name
-	fieldMap := map[string]string{
-		"name":                "name",
-		"clientName":          "clientName",
-		"description":         "description",
-		"type":                "type",
-		"startDate":           "startDate",
-		"deadline":            "deadline",
-		"completedAt":         "completedAt",
-		"deliveredAt":         "deliveredAt",
-		"totalAmount":         "totalAmount",
-		"advanceReceived":     "advanceReceived",
-		"totalReceived":       "totalReceived",
-		"partnerShareGiven":   "partnerShareGiven",
-		"partnerShareDate":    "partnerShareDate",
-		"harshkShareGiven":    "harshk_share_given",
-		"harshkShareDate":     "harshk_share_date",
-		"nikkuShareGiven":     "nikku_share_given",
-		"nikkuShareDate":      "nikku_share_date",
-		"completionVideoLink": "completionVideoLink",
-		"completionNotes":     "completionNotes",
-		"repoLink":            "repoLink",
-		"liveLink":            "liveLink",
-		"deliveryNotes":       "deliveryNotes",
-		"techStack":           "techStack",
-		"deliverables":        "deliverables",
-		"internalNotes":       "internalNotes",
-	}
-
 	// Validate and build SET clauses for provided fields
 	for jsonField, value := range updates {
-		// Skip id and createdAt (not updatable)
-		if jsonField == "id" || jsonField == "createdAt" {
+		// Skip id, createdAt, computed fields and escrow release fields (not updatable)
+		switch jsonField {
+		case "id", "createdAt", "status", "linksRedacted", "linksReleasedAt", "linksReleasedBy", "version", "deletedAt":
 			continue
 		}
 
-		dbField, ok := fieldMap[jsonField]
+		// totalReceived and totalTds are maintained from the payment ledger;
+		// tolerate clients that echo the current value back, but reject
+		// attempts to overwrite them
+		if jsonField == "totalReceived" || jsonField == "totalTds" {
+			current := oldProject.TotalReceived
+			if jsonField == "totalTds" {
+				current = oldProject.TotalTDS
+			}
+			if amount, ok := parseMoneyValue(value); ok && amount == current {
+				continue
+			}
+			respondError(w, http.StatusBadRequest, jsonField+" is derived from payments; use /api/projects/"+id+"/payments")
+			return
+		}
+
+		dbField, ok := projectFieldMap[jsonField]
 		if !ok {
 			respondError(w, http.StatusBadRequest, "Unknown field: "+jsonField)
 			return
 		}
 
+		// Roles may only change the fields they are allowed to edit, though an
+		// unchanged value sent back with the rest of the project is ignored
+		if !models.CanEditProjectField(role, jsonField) {
+			if sameFieldValue(oldValues, jsonField, value) {
+				continue
+			}
+			respondError(w, http.StatusForbidden, "Your role cannot change "+jsonField)
+			return
+		}
+
 		// Validate field-specific rules
 		switch jsonField {
+		case "clientId":
+			if linkedClientName != nil {
+				setParts = append(setParts, "clientName = ?")
+				args = append(args, *linkedClientName)
+			}
+		case "clientName":
+			// Tolerate clients echoing the linked name back, but the name of a
+			// linked client can only be changed through /api/clients
+			if linkedClientName != nil {
+				if str, ok := value.(string); ok && str == *linkedClientName {
+					continue
+				}
+				respondError(w, http.StatusBadRequest, "clientName follows the linked client; update the client or change clientId")
+				return
+			}
 		case "name":
 			if str, ok := value.(string); !ok || str == "" {
 				respondError(w, http.StatusBadRequest, "name must be a non-empty string")
@@ -293,22 +514,49 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 				respondError(w, http.StatusBadRequest, "deadline must be in ISO format (YYYY-MM-DD or RFC3339)")
 				return
 			}
-		case "totalAmount":
-			var amount float64
-			switch v := value.(type) {
-			case float64:
-				amount = v
-			case int:
-				amount = float64(v)
-			default:
-				respondError(w, http.StatusBadRequest, "totalAmount must be a number")
+		case "totalAmount", "advanceReceived":
+			amount, ok := parseMoneyValue(value)
+			if !ok {
+				respondError(w, http.StatusBadRequest, jsonField+" must be a number with at most 2 decimal places")
 				return
 			}
-			if amount <= 0 {
+			if jsonField == "totalAmount" && amount <= 0 {
 				respondError(w, http.StatusBadRequest, "totalAmount must be greater than 0")
 				return
 			}
-		case "startDate", "completedAt", "deliveredAt", "partnerShareDate", "harshkShareDate", "nikkuShareDate":
+			value = amount
+		case "currency":
+			str, ok := value.(string)
+			if !ok || !models.ValidCurrency(str) {
+				respondError(w, http.StatusBadRequest, "currency must be a supported ISO 4217 code")
+				return
+			}
+			// Payments are recorded in the project currency, so it is fixed
+			// once the ledger has entries
+			if str != oldProject.Currency {
+				var hasPayments bool
+				if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM payments WHERE project_id = ?)`, id).Scan(&hasPayments); err != nil {
+					respondError(w, http.StatusInternalServerError, "Failed to fetch payments")
+					return
+				}
+				if hasPayments {
+					respondError(w, http.StatusUnprocessableEntity, "Cannot change currency after payments have been recorded")
+					return
+				}
+			}
+		case "taxInclusive":
+			if _, ok := value.(bool); !ok {
+				respondError(w, http.StatusBadRequest, "taxInclusive must be a boolean")
+				return
+			}
+		case "gstRate":
+			amount, ok := parseMoneyValue(value)
+			if !ok || !models.ValidGSTRate(models.Percent(amount)) {
+				respondError(w, http.StatusBadRequest, "gstRate must be one of the GST rates: 0, 0.25, 3, 5, 12, 18, 28 or 40")
+				return
+			}
+			value = models.Percent(amount)
+		case "startDate", "completedAt", "deliveredAt":
 			if value != nil {
 				if str, ok := value.(string); ok && str != "" {
 					if !validateISODate(str) {
@@ -339,7 +587,7 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 	query += " WHERE id = ?"
 	args = append(args, id)
 
-	result, err := db.DB.Exec(query, args...)
+	result, err := tx.Exec(query, args...)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to update project")
 		return
@@ -351,14 +599,10 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Fetch and return updated project
+	// Fetch updated project
 	var p models.Project
-	err = p.Scan(db.DB.QueryRow(`
-		SELECT id, name, clientName, description, type, createdAt, startDate, deadline,
-		       completedAt, deliveredAt, totalAmount, advanceReceived, totalReceived,
-		       partnerShareGiven, partnerShareDate, harshk_share_given, harshk_share_date,
-		       nikku_share_given, nikku_share_date, completionVideoLink, completionNotes,
-		       repoLink, liveLink, deliveryNotes, techStack, deliverables, internalNotes
+	err = p.Scan(tx.QueryRow(`
+		SELECT `+projectColumns+`
 		FROM projects
 		WHERE id = ?
 	`, id))
@@ -368,45 +612,135 @@ func UpdateProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Audit Log
-	// Step 2 & 3: Compare fields and log changes safely
-	go func(old, new models.Project, updates map[string]interface{}) {
-		logChange := func(field, oldVal, newVal string) {
-			auditID := uuid.New().String()
-			ts := time.Now().UTC().Format(time.RFC3339)
-			// Ignore errors as per safety rules
-			_ = db.InsertAuditLog(auditID, new.ID, "PROJECT_UPDATED", &field, &oldVal, &newVal, ts)
-		}
+	if err := models.ValidateTransition(&oldProject, &p); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
 
-		// Track: name
-		if _, ok := updates["name"]; ok && old.Name != new.Name {
-			logChange("name", old.Name, new.Name)
+	// Audit Log: record every changed field in the same transaction
+	ts := time.Now().UTC().Format(time.RFC3339)
+	for _, change := range diffProjectFields(oldProject, p) {
+		if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), p.ID, "PROJECT_UPDATED", &change.Field, change.OldValue, change.NewValue, ts); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+			return
 		}
+	}
+	if err := db.InsertProjectVersion(tx, auditActor(r), &p, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
 
-		// Track: deadline
-		if _, ok := updates["deadline"]; ok && old.Deadline != new.Deadline {
-			logChange("deadline", old.Deadline, new.Deadline)
-		}
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update project")
+		return
+	}
 
-		// Track: totalAmount
-		if _, ok := updates["totalAmount"]; ok && old.TotalAmount != new.TotalAmount {
-			logChange("totalAmount", fmt.Sprintf("%g", old.TotalAmount), fmt.Sprintf("%g", new.TotalAmount))
-		}
+	presentProject(r, &p)
+	setProjectETag(w, &p)
+	respondJSON(w, http.StatusOK, p)
+}
+
+// ReleaseLinks records a manual escrow release so the delivery links are
+// returned even though payment is still due.
+func ReleaseLinks(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	var req struct {
+		ReleasedBy string `json:"releasedBy"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.ReleasedBy = strings.TrimSpace(req.ReleasedBy)
+	if req.ReleasedBy == "" {
+		respondError(w, http.StatusBadRequest, "releasedBy is required")
+		return
+	}
+
+	releasedAt := time.Now().UTC().Format(time.RFC3339)
 
-		// Track: totalReceived
-		if _, ok := updates["totalReceived"]; ok && old.TotalReceived != new.TotalReceived {
-			logChange("totalReceived", fmt.Sprintf("%g", old.TotalReceived), fmt.Sprintf("%g", new.TotalReceived))
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to release links")
+		return
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE projects
+		SET linksReleasedAt = ?, linksReleasedBy = ?
+		WHERE id = ? AND linksReleasedAt IS NULL AND deletedAt IS NULL
+	`, releasedAt, req.ReleasedBy, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to release links")
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		exists, err := projectExists(id)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+			return
+		}
+		if !exists {
+			respondError(w, http.StatusNotFound, "Project not found")
+			return
 		}
-	}(oldProject, p, updates)
+		respondError(w, http.StatusConflict, "Links have already been released")
+		return
+	}
+
+	field := "linksReleasedBy"
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), id, "LINKS_RELEASED", &field, nil, &req.ReleasedBy, releasedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
 
+	var p models.Project
+	err = p.Scan(tx.QueryRow(`
+		SELECT `+projectColumns+`
+		FROM projects
+		WHERE id = ?
+	`, id))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch updated project")
+		return
+	}
+
+	if err := db.InsertProjectVersion(tx, auditActor(r), &p, releasedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to release links")
+		return
+	}
+
+	// Released links are returned, but role-restricted fields are still hidden
+	p.RedactForRole(CurrentUser(r).Role)
+	setProjectETag(w, &p)
 	respondJSON(w, http.StatusOK, p)
 }
 
+// DeleteProject moves a project to the trash. It keeps its ledger and can be
+// restored until the purge job removes it for good.
 func DeleteProject(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id := vars["id"]
 
-	result, err := db.DB.Exec("DELETE FROM projects WHERE id = ?", id)
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete project")
+		return
+	}
+	defer tx.Rollback()
+
+	deletedAt := time.Now().UTC().Format(time.RFC3339)
+	result, err := tx.Exec(`UPDATE projects SET deletedAt = ? WHERE id = ? AND deletedAt IS NULL`, deletedAt, id)
 	if err != nil {
 		respondError(w, http.StatusInternalServerError, "Failed to delete project")
 		return
@@ -418,7 +752,22 @@ func DeleteProject(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	respondJSON(w, http.StatusOK, map[string]string{"message": "Project deleted"})
+	field := "deletedAt"
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), id, "PROJECT_TRASHED", &field, nil, &deletedAt, deletedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+	if err := db.SnapshotProject(tx, auditActor(r), id, deletedAt); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete project")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "Project moved to trash"})
 }
 
 func respondJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestProjectStatusTransitions(t *testing.T) {
+	openTestDB(t)
+	p := createProject(t, `{"totalAmount": 1000}`)
+	id := p["id"].(string)
+	vars := map[string]string{"id": id}
+	if p["status"] != "Not Started" {
+		t.Errorf("new project status = %v, want Not Started", p["status"])
+	}
+
+	steps := []struct {
+		name   string
+		body   string
+		status int
+		want   string
+	}{
+		{"complete without details", `{"completedAt": "2026-02-01"}`, http.StatusUnprocessableEntity, ""},
+		{"add details", `{"clientName": "Acme", "techStack": "[\"Go\"]", "deliverables": "[\"API\"]"}`, http.StatusOK, "Not Started"},
+		{"complete", `{"completedAt": "2026-02-01"}`, http.StatusOK, "Completed (Payment Pending)"},
+		{"deliver with dues", `{"deliveredAt": "2026-02-02", "repoLink": "r", "liveLink": "l", "completionVideoLink": "v"}`, http.StatusUnprocessableEntity, ""},
+		{"status is not writable", `{"name": "Renamed", "status": "Delivered"}`, http.StatusOK, "Completed (Payment Pending)"},
+	}
+	for _, step := range steps {
+		var got map[string]interface{}
+		w := updateProject(testAdmin, vars, step.body)
+		if w.Code != step.status {
+			t.Fatalf("%s: status %d, want %d: %s", step.name, w.Code, step.status, w.Body)
+		}
+		if step.status != http.StatusOK {
+			continue
+		}
+		decode(t, w, http.StatusOK, &got)
+		if got["status"] != step.want {
+			t.Errorf("%s: project status %v, want %s", step.name, got["status"], step.want)
+		}
+	}
+
+	// A rejected update leaves the project as it was
+	if got := getProject(t, id); got["deliveredAt"] != nil || got["repoLink"] != nil {
+		t.Errorf("rejected delivery was saved: %v", got)
+	}
+
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 1000}`), http.StatusCreated, nil)
+	if got := getProject(t, id)["status"]; got != "Ready to Deliver" {
+		t.Errorf("status once paid = %v, want Ready to Deliver", got)
+	}
+
+	var delivered map[string]interface{}
+	decode(t, updateProject(testAdmin, vars, `{"deliveredAt": "2026-02-02", "repoLink": "r", "liveLink": "l", "completionVideoLink": "v"}`), http.StatusOK, &delivered)
+	if delivered["status"] != "Delivered" {
+		t.Errorf("status once delivered = %v, want Delivered", delivered["status"])
+	}
+}
+
+func TestCreateProjectEnforcesTransitions(t *testing.T) {
+	openTestDB(t)
+	w := serve(CreateProject, "POST", nil, `{"name": "X", "type": "software", "deadline": "2026-12-31", "totalAmount": 1000, "completedAt": "2026-01-01"}`)
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Errorf("creating a completed project without details: status %d, want 422", w.Code)
+	}
+}
+
+func TestDeliveryLinksEscrow(t *testing.T) {
+	openTestDB(t)
+	links := `"repoLink": "https://example.com/repo", "liveLink": "https://example.com", "completionVideoLink": "https://example.com/video"`
+	unpaid := createProject(t, `{"totalAmount": 1000, "totalReceived": 400, `+links+`}`)
+	paid := createProject(t, `{"totalAmount": 1000, "totalReceived": 1000, `+links+`}`)
+
+	if unpaid["repoLink"] != nil || unpaid["linksRedacted"] != true {
+		t.Errorf("created unpaid project shows its links: %v", unpaid)
+	}
+	got := getProject(t, unpaid["id"].(string))
+	if got["repoLink"] != nil || got["liveLink"] != nil || got["completionVideoLink"] != nil || got["linksRedacted"] != true {
+		t.Errorf("unpaid project shows its links: %v", got)
+	}
+	if got := getProject(t, paid["id"].(string)); got["repoLink"] != "https://example.com/repo" || got["linksRedacted"] != false {
+		t.Errorf("paid project hides its links: %v", got)
+	}
+
+	var list []map[string]interface{}
+	decode(t, serve(GetProjects, "GET", nil, ""), http.StatusOK, &list)
+	for _, p := range list {
+		if p["id"] == unpaid["id"] && p["repoLink"] != nil {
+			t.Errorf("project list shows unpaid links: %v", p)
+		}
+	}
+
+	// The release cannot be forged through a project update
+	vars := map[string]string{"id": unpaid["id"].(string)}
+	decode(t, updateProject(testAdmin, vars, `{"name": "Renamed", "linksReleasedAt": "2026-01-01T00:00:00Z", "linksReleasedBy": "me"}`), http.StatusOK, nil)
+	if got := getProject(t, unpaid["id"].(string)); got["repoLink"] != nil {
+		t.Errorf("update released the links: %v", got)
+	}
+
+	decode(t, serve(ReleaseLinks, "POST", vars, `{}`), http.StatusBadRequest, nil)
+	var released map[string]interface{}
+	decode(t, serve(ReleaseLinks, "POST", vars, `{"releasedBy": "Priya"}`), http.StatusOK, &released)
+	if released["repoLink"] != "https://example.com/repo" || released["linksReleasedBy"] != "Priya" || released["linksRedacted"] != false {
+		t.Errorf("released project = %v, want its links shown", released)
+	}
+	decode(t, serve(ReleaseLinks, "POST", vars, `{"releasedBy": "Priya"}`), http.StatusConflict, nil)
+	decode(t, serve(ReleaseLinks, "POST", map[string]string{"id": "missing"}, `{"releasedBy": "Priya"}`), http.StatusNotFound, nil)
+}
+
+func TestProjectETags(t *testing.T) {
+	openTestDB(t)
+	w := serve(CreateProject, "POST", nil, `{"name": "Site", "type": "software", "deadline": "2026-12-31", "totalAmount": 1000}`)
+	var created map[string]interface{}
+	decode(t, w, http.StatusCreated, &created)
+	if etag := w.Header().Get("ETag"); etag != `"1"` || created["version"] != 1.0 {
+		t.Fatalf("new project ETag %s, version %v, want \"1\"", etag, created["version"])
+	}
+	vars := map[string]string{"id": created["id"].(string)}
+
+	put := func(ifMatch, body string) *httptest.ResponseRecorder {
+		r := newRequest(testAdmin, "PUT", "/", vars, body)
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		UpdateProject(w, r)
+		return w
+	}
+
+	if w := put("", `{"name": "Unconditional"}`); w.Code != http.StatusPreconditionRequired {
+		t.Errorf("without If-Match: status %d, want 428", w.Code)
+	}
+
+	w = put(`"1"`, `{"name": "First edit", "version": 7}`)
+	if w.Code != http.StatusOK || w.Header().Get("ETag") != `"2"` {
+		t.Fatalf("matching If-Match: status %d, ETag %s: %s", w.Code, w.Header().Get("ETag"), w.Body)
+	}
+
+	// An edit based on the old version is refused with the current project,
+	// so the client can redo it on top
+	for _, stale := range []string{`"1"`, `W/"2"`, `"1", "3"`} {
+		w := put(stale, `{"name": "Lost edit"}`)
+		var body struct {
+			Error   string                 `json:"error"`
+			Project map[string]interface{} `json:"project"`
+		}
+		decode(t, w, http.StatusPreconditionFailed, &body)
+		if body.Project["name"] != "First edit" || w.Header().Get("ETag") != `"2"` {
+			t.Errorf("If-Match %s: current project %v, ETag %s", stale, body.Project, w.Header().Get("ETag"))
+		}
+	}
+	if w := put(`"9", "2"`, `{"name": "Second edit"}`); w.Code != http.StatusOK {
+		t.Errorf("If-Match listing the current ETag: status %d: %s", w.Code, w.Body)
+	}
+
+	// Changes by any route move the version on
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 100}`), http.StatusCreated, nil)
+	if p := getProject(t, vars["id"]); p["version"] != 4.0 || p["name"] != "Second edit" {
+		t.Errorf("after a payment: version %v, name %v, want version 4", p["version"], p["name"])
+	}
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		r := newRequest(testAdmin, "GET", "/", vars, "")
+		r.Header.Set("If-None-Match", ifNoneMatch)
+		w := httptest.NewRecorder()
+		GetProject(w, r)
+		return w
+	}
+	if w := get(`"4"`); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("If-None-Match current: status %d, %d bytes", w.Code, w.Body.Len())
+	}
+	if w := get(`"3"`); w.Code != http.StatusOK || w.Header().Get("ETag") != `"4"` {
+		t.Errorf("If-None-Match stale: status %d, ETag %s", w.Code, w.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,49 @@
+package handlers
+
+import (
+	"net/http"
+	"slices"
+
+	"project-tracker/models"
+)
+
+// Role groups used with RequireRole when registering routes
+var (
+	// Admins may manage users
+	Admins = []string{models.RoleAdmin}
+	// Staff may change projects, ledgers, clients and partners, and read the
+	// audit trail
+	Staff = []string{models.RoleAdmin, models.RoleManager}
+	// Editors may update projects; partners only the fields CanEditProjectField allows
+	Editors = []string{models.RoleAdmin, models.RoleManager, models.RolePartner}
+)
+
+// RequireRole only lets users with one of roles reach next
+func RequireRole(roles []string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user := CurrentUser(r)
+		if user == nil || !slices.Contains(roles, user.Role) {
+			respondError(w, http.StatusForbidden, "Your role does not allow this request")
+			return
+		}
+		next(w, r)
+	}
+}
+
+// presentProject prepares a project for the current user: delivery links stay
+// hidden until released, and role-restricted fields are cleared
+func presentProject(r *http.Request, p *models.Project) {
+	p.RedactLinks()
+	p.RedactForRole(CurrentUser(r).Role)
+}
+
+// presentProjectVersion prepares a stored version of current for the current
+// user. Its links show only while current's are released: a version saved
+// when the project was paid up must not reveal them after a payment is
+// deleted.
+func presentProjectVersion(r *http.Request, v *models.Project, current *models.Project) {
+	if !current.LinksReleased() {
+		v.ClearLinks()
+	}
+	v.RedactForRole(CurrentUser(r).Role)
+}
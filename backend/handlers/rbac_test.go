@@ -0,0 +1,139 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"project-tracker/models"
+)
+
+var (
+	testManager = &models.User{ID: "test-manager", Name: "Manager", Role: models.RoleManager}
+	testViewer  = &models.User{ID: "test-viewer", Name: "Viewer", Role: models.RoleViewer}
+)
+
+// testPartnerUser returns a partner-role user linked to partnerID
+func testPartnerUser(partnerID string) *models.User {
+	return &models.User{ID: "test-partner", Name: "Partner", Role: models.RolePartner, PartnerID: &partnerID}
+}
+
+func TestRequireRole(t *testing.T) {
+	openTestDB(t)
+	create := RequireRole(Staff, CreateProject)
+	body := `{"name": "P", "type": "software", "deadline": "2026-12-31", "totalAmount": 100}`
+
+	for _, tc := range []struct {
+		user *models.User
+		want int
+	}{
+		{testAdmin, http.StatusCreated},
+		{testManager, http.StatusCreated},
+		{testViewer, http.StatusForbidden},
+		{testPartnerUser("p1"), http.StatusForbidden},
+		{nil, http.StatusForbidden},
+	} {
+		if w := serveAs(tc.user, create, "POST", "/", nil, body); w.Code != tc.want {
+			t.Errorf("%v: status %d, want %d", tc.user, w.Code, tc.want)
+		}
+	}
+}
+
+func TestInternalNotesHiddenFromViewersAndPartners(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"internalNotes": "Client pays late"}`)
+	vars := map[string]string{"id": project["id"].(string)}
+
+	for _, tc := range []struct {
+		user  *models.User
+		shown bool
+	}{
+		{testAdmin, true},
+		{testManager, true},
+		{testViewer, false},
+		{testPartnerUser("p1"), false},
+	} {
+		var p map[string]interface{}
+		decode(t, serveAs(tc.user, GetProject, "GET", "/", vars, ""), http.StatusOK, &p)
+		if _, ok := p["internalNotes"]; ok != tc.shown {
+			t.Errorf("%s get: internalNotes shown %v, want %v", tc.user.Role, ok, tc.shown)
+		}
+
+		var list []map[string]interface{}
+		decode(t, serveAs(tc.user, GetProjects, "GET", "/", nil, ""), http.StatusOK, &list)
+		if len(list) != 1 {
+			t.Fatalf("%s list: %d projects", tc.user.Role, len(list))
+		}
+		if _, ok := list[0]["internalNotes"]; ok != tc.shown {
+			t.Errorf("%s list: internalNotes shown %v, want %v", tc.user.Role, ok, tc.shown)
+		}
+	}
+}
+
+func TestPartnerEditsOnlyDeliveryFields(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"totalAmount": 5000}`)
+	vars := map[string]string{"id": project["id"].(string)}
+	partner := testPartnerUser("p1")
+
+	if w := updateProject(partner, vars, `{"description": "Phase two", "repoLink": "https://git.example.com/p"}`); w.Code != http.StatusOK {
+		t.Fatalf("partner delivery update: status %d: %s", w.Code, w.Body)
+	}
+
+	// The whole project sent back is fine as long as the restricted fields
+	// are unchanged
+	if w := updateProject(partner, vars, `{"description": "Phase three", "name": "Test project", "totalAmount": 5000}`); w.Code != http.StatusOK {
+		t.Errorf("partner update with unchanged fields: status %d: %s", w.Code, w.Body)
+	}
+
+	for _, body := range []string{`{"totalAmount": 9000}`, `{"name": "Renamed"}`, `{"internalNotes": "x"}`} {
+		if w := updateProject(partner, vars, body); w.Code != http.StatusForbidden {
+			t.Errorf("partner %s: status %d, want 403", body, w.Code)
+		}
+	}
+	if w := updateProject(testViewer, vars, `{"description": "x"}`); w.Code != http.StatusForbidden {
+		t.Errorf("viewer update: status %d, want 403", w.Code)
+	}
+
+	p := getProject(t, vars["id"])
+	if p["description"] != "Phase three" || p["totalAmount"] != 5000.0 || p["name"] != "Test project" {
+		t.Errorf("project after partner edits = %v", p)
+	}
+}
+
+func TestPayoutsVisibleByRole(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"totalReceived": 4000}`)
+	projectVars := map[string]string{"id": project["id"].(string)}
+
+	partnerIDs := []string{}
+	for _, name := range []string{"Asha", "Ravi"} {
+		var partner map[string]interface{}
+		decode(t, serve(CreatePartner, "POST", nil, `{"name": "`+name+`"}`), http.StatusCreated, &partner)
+		id := partner["id"].(string)
+		partnerIDs = append(partnerIDs, id)
+		decode(t, serve(CreatePayout, "POST", projectVars, `{"partnerId": "`+id+`", "amount": 500}`), http.StatusCreated, nil)
+	}
+	asha := testPartnerUser(partnerIDs[0])
+
+	var payouts []map[string]interface{}
+	decode(t, serveAs(asha, GetProjectPayouts, "GET", "/", projectVars, ""), http.StatusOK, &payouts)
+	if len(payouts) != 1 || payouts[0]["partnerId"] != partnerIDs[0] {
+		t.Errorf("partner sees payouts %v, want only their own", payouts)
+	}
+	decode(t, serveAs(testManager, GetProjectPayouts, "GET", "/", projectVars, ""), http.StatusOK, &payouts)
+	if len(payouts) != 2 {
+		t.Errorf("manager sees %d payouts, want 2", len(payouts))
+	}
+	if w := serveAs(testViewer, GetProjectPayouts, "GET", "/", projectVars, ""); w.Code != http.StatusForbidden {
+		t.Errorf("viewer project payouts: status %d, want 403", w.Code)
+	}
+
+	own := map[string]string{"partnerId": partnerIDs[0]}
+	other := map[string]string{"partnerId": partnerIDs[1]}
+	if w := serveAs(asha, GetPartnerPayouts, "GET", "/", own, ""); w.Code != http.StatusOK {
+		t.Errorf("partner own payouts: status %d", w.Code)
+	}
+	if w := serveAs(asha, GetPartnerPayouts, "GET", "/", other, ""); w.Code != http.StatusForbidden {
+		t.Errorf("partner other's payouts: status %d, want 403", w.Code)
+	}
+}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+// SummaryReport totals the payments received in a period, per currency and
+// converted into the base currency at the rate on each payment date
+type SummaryReport struct {
+	BaseCurrency string          `json:"baseCurrency"`
+	From         string          `json:"from,omitempty"`
+	To           string          `json:"to,omitempty"`
+	Received     models.Money    `json:"received"` // In BaseCurrency
+	ByCurrency   []CurrencyTotal `json:"byCurrency"`
+}
+
+// CurrencyTotal is the part of a SummaryReport paid in one currency
+type CurrencyTotal struct {
+	Currency       string       `json:"currency"`
+	Payments       int          `json:"payments"`
+	Received       models.Money `json:"received"`
+	ReceivedInBase models.Money `json:"receivedInBase"`
+}
+
+// GetSummaryReport reports payments received between the optional from and to
+// dates (inclusive). It answers 422 when a payment has no exchange rate on or
+// before its date, rather than silently leaving it out.
+func GetSummaryReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	report := SummaryReport{BaseCurrency: BaseCurrency, ByCurrency: []CurrencyTotal{}}
+
+	query := `SELECT currency, amount, substr(paid_at, 1, 10) FROM payments WHERE ` + inActiveProject
+	args := []interface{}{}
+
+	if from := q.Get("from"); from != "" {
+		if _, err := time.Parse("2006-01-02", from); err != nil {
+			respondError(w, http.StatusBadRequest, "from must be in YYYY-MM-DD format")
+			return
+		}
+		query += ` AND substr(paid_at, 1, 10) >= ?`
+		args = append(args, from)
+		report.From = from
+	}
+	if to := q.Get("to"); to != "" {
+		if _, err := time.Parse("2006-01-02", to); err != nil {
+			respondError(w, http.StatusBadRequest, "to must be in YYYY-MM-DD format")
+			return
+		}
+		query += ` AND substr(paid_at, 1, 10) <= ?`
+		args = append(args, to)
+		report.To = to
+	}
+
+	rows, err := db.DB.Query(query, args...)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+
+	type payment struct {
+		currency, date string
+		amount         models.Money
+	}
+	payments := []payment{}
+	for rows.Next() {
+		var p payment
+		if err := rows.Scan(&p.currency, &p.amount, &p.date); err != nil {
+			rows.Close()
+			respondError(w, http.StatusInternalServerError, "Failed to scan payment")
+			return
+		}
+		payments = append(payments, p)
+	}
+	rows.Close()
+
+	totals := map[string]*CurrencyTotal{}
+	for _, p := range payments {
+		rate, err := rateOn(db.DB, p.currency, p.date)
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusUnprocessableEntity, "No "+p.currency+" exchange rate on or before "+p.date)
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch exchange rate")
+			return
+		}
+		parsed, ok := models.ParseRate(rate)
+		if !ok {
+			respondError(w, http.StatusInternalServerError, "Invalid stored exchange rate for "+p.currency)
+			return
+		}
+
+		converted := p.amount.Convert(parsed)
+
+		total, ok := totals[p.currency]
+		if !ok {
+			total = &CurrencyTotal{Currency: p.currency}
+			totals[p.currency] = total
+		}
+		total.Payments++
+		total.Received += p.amount
+		total.ReceivedInBase += converted
+		report.Received += converted
+	}
+
+	for _, total := range totals {
+		report.ByCurrency = append(report.ByCurrency, *total)
+	}
+	sort.Slice(report.ByCurrency, func(i, j int) bool {
+		return report.ByCurrency[i].Currency < report.ByCurrency[j].Currency
+	})
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// TDSReport lists the TDS clients deducted from payments in one quarter of a
+// financial year, grouped the way Form 26AS lists it: by deductor and
+// section. Amounts are in INR, the only currency TDS is recorded in.
+type TDSReport struct {
+	FinancialYear string        `json:"financialYear"`
+	Quarter       int           `json:"quarter"`
+	From          string        `json:"from"`
+	To            string        `json:"to"`
+	GrossAmount   models.Money  `json:"grossAmount"`
+	TDSAmount     models.Money  `json:"tdsAmount"`
+	Deductors     []TDSDeductor `json:"deductors"`
+}
+
+// TDSDeductor is the TDS one client deducted under one section
+type TDSDeductor struct {
+	ClientID    *string      `json:"clientId,omitempty"`
+	ClientName  *string      `json:"clientName,omitempty"`
+	TAN         *string      `json:"tan,omitempty"`
+	Section     string       `json:"section"`
+	GrossAmount models.Money `json:"grossAmount"` // Amount paid or credited, as 26AS calls it
+	TDSAmount   models.Money `json:"tdsAmount"`
+	Payments    []TDSPayment `json:"payments"`
+}
+
+// TDSPayment is a payment in a TDSDeductor
+type TDSPayment struct {
+	PaymentID   string       `json:"paymentId"`
+	ProjectID   string       `json:"projectId"`
+	ProjectName string       `json:"projectName"`
+	Date        string       `json:"date"` // YYYY-MM-DD
+	Reference   *string      `json:"reference,omitempty"`
+	GrossAmount models.Money `json:"grossAmount"`
+	TDSAmount   models.Money `json:"tdsAmount"`
+}
+
+// GetTDSReport reports the TDS receivable for ?financialYear=2025-26 and
+// ?quarter=1-4, by default the current quarter, to reconcile against the
+// credits shown in Form 26AS
+func GetTDSReport(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	financialYear, quarter := models.TDSQuarter(time.Now().UTC())
+	if fy := q.Get("financialYear"); fy != "" {
+		financialYear = fy
+	}
+	if qs := q.Get("quarter"); qs != "" {
+		n, err := strconv.Atoi(qs)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "quarter must be 1, 2, 3 or 4")
+			return
+		}
+		quarter = n
+	}
+	from, to, ok := models.TDSQuarterDates(financialYear, quarter)
+	if !ok {
+		respondError(w, http.StatusBadRequest, "financialYear must be written as 2025-26 and quarter must be 1, 2, 3 or 4")
+		return
+	}
+
+	report := TDSReport{FinancialYear: financialYear, Quarter: quarter, From: from, To: to, Deductors: []TDSDeductor{}}
+
+	rows, err := db.DB.Query(`
+		SELECT pay.id, pay.project_id, p.name, substr(pay.paid_at, 1, 10), pay.reference,
+			pay.amount + pay.tds_amount, pay.tds_amount, pay.tds_section,
+			c.id, COALESCE(c.name, p.clientName), c.tan
+		FROM payments pay
+		JOIN projects p ON p.id = pay.project_id
+		LEFT JOIN clients c ON c.id = p.clientId
+		WHERE pay.tds_amount > 0 AND p.deletedAt IS NULL
+			AND substr(pay.paid_at, 1, 10) BETWEEN ? AND ?
+		ORDER BY COALESCE(c.name, p.clientName) COLLATE NOCASE, pay.tds_section, pay.paid_at, pay.created_at
+	`, from, to)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+	defer rows.Close()
+
+	index := map[string]int{}
+	for rows.Next() {
+		var pay TDSPayment
+		var d TDSDeductor
+		err := rows.Scan(&pay.PaymentID, &pay.ProjectID, &pay.ProjectName, &pay.Date, &pay.Reference,
+			&pay.GrossAmount, &pay.TDSAmount, &d.Section, &d.ClientID, &d.ClientName, &d.TAN)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan payment")
+			return
+		}
+
+		// Linked clients are told apart by id, others by the name on the project
+		key := derefString(d.ClientName) + "\x00" + d.Section
+		if d.ClientID != nil {
+			key = *d.ClientID + "\x00" + d.Section
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(report.Deductors)
+			index[key] = i
+			d.Payments = []TDSPayment{}
+			report.Deductors = append(report.Deductors, d)
+		}
+
+		deductor := &report.Deductors[i]
+		deductor.Payments = append(deductor.Payments, pay)
+		deductor.GrossAmount += pay.GrossAmount
+		deductor.TDSAmount += pay.TDSAmount
+		report.GrossAmount += pay.GrossAmount
+		report.TDSAmount += pay.TDSAmount
+	}
+	if err := rows.Err(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch payments")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+
+	"project-tracker/models"
+)
+
+func TestSummaryReportConvertsAtPaymentDateRates(t *testing.T) {
+	openTestDB(t)
+	inr := createProject(t, `{"totalAmount": 50000}`)["id"].(string)
+	usd := createProject(t, `{"totalAmount": 1000, "currency": "USD"}`)["id"].(string)
+
+	payments := []struct{ project, body string }{
+		{inr, `{"amount": 10000, "date": "2026-01-10"}`},
+		{usd, `{"amount": 100, "date": "2026-01-15"}`},
+		{usd, `{"amount": 200.5, "date": "2026-02-15"}`},
+	}
+	for _, p := range payments {
+		decode(t, serve(CreatePayment, "POST", map[string]string{"id": p.project}, p.body), http.StatusCreated, nil)
+	}
+
+	// A payment in another currency is refused, not converted
+	if w := serve(CreatePayment, "POST", map[string]string{"id": usd}, `{"amount": 5, "currency": "EUR"}`); w.Code != http.StatusBadRequest {
+		t.Errorf("EUR payment on a USD project: status %d, want 400", w.Code)
+	}
+
+	// Without a rate the report cannot be worked out
+	decode(t, serveGET(GetSummaryReport, "/api/reports/summary", nil), http.StatusUnprocessableEntity, nil)
+
+	for _, body := range []string{
+		`{"currency": "USD", "date": "2026-01-01", "rate": 83}`,
+		`{"currency": "USD", "date": "2026-02-01", "rate": 83.5}`,
+	} {
+		decode(t, serve(CreateExchangeRate, "POST", nil, body), http.StatusCreated, nil)
+	}
+	for _, body := range []string{
+		`{"currency": "INR", "date": "2026-01-01", "rate": 1}`,
+		`{"currency": "XYZ", "date": "2026-01-01", "rate": 1}`,
+		`{"currency": "USD", "date": "2026-01-01", "rate": 0}`,
+		`{"currency": "USD", "date": "01/01/2026", "rate": 83}`,
+	} {
+		if w := serve(CreateExchangeRate, "POST", nil, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+
+	tests := []struct {
+		target   string
+		received float64
+		usd      float64
+	}{
+		// 10000 + 100 at 83 + 200.5 at 83.5
+		{"/api/reports/summary", 10000 + 8300 + 16741.75, 8300 + 16741.75},
+		{"/api/reports/summary?from=2026-01-11&to=2026-01-31", 8300, 8300},
+		{"/api/reports/summary?to=2026-01-10", 10000, 0},
+	}
+	for _, tt := range tests {
+		var report map[string]interface{}
+		decode(t, serveGET(GetSummaryReport, tt.target, nil), http.StatusOK, &report)
+		if report["received"] != tt.received {
+			t.Errorf("%s: received %v, want %v", tt.target, report["received"], tt.received)
+		}
+		usdInBase := 0.0
+		for _, c := range report["byCurrency"].([]interface{}) {
+			if c := c.(map[string]interface{}); c["currency"] == "USD" {
+				usdInBase = c["receivedInBase"].(float64)
+			}
+		}
+		if usdInBase != tt.usd {
+			t.Errorf("%s: USD received %v in base, want %v", tt.target, usdInBase, tt.usd)
+		}
+	}
+
+	decode(t, serveGET(GetSummaryReport, "/api/reports/summary?from=2026-1-1", nil), http.StatusBadRequest, nil)
+}
+
+func TestTDSReport(t *testing.T) {
+	openTestDB(t)
+	var client map[string]interface{}
+	decode(t, serve(CreateClient, "POST", nil, `{"name": "Acme Corp", "tan": "blra12345b"}`), http.StatusCreated, &client)
+	acme := createProject(t, `{"name": "Portal", "totalAmount": 500000, "clientId": "`+client["id"].(string)+`"}`)["id"].(string)
+	walkIn := createProject(t, `{"name": "Logo", "totalAmount": 50000, "clientName": "Bee Studio"}`)["id"].(string)
+
+	for _, p := range []struct{ project, body string }{
+		{acme, `{"amount": 90000, "tdsAmount": 10000, "tdsSection": "194J", "date": "2026-10-01", "reference": "NEFT-1"}`},
+		{acme, `{"amount": 45000, "tdsAmount": 5000, "tdsSection": "194J", "date": "2026-12-31"}`},
+		{acme, `{"amount": 49000, "tdsAmount": 1000, "tdsSection": "194C", "date": "2026-11-15"}`},
+		{walkIn, `{"amount": 9000, "tdsAmount": 1000, "tdsSection": "194J", "date": "2026-11-20"}`},
+		// Outside the quarter, or without TDS
+		{acme, `{"amount": 18000, "tdsAmount": 2000, "tdsSection": "194J", "date": "2026-09-30"}`},
+		{acme, `{"amount": 27000, "tdsAmount": 3000, "tdsSection": "194J", "date": "2027-01-01"}`},
+		{walkIn, `{"amount": 5000, "date": "2026-11-21"}`},
+	} {
+		decode(t, serve(CreatePayment, "POST", map[string]string{"id": p.project}, p.body), http.StatusCreated, nil)
+	}
+
+	var report TDSReport
+	decode(t, serveGET(GetTDSReport, "/?financialYear=2026-27&quarter=3", nil), http.StatusOK, &report)
+	if report.From != "2026-10-01" || report.To != "2026-12-31" {
+		t.Errorf("quarter 3 runs %s to %s", report.From, report.To)
+	}
+	if report.GrossAmount != 21000000 || report.TDSAmount != 1700000 {
+		t.Errorf("totals %s gross, %s TDS, want 210000.00 and 17000.00", report.GrossAmount, report.TDSAmount)
+	}
+
+	want := []struct {
+		name, section string
+		tds           models.Money
+		payments      int
+	}{
+		{"Acme Corp", "194C", 100000, 1},
+		{"Acme Corp", "194J", 1500000, 2},
+		{"Bee Studio", "194J", 100000, 1},
+	}
+	if len(report.Deductors) != len(want) {
+		t.Fatalf("deductors = %+v", report.Deductors)
+	}
+	for i, w := range want {
+		d := report.Deductors[i]
+		if show(d.ClientName) != w.name || d.Section != w.section || d.TDSAmount != w.tds || len(d.Payments) != w.payments {
+			t.Errorf("deductor %d = %s %s %s with %d payments, want %s %s %s with %d",
+				i, show(d.ClientName), d.Section, d.TDSAmount, len(d.Payments), w.name, w.section, w.tds, w.payments)
+		}
+	}
+	if acme := report.Deductors[1]; show(acme.TAN) != "BLRA12345B" || show(acme.Payments[0].Reference) != "NEFT-1" || acme.GrossAmount != 15000000 {
+		t.Errorf("Acme 194J = %+v", acme)
+	}
+	if bee := report.Deductors[2]; bee.ClientID != nil || bee.TAN != nil {
+		t.Errorf("unlinked client = %+v", bee)
+	}
+
+	for _, target := range []string{"/?quarter=5", "/?quarter=x", "/?financialYear=2026-28", "/?financialYear=2026"} {
+		decode(t, serveGET(GetTDSReport, target, nil), http.StatusBadRequest, nil)
+	}
+}
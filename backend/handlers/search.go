@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+// searchFields are the project_search columns, in table order
+var searchFields = []string{
+	"name", "clientName", "description", "completionNotes",
+	"deliveryNotes", "internalNotes", "techStack", "deliverables",
+}
+
+// Snippets are marked with private-use characters so the text around them can
+// be HTML-escaped before the markers become <mark> tags
+const (
+	snippetStart = "\uE000"
+	snippetEnd   = "\uE001"
+)
+
+// SearchResult is a project matching a search, with a snippet from each field
+// that matched
+type SearchResult struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`
+	ClientName *string       `json:"clientName,omitempty"`
+	Rank       float64       `json:"rank"` // Lower is a better match
+	Matches    []SearchMatch `json:"matches"`
+}
+
+// SearchMatch is a highlighted excerpt of one field. Snippet is HTML: the
+// field text is escaped and the matched terms are wrapped in <mark>.
+type SearchMatch struct {
+	Field   string `json:"field"`
+	Snippet string `json:"snippet"`
+}
+
+// searchQuery turns free text into an FTS5 query: every word must appear,
+// each as a quoted prefix so operators in the input are taken literally. When
+// fields is not empty the words are only looked for in those columns.
+func searchQuery(text string, fields []string) string {
+	filter := ""
+	if len(fields) > 0 {
+		filter = "{" + strings.Join(fields, " ") + "} : "
+	}
+
+	terms := []string{}
+	for _, word := range strings.Fields(text) {
+		terms = append(terms, filter+`"`+strings.ReplaceAll(word, `"`, `""`)+`"*`)
+	}
+	return strings.Join(terms, " AND ")
+}
+
+// SearchProjects runs a ranked full-text search over the projects' text
+// fields. Roles that cannot see internal notes neither match on nor see them.
+func SearchProjects(w http.ResponseWriter, r *http.Request) {
+	text := strings.TrimSpace(r.URL.Query().Get("q"))
+	if text == "" {
+		respondError(w, http.StatusBadRequest, "q is required")
+		return
+	}
+
+	limit := defaultSearchLimit
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 {
+			respondError(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = min(n, maxSearchLimit)
+	}
+
+	visible := searchFields
+	var restrict []string
+	if !models.CanSeeInternal(CurrentUser(r).Role) {
+		visible = []string{}
+		for _, field := range searchFields {
+			if field != "internalNotes" {
+				visible = append(visible, field)
+			}
+		}
+		restrict = visible
+	}
+
+	snippets := make([]string, len(searchFields))
+	for i := range searchFields {
+		snippets[i] = fmt.Sprintf(`snippet(project_search, %d, '%s', '%s', '…', 12)`, i, snippetStart, snippetEnd)
+	}
+
+	// Names count for more than notes when ranking
+	rows, err := db.DB.Query(`
+		SELECT p.id, p.name, p.clientName, bm25(project_search, 10.0, 5.0, 1.0, 1.0, 1.0, 1.0, 2.0, 1.0) AS score,
+		       `+strings.Join(snippets, ", ")+`
+		FROM project_search
+		JOIN projects p ON p.rowid = project_search.rowid
+		WHERE project_search MATCH ? AND p.deletedAt IS NULL
+		ORDER BY score
+		LIMIT ?
+	`, searchQuery(text, restrict), limit)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to search projects")
+		return
+	}
+	defer rows.Close()
+
+	results := []SearchResult{}
+	for rows.Next() {
+		var res SearchResult
+		fieldSnippets := make([]*string, len(searchFields))
+		dest := []interface{}{&res.ID, &res.Name, &res.ClientName, &res.Rank}
+		for i := range fieldSnippets {
+			dest = append(dest, &fieldSnippets[i])
+		}
+		if err := rows.Scan(dest...); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan search result")
+			return
+		}
+
+		res.Matches = []SearchMatch{}
+		for i, field := range searchFields {
+			snippet := fieldSnippets[i]
+			if snippet == nil || !strings.Contains(*snippet, snippetStart) || !slices.Contains(visible, field) {
+				continue
+			}
+			marked := html.EscapeString(*snippet)
+			marked = strings.ReplaceAll(marked, snippetStart, "<mark>")
+			marked = strings.ReplaceAll(marked, snippetEnd, "</mark>")
+			res.Matches = append(res.Matches, SearchMatch{Field: field, Snippet: marked})
+		}
+		results = append(results, res)
+	}
+
+	respondJSON(w, http.StatusOK, results)
+}
@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+// search runs q as user and returns the results
+func search(t *testing.T, user *models.User, q string) []SearchResult {
+	t.Helper()
+	var results []SearchResult
+	decode(t, serveAs(user, SearchProjects, "GET", "/?q="+url.QueryEscape(q), nil, ""), http.StatusOK, &results)
+	return results
+}
+
+func TestSearchProjects(t *testing.T) {
+	openTestDB(t)
+	store := createProject(t, `{"name": "Storefront", "description": "Payment gateway <integration>", "internalNotes": "The gateway vendor is flaky"}`)
+	gateway := createProject(t, `{"name": "Gateway audit"}`)
+	createProject(t, `{"name": "Other", "internalNotes": "Nightingale"}`)
+
+	// A match in the name ranks above one in the description, and words
+	// match as prefixes
+	results := search(t, testAdmin, "gate")
+	if len(results) != 2 || results[0].ID != gateway["id"] || results[1].ID != store["id"] {
+		t.Fatalf("results = %+v, want the gateway audit, then the storefront", results)
+	}
+
+	// Snippets escape the field text and mark the match
+	snippets := map[string]string{}
+	for _, m := range results[1].Matches {
+		snippets[m.Field] = m.Snippet
+	}
+	if want := "Payment <mark>gateway</mark> &lt;integration&gt;"; snippets["description"] != want {
+		t.Errorf("description snippet %q, want %q", snippets["description"], want)
+	}
+	if want := "The <mark>gateway</mark> vendor is flaky"; snippets["internalNotes"] != want {
+		t.Errorf("internal notes snippet %q, want %q", snippets["internalNotes"], want)
+	}
+
+	// Every word must match
+	if results := search(t, testAdmin, "payment flaky"); len(results) != 1 || results[0].ID != store["id"] {
+		t.Errorf("two-word search = %+v, want the storefront", results)
+	}
+
+	// Viewers and partners neither match on internal notes nor see them
+	for _, user := range []*models.User{testViewer, testPartnerUser("p1")} {
+		if results := search(t, user, "nightingale"); len(results) != 0 {
+			t.Errorf("%s found %d projects by internal notes", user.Role, len(results))
+		}
+		results := search(t, user, "gateway")
+		if len(results) != 2 {
+			t.Fatalf("%s search = %+v, want 2 results", user.Role, results)
+		}
+		for _, res := range results {
+			for _, m := range res.Matches {
+				if m.Field == "internalNotes" {
+					t.Errorf("%s sees an internal notes snippet: %q", user.Role, m.Snippet)
+				}
+			}
+		}
+	}
+	if results := search(t, testManager, "nightingale"); len(results) != 1 {
+		t.Errorf("manager found %d projects by internal notes, want 1", len(results))
+	}
+
+	// Query syntax in the input is taken literally
+	for _, q := range []string{`gateway OR other`, `"gateway`, `name:other`, `gate*`, `(`} {
+		if w := serveGET(SearchProjects, "/?q="+url.QueryEscape(q), nil); w.Code != http.StatusOK {
+			t.Errorf("q=%s: status %d: %s", q, w.Code, w.Body)
+		}
+	}
+
+	// The index follows updates and deletes
+	decode(t, updateProject(testAdmin, map[string]string{"id": gateway["id"].(string)}, `{"name": "Security review"}`), http.StatusOK, nil)
+	if results := search(t, testAdmin, "security"); len(results) != 1 || results[0].ID != gateway["id"] {
+		t.Errorf("search after rename = %+v", results)
+	}
+	decode(t, serve(DeleteProject, "DELETE", map[string]string{"id": store["id"].(string)}, ""), http.StatusOK, nil)
+	if results := search(t, testAdmin, "payment"); len(results) != 0 {
+		t.Errorf("deleted project still found: %+v", results)
+	}
+
+	// A lost index is restored by a rebuild
+	if _, err := db.DB.Exec(`INSERT INTO project_search (project_search) VALUES ('delete-all')`); err != nil {
+		t.Fatalf("clear index: %v", err)
+	}
+	if results := search(t, testAdmin, "security"); len(results) != 0 {
+		t.Fatalf("cleared index still finds %+v", results)
+	}
+	if err := db.RebuildSearchIndex(); err != nil {
+		t.Fatalf("rebuild: %v", err)
+	}
+	if results := search(t, testAdmin, "security"); len(results) != 1 {
+		t.Errorf("rebuilt index finds %d projects, want 1", len(results))
+	}
+
+	for _, target := range []string{"/", "/?q=%20", "/?q=gate&limit=0"} {
+		if w := serveGET(SearchProjects, target, nil); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", target, w.Code)
+		}
+	}
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const apiTokenColumns = `id, user_id, name, prefix, scope, created_at, expires_at, last_used_at, revoked_at`
+
+// apiTokenPrefix marks the secrets we issue, so they are easy to spot in
+// scripts and secret scanners
+const apiTokenPrefix = "ptk_"
+
+// createdAPIToken is returned once, on creation, with the secret itself
+type createdAPIToken struct {
+	models.APIToken
+	Token string `json:"token"`
+}
+
+// requireTokenAdmin stops API tokens below admin scope from managing tokens
+// or users, so a leaked script token cannot mint itself a stronger one, nor
+// create an admin to log in as and mint one from there
+func requireTokenAdmin(w http.ResponseWriter, r *http.Request) bool {
+	if scope := tokenScope(r); scope != "" && scope != models.ScopeAdmin {
+		respondError(w, http.StatusForbidden, "Managing API tokens and users requires an admin-scoped token")
+		return false
+	}
+	return true
+}
+
+// GetAPITokens lists the current user's tokens, including revoked ones
+func GetAPITokens(w http.ResponseWriter, r *http.Request) {
+	if !requireTokenAdmin(w, r) {
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT `+apiTokenColumns+`
+		FROM api_tokens
+		WHERE user_id = ?
+		ORDER BY created_at DESC
+	`, CurrentUser(r).ID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch API tokens")
+		return
+	}
+	defer rows.Close()
+
+	tokens := []models.APIToken{}
+	for rows.Next() {
+		var t models.APIToken
+		if err := t.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan API token")
+			return
+		}
+		tokens = append(tokens, t)
+	}
+
+	respondJSON(w, http.StatusOK, tokens)
+}
+
+func CreateAPIToken(w http.ResponseWriter, r *http.Request) {
+	if !requireTokenAdmin(w, r) {
+		return
+	}
+
+	var req struct {
+		Name      string  `json:"name"`
+		Scope     string  `json:"scope"`
+		ExpiresAt *string `json:"expiresAt"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		respondError(w, http.StatusBadRequest, "name is required")
+		return
+	}
+	if !models.ValidScope(req.Scope) {
+		respondError(w, http.StatusBadRequest, "scope must be 'read', 'write', or 'admin'")
+		return
+	}
+
+	now := time.Now().UTC()
+
+	var expiresAt *string
+	if req.ExpiresAt != nil && *req.ExpiresAt != "" {
+		expires, err := time.Parse(time.RFC3339, *req.ExpiresAt)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "expiresAt must be an RFC3339 timestamp")
+			return
+		}
+		if !expires.After(now) {
+			respondError(w, http.StatusBadRequest, "expiresAt must be in the future")
+			return
+		}
+		formatted := expires.UTC().Format(time.RFC3339)
+		expiresAt = &formatted
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+	token := apiTokenPrefix + secret
+
+	created := createdAPIToken{
+		APIToken: models.APIToken{
+			ID:        uuid.New().String(),
+			UserID:    CurrentUser(r).ID,
+			Name:      req.Name,
+			Prefix:    token[:len(apiTokenPrefix)+6],
+			Scope:     req.Scope,
+			CreatedAt: now.Format(time.RFC3339),
+			ExpiresAt: expiresAt,
+		},
+		Token: token,
+	}
+
+	_, err = db.DB.Exec(`
+		INSERT INTO api_tokens (id, user_id, name, token_hash, prefix, scope, created_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, created.ID, created.UserID, created.Name, hashToken(token), created.Prefix, created.Scope, created.CreatedAt, created.ExpiresAt)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to create API token")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// RevokeAPIToken marks one of the current user's tokens as revoked. The row is
+// kept so the token's history stays visible.
+func RevokeAPIToken(w http.ResponseWriter, r *http.Request) {
+	if !requireTokenAdmin(w, r) {
+		return
+	}
+
+	id := mux.Vars(r)["tokenId"]
+	userID := CurrentUser(r).ID
+
+	result, err := db.DB.Exec(`
+		UPDATE api_tokens SET revoked_at = ?
+		WHERE id = ? AND user_id = ? AND revoked_at IS NULL
+	`, time.Now().UTC().Format(time.RFC3339), id, userID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to revoke API token")
+		return
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		var t models.APIToken
+		err := t.Scan(db.DB.QueryRow(`SELECT `+apiTokenColumns+` FROM api_tokens WHERE id = ? AND user_id = ?`, id, userID))
+		if err != nil {
+			respondError(w, http.StatusNotFound, "API token not found")
+			return
+		}
+		respondError(w, http.StatusConflict, "API token is already revoked")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "API token revoked"})
+}
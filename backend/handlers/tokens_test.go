@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"project-tracker/db"
+
+	"github.com/gorilla/mux"
+)
+
+// serveBearer sends a request with the Authorization header given through
+// RequireAuth to handler
+func serveBearer(handler http.HandlerFunc, method string, vars map[string]string, authorization, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/", strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	r.Header.Set("Authorization", authorization)
+	r = mux.SetURLVars(r, vars)
+	w := httptest.NewRecorder()
+	RequireAuth(handler).ServeHTTP(w, r)
+	return w
+}
+
+// createToken creates an API token for the signed-in user and returns it
+// with its secret
+func createToken(t *testing.T, session, body string) map[string]interface{} {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/", strings.NewReader(body))
+	r.AddCookie(&http.Cookie{Name: sessionCookieName, Value: session})
+	w := httptest.NewRecorder()
+	RequireAuth(http.HandlerFunc(CreateAPIToken)).ServeHTTP(w, r)
+
+	var token map[string]interface{}
+	decode(t, w, http.StatusCreated, &token)
+	return token
+}
+
+func TestAPITokenScopes(t *testing.T) {
+	openTestDB(t)
+	createAdmin(t, "admin@example.com", "correct horse")
+	session := login(t, "admin@example.com", "correct horse")
+
+	read := createToken(t, session, `{"name": "Reports", "scope": "read"}`)
+	write := createToken(t, session, `{"name": "Sync", "scope": "write"}`)
+	admin := createToken(t, session, `{"name": "Ops", "scope": "admin"}`)
+
+	secret := read["token"].(string)
+	if !strings.HasPrefix(secret, apiTokenPrefix) || !strings.HasPrefix(secret, read["prefix"].(string)) {
+		t.Errorf("token %q, prefix %v", secret, read["prefix"])
+	}
+	var stored int
+	db.DB.QueryRow(`SELECT COUNT(*) FROM api_tokens WHERE token_hash = ?`, secret).Scan(&stored)
+	if stored != 0 {
+		t.Error("API token stored in plain text")
+	}
+
+	bearer := func(token map[string]interface{}) string { return "Bearer " + token["token"].(string) }
+
+	// A read token can read but not write
+	if w := serveBearer(GetProjects, "GET", nil, bearer(read), ""); w.Code != http.StatusOK {
+		t.Errorf("read token GET: status %d: %s", w.Code, w.Body)
+	}
+	if w := serveBearer(CreateProject, "POST", nil, bearer(read), `{"name": "P", "type": "software", "deadline": "2026-12-31", "totalAmount": 100}`); w.Code != http.StatusForbidden {
+		t.Errorf("read token POST: status %d, want 403", w.Code)
+	}
+
+	// A write token can write but cannot manage tokens
+	if w := serveBearer(CreateProject, "POST", nil, bearer(write), `{"name": "P", "type": "software", "deadline": "2026-12-31", "totalAmount": 100}`); w.Code != http.StatusCreated {
+		t.Errorf("write token POST: status %d: %s", w.Code, w.Body)
+	}
+	if w := serveBearer(CreateAPIToken, "POST", nil, bearer(write), `{"name": "Escalate", "scope": "admin"}`); w.Code != http.StatusForbidden {
+		t.Errorf("write token creating a token: status %d, want 403", w.Code)
+	}
+
+	// Nor users, or it could create an admin to log in as and mint one
+	if w := serveBearer(GetUsers, "GET", nil, bearer(write), ""); w.Code != http.StatusForbidden {
+		t.Errorf("write token listing users: status %d, want 403", w.Code)
+	}
+	if w := serveBearer(CreateUser, "POST", nil, bearer(write), `{"email": "x@example.com", "password": "long enough", "role": "admin"}`); w.Code != http.StatusForbidden {
+		t.Errorf("write token creating a user: status %d, want 403", w.Code)
+	}
+	if w := serveBearer(GetUsers, "GET", nil, bearer(admin), ""); w.Code != http.StatusOK {
+		t.Errorf("admin token listing users: status %d: %s", w.Code, w.Body)
+	}
+
+	// An admin token can, and the secret is never listed again
+	var tokens []map[string]interface{}
+	decode(t, serveBearer(GetAPITokens, "GET", nil, bearer(admin), ""), http.StatusOK, &tokens)
+	if len(tokens) != 3 {
+		t.Fatalf("%d tokens listed, want 3", len(tokens))
+	}
+	for _, tok := range tokens {
+		if _, ok := tok["token"]; ok {
+			t.Errorf("listed token %v exposes its secret", tok["name"])
+		}
+	}
+
+	var used *string
+	db.DB.QueryRow(`SELECT last_used_at FROM api_tokens WHERE id = ?`, read["id"]).Scan(&used)
+	if used == nil {
+		t.Error("last_used_at not recorded")
+	}
+
+	for _, header := range []string{"Basic abc", "Bearer ", "Bearer ptk_unknown"} {
+		if w := serveBearer(GetProjects, "GET", nil, header, ""); w.Code != http.StatusUnauthorized {
+			t.Errorf("Authorization %q: status %d, want 401", header, w.Code)
+		}
+	}
+
+	revoke := func(id string) int {
+		return serveBearer(RevokeAPIToken, "DELETE", map[string]string{"tokenId": id}, bearer(admin), "").Code
+	}
+	if code := revoke(read["id"].(string)); code != http.StatusOK {
+		t.Fatalf("revoke: status %d", code)
+	}
+	if w := serveBearer(GetProjects, "GET", nil, bearer(read), ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want 401", w.Code)
+	}
+	if code := revoke(read["id"].(string)); code != http.StatusConflict {
+		t.Errorf("revoke twice: status %d, want 409", code)
+	}
+	if code := revoke("missing"); code != http.StatusNotFound {
+		t.Errorf("revoke unknown token: status %d, want 404", code)
+	}
+
+	db.DB.Exec(`UPDATE api_tokens SET expires_at = ? WHERE id = ?`,
+		time.Now().UTC().Add(-time.Minute).Format(time.RFC3339), write["id"])
+	if w := serveBearer(GetProjects, "GET", nil, bearer(write), ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("expired token: status %d, want 401", w.Code)
+	}
+}
+
+func TestCreateAPITokenValidation(t *testing.T) {
+	openTestDB(t)
+	createAdmin(t, "admin@example.com", "correct horse")
+	session := login(t, "admin@example.com", "correct horse")
+	admin := "Bearer " + createToken(t, session, `{"name": "Ops", "scope": "admin"}`)["token"].(string)
+
+	past := time.Now().UTC().Add(-time.Hour).Format(time.RFC3339)
+	for _, body := range []string{
+		`{"scope": "read"}`,
+		`{"name": "T", "scope": "owner"}`,
+		`{"name": "T", "scope": "read", "expiresAt": "tomorrow"}`,
+		`{"name": "T", "scope": "read", "expiresAt": "` + past + `"}`,
+	} {
+		if w := serveBearer(CreateAPIToken, "POST", nil, admin, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+}
@@ -0,0 +1,210 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// GetTrash lists the projects in the trash, most recently deleted first
+func GetTrash(w http.ResponseWriter, r *http.Request) {
+	rows, err := db.DB.Query(`
+		SELECT ` + projectColumns + `
+		FROM projects
+		WHERE deletedAt IS NOT NULL
+		ORDER BY deletedAt DESC
+	`)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch trash")
+		return
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		var p models.Project
+		if err := p.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan project")
+			return
+		}
+		presentProject(r, &p)
+		projects = append(projects, p)
+	}
+
+	respondJSON(w, http.StatusOK, projects)
+}
+
+// RestoreProject takes a project back out of the trash
+func RestoreProject(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to restore project")
+		return
+	}
+	defer tx.Rollback()
+
+	var deletedAt *string
+	err = tx.QueryRow(`SELECT deletedAt FROM projects WHERE id = ?`, id).Scan(&deletedAt)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+	if deletedAt == nil {
+		respondError(w, http.StatusConflict, "Project is not in the trash")
+		return
+	}
+
+	if _, err := tx.Exec(`UPDATE projects SET deletedAt = NULL WHERE id = ?`, id); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to restore project")
+		return
+	}
+
+	field := "deletedAt"
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), id, "PROJECT_RESTORED", &field, deletedAt, nil, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+		return
+	}
+
+	var p models.Project
+	if err := p.Scan(tx.QueryRow(`SELECT `+projectColumns+` FROM projects WHERE id = ?`, id)); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch restored project")
+		return
+	}
+	if err := db.InsertProjectVersion(tx, auditActor(r), &p, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to restore project")
+		return
+	}
+
+	presentProject(r, &p)
+	setProjectETag(w, &p)
+	respondJSON(w, http.StatusOK, p)
+}
+
+// PurgeTrash permanently deletes the projects that have been in the trash for
+// longer than retention, returning how many were removed. Each is recorded as
+// PROJECT_DELETED by the system, with a snapshot of the project and its ledger.
+func PurgeTrash(retention time.Duration) (int, error) {
+	cutoff := time.Now().UTC().Add(-retention).Format(time.RFC3339)
+
+	rows, err := db.DB.Query(`SELECT id FROM projects WHERE deletedAt IS NOT NULL AND deletedAt < ?`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	purged := 0
+	for _, id := range ids {
+		if err := purgeProject(id, cutoff); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// purgeProject deletes one trashed project, cascading to its ledger, in its own
+// transaction. It does nothing if the project was restored in the meantime.
+func purgeProject(id, cutoff string) error {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Snapshot the project with its ledger before the cascade removes it,
+	// so the PROJECT_DELETED entry is enough to recover it
+	var snapshot deletedProjectSnapshot
+	err = snapshot.Project.Scan(tx.QueryRow(`
+		SELECT `+projectColumns+`
+		FROM projects
+		WHERE id = ? AND deletedAt IS NOT NULL AND deletedAt < ?
+	`, id, cutoff))
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if snapshot.Payments, err = fetchPayments(tx, id); err != nil {
+		return err
+	}
+	if snapshot.Payouts, err = fetchPayouts(tx, `WHERE project_id = ?`, id); err != nil {
+		return err
+	}
+
+	snapshotJSON, err := json.Marshal(snapshot)
+	if err != nil {
+		return err
+	}
+	snapshotValue := string(snapshotJSON)
+
+	// Issued invoices outlive the project; drafts go with it
+	if _, err := tx.Exec(`DELETE FROM invoices WHERE project_id = ? AND status = ?`, id, models.InvoiceDraft); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`DELETE FROM projects WHERE id = ?`, id); err != nil {
+		return err
+	}
+
+	ts := time.Now().UTC().Format(time.RFC3339)
+	if err := db.InsertAuditLog(tx, models.SystemActor, uuid.New().String(), id, "PROJECT_DELETED", nil, &snapshotValue, nil, ts); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RunTrashPurge purges the trash every interval until stop is closed, starting
+// immediately
+func RunTrashPurge(retention, interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if n, err := PurgeTrash(retention); err != nil {
+			log.Printf("trash purge failed: %v", err)
+		} else if n > 0 {
+			log.Printf("purged %d project(s) from the trash", n)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"project-tracker/db"
+)
+
+func TestTrashAndRestore(t *testing.T) {
+	openTestDB(t)
+	kept := createProject(t, `{"name": "Kept"}`)
+	trashed := createProject(t, `{"name": "Binned", "totalReceived": 300}`)
+	id := trashed["id"].(string)
+	vars := map[string]string{"id": id}
+
+	decode(t, serve(DeleteProject, "DELETE", vars, ""), http.StatusOK, nil)
+	decode(t, serve(DeleteProject, "DELETE", vars, ""), http.StatusNotFound, nil)
+
+	// A trashed project is out of sight everywhere but the trash
+	decode(t, serve(GetProject, "GET", vars, ""), http.StatusNotFound, nil)
+	decode(t, serve(GetPayments, "GET", vars, ""), http.StatusNotFound, nil)
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 10}`), http.StatusNotFound, nil)
+	decode(t, updateProject(testAdmin, vars, `{"name": "Edited"}`), http.StatusNotFound, nil)
+
+	var list []map[string]interface{}
+	decode(t, serveGET(GetProjects, "/", nil), http.StatusOK, &list)
+	if len(list) != 1 || list[0]["id"] != kept["id"] {
+		t.Errorf("project list = %v, want only the kept project", list)
+	}
+	if results := search(t, testAdmin, "binned"); len(results) != 0 {
+		t.Errorf("search finds trashed project: %+v", results)
+	}
+
+	var trash []map[string]interface{}
+	decode(t, serveGET(GetTrash, "/", nil), http.StatusOK, &trash)
+	if len(trash) != 1 || trash[0]["id"] != id || trash[0]["deletedAt"] == nil {
+		t.Fatalf("trash = %v, want the binned project", trash)
+	}
+
+	// Restoring brings it back with its ledger untouched
+	var restored map[string]interface{}
+	decode(t, serve(RestoreProject, "POST", vars, ""), http.StatusOK, &restored)
+	if restored["deletedAt"] != nil || restored["totalReceived"] != 300.0 {
+		t.Errorf("restored project = %v", restored)
+	}
+	decode(t, serve(RestoreProject, "POST", vars, ""), http.StatusConflict, nil)
+	decode(t, serve(RestoreProject, "POST", map[string]string{"id": "missing"}, ""), http.StatusNotFound, nil)
+	if results := search(t, testAdmin, "binned"); len(results) != 1 {
+		t.Errorf("restored project found %d times by search, want 1", len(results))
+	}
+
+	var page AuditPage
+	decode(t, serveGET(GetProjectAuditLogs, "/?field=deletedAt", vars), http.StatusOK, &page)
+	if len(page.Entries) != 2 || page.Entries[0].Action != "PROJECT_RESTORED" || page.Entries[1].Action != "PROJECT_TRASHED" {
+		t.Errorf("trash audit entries = %+v, want trashed then restored", page.Entries)
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	openTestDB(t)
+	old := createProject(t, `{"name": "Old", "totalReceived": 100}`)["id"].(string)
+	recent := createProject(t, `{"name": "Recent"}`)["id"].(string)
+	createProject(t, `{"name": "Active"}`)
+
+	for _, id := range []string{old, recent} {
+		decode(t, serve(DeleteProject, "DELETE", map[string]string{"id": id}, ""), http.StatusOK, nil)
+	}
+	longAgo := time.Now().UTC().AddDate(0, 0, -31).Format(time.RFC3339)
+	db.DB.Exec(`UPDATE projects SET deletedAt = ? WHERE id = ?`, longAgo, old)
+
+	// Only what has been in the trash past the retention period goes
+	n, err := PurgeTrash(30 * 24 * time.Hour)
+	if err != nil || n != 1 {
+		t.Fatalf("purge: %d projects, %v; want 1", n, err)
+	}
+	var remaining, payments int
+	db.DB.QueryRow(`SELECT COUNT(*) FROM projects`).Scan(&remaining)
+	db.DB.QueryRow(`SELECT COUNT(*) FROM payments WHERE project_id = ?`, old).Scan(&payments)
+	if remaining != 2 || payments != 0 {
+		t.Errorf("%d projects and %d of the purged project's payments left, want 2 and 0", remaining, payments)
+	}
+	decode(t, serve(RestoreProject, "POST", map[string]string{"id": old}, ""), http.StatusNotFound, nil)
+
+	var page AuditPage
+	decode(t, serveGET(GetAuditLogs, "/?action=PROJECT_DELETED", nil), http.StatusOK, &page)
+	if page.Total != 1 || page.Entries[0].ProjectID != old || page.Entries[0].ActorType != "system" {
+		t.Errorf("PROJECT_DELETED entries = %+v, want one by the system for the purged project", page.Entries)
+	}
+
+	if n, err := PurgeTrash(30 * 24 * time.Hour); err != nil || n != 0 {
+		t.Errorf("second purge: %d projects, %v; want none", n, err)
+	}
+}
@@ -0,0 +1,249 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// checkUserPartner validates the partner link for a role: partner users must
+// name an existing partner, and no other role may have one
+func checkUserPartner(w http.ResponseWriter, role string, partnerID *string) bool {
+	if role != models.RolePartner {
+		if partnerID != nil {
+			respondError(w, http.StatusBadRequest, "partnerId is only allowed for the partner role")
+			return false
+		}
+		return true
+	}
+
+	if partnerID == nil || *partnerID == "" {
+		respondError(w, http.StatusBadRequest, "partnerId is required for the partner role")
+		return false
+	}
+	exists, err := partnerExists(*partnerID)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch partner")
+		return false
+	}
+	if !exists {
+		respondError(w, http.StatusBadRequest, "Partner not found: "+*partnerID)
+		return false
+	}
+	return true
+}
+
+func GetUsers(w http.ResponseWriter, r *http.Request) {
+	if !requireTokenAdmin(w, r) {
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT ` + userColumns + `
+		FROM users
+		ORDER BY email COLLATE NOCASE ASC
+	`)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch users")
+		return
+	}
+	defer rows.Close()
+
+	users := []models.User{}
+	for rows.Next() {
+		var u models.User
+		if err := u.ScanRows(rows); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan user")
+			return
+		}
+		users = append(users, u)
+	}
+
+	respondJSON(w, http.StatusOK, users)
+}
+
+func CreateUser(w http.ResponseWriter, r *http.Request) {
+	if !requireTokenAdmin(w, r) {
+		return
+	}
+
+	var req struct {
+		Email     string  `json:"email"`
+		Name      string  `json:"name"`
+		Password  string  `json:"password"`
+		Role      string  `json:"role"`
+		PartnerID *string `json:"partnerId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	u := models.User{
+		ID:        uuid.New().String(),
+		Email:     strings.TrimSpace(req.Email),
+		Name:      strings.TrimSpace(req.Name),
+		Role:      req.Role,
+		PartnerID: req.PartnerID,
+		CreatedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if u.Email == "" || !strings.Contains(u.Email, "@") {
+		respondError(w, http.StatusBadRequest, "email must be a valid email address")
+		return
+	}
+	if u.Name == "" {
+		u.Name = u.Email
+	}
+	if !models.ValidRole(u.Role) {
+		respondError(w, http.StatusBadRequest, "role must be 'admin', 'manager', 'viewer', or 'partner'")
+		return
+	}
+	if !checkUserPartner(w, u.Role, u.PartnerID) {
+		return
+	}
+
+	hash, err := models.HashPassword(req.Password)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	u.PasswordHash = hash
+
+	_, err = db.DB.Exec(`
+		INSERT INTO users (`+userColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, u.ID, u.Email, u.Name, u.PasswordHash, u.Role, u.CreatedAt, u.PartnerID)
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE") {
+			respondError(w, http.StatusConflict, "A user with this email already exists")
+			return
+		}
+		respondError(w, http.StatusInternalServerError, "Failed to create user")
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, u)
+}
+
+// UpdateUser applies a partial update to a user's name, role, partner link or
+// password. Admins cannot change their own role, so there is always an admin.
+func UpdateUser(w http.ResponseWriter, r *http.Request) {
+	if !requireTokenAdmin(w, r) {
+		return
+	}
+
+	id := mux.Vars(r)["userId"]
+
+	var u models.User
+	err := u.Scan(db.DB.QueryRow(`SELECT `+userColumns+` FROM users WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch user")
+		return
+	}
+
+	var req struct {
+		Name      *string         `json:"name"`
+		Role      *string         `json:"role"`
+		Password  *string         `json:"password"`
+		PartnerID json.RawMessage `json:"partnerId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			respondError(w, http.StatusBadRequest, "name must be a non-empty string")
+			return
+		}
+		u.Name = name
+	}
+	if req.Role != nil && *req.Role != u.Role {
+		if !models.ValidRole(*req.Role) {
+			respondError(w, http.StatusBadRequest, "role must be 'admin', 'manager', 'viewer', or 'partner'")
+			return
+		}
+		if id == CurrentUser(r).ID {
+			respondError(w, http.StatusUnprocessableEntity, "You cannot change your own role")
+			return
+		}
+		u.Role = *req.Role
+		if u.Role != models.RolePartner {
+			u.PartnerID = nil
+		}
+	}
+	if req.PartnerID != nil {
+		var partnerID *string
+		if err := json.Unmarshal(req.PartnerID, &partnerID); err != nil {
+			respondError(w, http.StatusBadRequest, "partnerId must be a string or null")
+			return
+		}
+		u.PartnerID = partnerID
+	}
+	if !checkUserPartner(w, u.Role, u.PartnerID) {
+		return
+	}
+
+	if req.Password != nil {
+		hash, err := models.HashPassword(*req.Password)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		u.PasswordHash = hash
+	}
+
+	_, err = db.DB.Exec(`
+		UPDATE users SET name = ?, role = ?, partner_id = ?, password_hash = ?
+		WHERE id = ?
+	`, u.Name, u.Role, u.PartnerID, u.PasswordHash, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to update user")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, u)
+}
+
+// DeleteUser removes a user along with their sessions and API tokens
+func DeleteUser(w http.ResponseWriter, r *http.Request) {
+	if !requireTokenAdmin(w, r) {
+		return
+	}
+
+	id := mux.Vars(r)["userId"]
+
+	if id == CurrentUser(r).ID {
+		respondError(w, http.StatusUnprocessableEntity, "You cannot delete yourself")
+		return
+	}
+
+	result, err := db.DB.Exec("DELETE FROM users WHERE id = ?", id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to delete user")
+		return
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		respondError(w, http.StatusNotFound, "User not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, map[string]string{"message": "User deleted"})
+}
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"project-tracker/db"
+)
+
+func TestManageUsers(t *testing.T) {
+	openTestDB(t)
+
+	var partner map[string]interface{}
+	decode(t, serve(CreatePartner, "POST", nil, `{"name": "Asha"}`), http.StatusCreated, &partner)
+	partnerID := partner["id"].(string)
+
+	for _, body := range []string{
+		`{"email": "not-an-email", "password": "long enough", "role": "viewer"}`,
+		`{"email": "v@example.com", "password": "long enough", "role": "owner"}`,
+		`{"email": "v@example.com", "password": "short", "role": "viewer"}`,
+		`{"email": "v@example.com", "password": "long enough", "role": "partner"}`,
+		`{"email": "v@example.com", "password": "long enough", "role": "viewer", "partnerId": "` + partnerID + `"}`,
+		`{"email": "v@example.com", "password": "long enough", "role": "partner", "partnerId": "missing"}`,
+	} {
+		if w := serve(CreateUser, "POST", nil, body); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", body, w.Code)
+		}
+	}
+
+	var user map[string]interface{}
+	body := `{"email": "asha@example.com", "password": "long enough", "role": "partner", "partnerId": "` + partnerID + `"}`
+	w := serve(CreateUser, "POST", nil, body)
+	decode(t, w, http.StatusCreated, &user)
+	if strings.Contains(w.Body.String(), "$2a$") {
+		t.Error("created user exposes the password hash")
+	}
+	decode(t, serve(CreateUser, "POST", nil, strings.Replace(body, "asha@", "ASHA@", 1)), http.StatusConflict, nil)
+	userVars := map[string]string{"userId": user["id"].(string)}
+
+	// A partner linked to a user cannot be deleted from under them
+	decode(t, serve(DeletePartner, "DELETE", map[string]string{"partnerId": partnerID}, ""), http.StatusConflict, nil)
+
+	// Leaving the partner role drops the partner link; returning needs one
+	var updated map[string]interface{}
+	decode(t, serve(UpdateUser, "PUT", userVars, `{"role": "viewer"}`), http.StatusOK, &updated)
+	if updated["role"] != "viewer" || updated["partnerId"] != nil {
+		t.Errorf("updated user = %v", updated)
+	}
+	decode(t, serve(UpdateUser, "PUT", userVars, `{"role": "partner"}`), http.StatusBadRequest, nil)
+
+	// Admins cannot lock themselves out
+	db.DB.Exec(`INSERT INTO users (id, email, name, password_hash, role, created_at) VALUES (?, ?, ?, 'x', 'admin', '2026-01-01T00:00:00Z')`,
+		testAdmin.ID, testAdmin.Email, testAdmin.Name)
+	self := map[string]string{"userId": testAdmin.ID}
+	decode(t, serve(UpdateUser, "PUT", self, `{"role": "viewer"}`), http.StatusUnprocessableEntity, nil)
+	decode(t, serve(DeleteUser, "DELETE", self, ""), http.StatusUnprocessableEntity, nil)
+
+	decode(t, serve(DeleteUser, "DELETE", userVars, ""), http.StatusOK, nil)
+	decode(t, serve(DeleteUser, "DELETE", userVars, ""), http.StatusNotFound, nil)
+}
@@ -0,0 +1,374 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"project-tracker/db"
+	"project-tracker/models"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+const projectVersionColumns = `version, snapshot, created_at, actor_id, actor_type`
+
+// ProjectVersion is a snapshot of a project as it was after one change
+type ProjectVersion struct {
+	Version   int64          `json:"version"`
+	CreatedAt string         `json:"createdAt"`
+	ActorID   *string        `json:"actorId,omitempty"`
+	ActorType string         `json:"actorType"`
+	Project   models.Project `json:"project"`
+}
+
+// VersionDiff lists the fields that differ between two versions of a project
+type VersionDiff struct {
+	From    int64           `json:"from"`
+	To      int64           `json:"to"`
+	Changes []VersionChange `json:"changes"`
+}
+
+// VersionChange is one field's value in each of the compared versions
+type VersionChange struct {
+	Field string  `json:"field"`
+	From  *string `json:"from"`
+	To    *string `json:"to"`
+}
+
+// scanProjectVersion reads a project_versions row selected with
+// projectVersionColumns
+func scanProjectVersion(scan func(dest ...interface{}) error) (ProjectVersion, error) {
+	var v ProjectVersion
+	var snapshot string
+	if err := scan(&v.Version, &snapshot, &v.CreatedAt, &v.ActorID, &v.ActorType); err != nil {
+		return v, err
+	}
+	// Snapshots taken before GST was tracked lack a rate and read as 0%, the
+	// rate migration 018 gave those projects
+	if err := json.Unmarshal([]byte(snapshot), &v.Project); err != nil {
+		return v, err
+	}
+	// Computed fields are worked out again rather than trusted from the snapshot
+	v.Project.Status = v.Project.ComputeStatus()
+	v.Project.LinksRedacted = false
+	return v, nil
+}
+
+// presentVersion prepares a stored version of current for the current user
+// like a live project, and hides who made it from roles that cannot see the
+// audit trail
+func presentVersion(r *http.Request, v *ProjectVersion, current *models.Project) {
+	presentProjectVersion(r, &v.Project, current)
+	if !models.CanSeeInternal(CurrentUser(r).Role) {
+		v.ActorID = nil
+	}
+}
+
+// fetchProjectVersion returns one version of a project, or sql.ErrNoRows
+func fetchProjectVersion(q querier, projectID string, version int64) (ProjectVersion, error) {
+	row := q.QueryRow(`
+		SELECT `+projectVersionColumns+`
+		FROM project_versions
+		WHERE project_id = ? AND version = ?
+	`, projectID, version)
+	return scanProjectVersion(row.Scan)
+}
+
+// parseVersion reads a version number, writing a 400 and returning ok=false
+// when it is not a positive integer
+func parseVersion(w http.ResponseWriter, name, value string) (int64, bool) {
+	version, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || version <= 0 {
+		respondError(w, http.StatusBadRequest, name+" must be a positive integer")
+		return 0, false
+	}
+	return version, true
+}
+
+// activeProject returns the project as it is now, writing a 404 and
+// returning ok=false unless it exists outside the trash
+func activeProject(w http.ResponseWriter, id string) (*models.Project, bool) {
+	var p models.Project
+	err := p.Scan(db.DB.QueryRow(`SELECT `+projectColumns+` FROM projects WHERE id = ? AND deletedAt IS NULL`, id))
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return nil, false
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return nil, false
+	}
+	return &p, true
+}
+
+// GetProjectVersions lists the stored versions of a project, newest first
+func GetProjectVersions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	current, ok := activeProject(w, id)
+	if !ok {
+		return
+	}
+
+	rows, err := db.DB.Query(`
+		SELECT `+projectVersionColumns+`
+		FROM project_versions
+		WHERE project_id = ?
+		ORDER BY version DESC
+	`, id)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project versions")
+		return
+	}
+	defer rows.Close()
+
+	versions := []ProjectVersion{}
+	for rows.Next() {
+		v, err := scanProjectVersion(rows.Scan)
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to scan project version")
+			return
+		}
+		presentVersion(r, &v, current)
+		versions = append(versions, v)
+	}
+
+	respondJSON(w, http.StatusOK, versions)
+}
+
+// GetProjectVersion returns a single stored version of a project
+func GetProjectVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	version, ok := parseVersion(w, "version", vars["version"])
+	if !ok {
+		return
+	}
+	current, ok := activeProject(w, id)
+	if !ok {
+		return
+	}
+
+	v, err := fetchProjectVersion(db.DB, id, version)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Version not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project version")
+		return
+	}
+
+	presentVersion(r, &v, current)
+	respondJSON(w, http.StatusOK, v)
+}
+
+// DiffProjectVersions compares the versions given by the from and to query
+// parameters, field by field. Fields the caller may not see are left out.
+func DiffProjectVersions(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	params := r.URL.Query()
+
+	from, ok := parseVersion(w, "from", params.Get("from"))
+	if !ok {
+		return
+	}
+	to, ok := parseVersion(w, "to", params.Get("to"))
+	if !ok {
+		return
+	}
+	current, ok := activeProject(w, id)
+	if !ok {
+		return
+	}
+
+	snapshots := [2]models.Project{}
+	for i, version := range []int64{from, to} {
+		v, err := fetchProjectVersion(db.DB, id, version)
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusNotFound, "Version "+strconv.FormatInt(version, 10)+" not found")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch project version")
+			return
+		}
+		presentProjectVersion(r, &v.Project, current)
+		snapshots[i] = v.Project
+	}
+
+	diff := VersionDiff{From: from, To: to, Changes: []VersionChange{}}
+	for _, change := range diffProjectFields(snapshots[0], snapshots[1]) {
+		diff.Changes = append(diff.Changes, VersionChange{Field: change.Field, From: change.OldValue, To: change.NewValue})
+	}
+
+	// Versions also move when the backend changes a project, such as a
+	// payment updating its totals, so those fields are compared too
+	oldValues, newValues := projectFieldValues(snapshots[0]), projectFieldValues(snapshots[1])
+	for _, field := range maintainedProjectFields {
+		oldVal, newVal := oldValues[field], newValues[field]
+		if !sameAuditValue(oldVal, newVal) {
+			diff.Changes = append(diff.Changes, VersionChange{Field: field, From: oldVal, To: newVal})
+		}
+	}
+	sort.Slice(diff.Changes, func(i, j int) bool { return diff.Changes[i].Field < diff.Changes[j].Field })
+
+	respondJSON(w, http.StatusOK, diff)
+}
+
+// maintainedProjectFields are the fields the backend keeps up to date itself,
+// which a version diff reports alongside the updatable ones
+var maintainedProjectFields = []string{"totalReceived", "totalTds", "linksReleasedAt", "deletedAt"}
+
+// RestoreProjectVersion puts a project's fields back to how they were at an
+// earlier version. The restore is a change like any other: it passes the
+// status rules, is audited field by field and is stored as a new version.
+// totalReceived stays with the payment ledger and an escrow release is never
+// undone. Like a PUT, it must carry an If-Match header naming the current
+// version.
+func RestoreProjectVersion(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id := vars["id"]
+
+	version, ok := parseVersion(w, "version", vars["version"])
+	if !ok {
+		return
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		respondError(w, http.StatusPreconditionRequired, "If-Match header with the project's ETag is required")
+		return
+	}
+
+	tx, err := db.DB.Begin()
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to restore version")
+		return
+	}
+	defer tx.Rollback()
+
+	var oldProject models.Project
+	err = oldProject.Scan(tx.QueryRow(`
+		SELECT `+projectColumns+`
+		FROM projects
+		WHERE id = ? AND deletedAt IS NULL
+	`, id))
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Project not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project")
+		return
+	}
+
+	if !etagMatches(ifMatch, oldProject.Version) {
+		presentProject(r, &oldProject)
+		setProjectETag(w, &oldProject)
+		respondJSON(w, http.StatusPreconditionFailed, map[string]interface{}{
+			"error":   "Project has been changed since it was loaded",
+			"project": oldProject,
+		})
+		return
+	}
+
+	v, err := fetchProjectVersion(tx, id, version)
+	if err == sql.ErrNoRows {
+		respondError(w, http.StatusNotFound, "Version not found")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch project version")
+		return
+	}
+	target := v.Project
+
+	// A linked client may have been renamed or removed since
+	if target.ClientID != nil {
+		name, err := clientName(tx, *target.ClientID)
+		if err == sql.ErrNoRows {
+			respondError(w, http.StatusUnprocessableEntity, "The client linked in this version no longer exists")
+			return
+		}
+		if err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch client")
+			return
+		}
+		target.ClientName = &name
+	}
+
+	if target.Currency != oldProject.Currency {
+		var hasPayments bool
+		if err := tx.QueryRow(`SELECT EXISTS(SELECT 1 FROM payments WHERE project_id = ?)`, id).Scan(&hasPayments); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to fetch payments")
+			return
+		}
+		if hasPayments {
+			respondError(w, http.StatusUnprocessableEntity, "Cannot change currency after payments have been recorded")
+			return
+		}
+	}
+
+	_, err = tx.Exec(`
+		UPDATE projects
+		SET name = ?, clientName = ?, clientId = ?, description = ?, type = ?, startDate = ?,
+			deadline = ?, completedAt = ?, deliveredAt = ?, totalAmount = ?, advanceReceived = ?,
+			currency = ?, completionVideoLink = ?, completionNotes = ?, repoLink = ?, liveLink = ?,
+			deliveryNotes = ?, techStack = ?, deliverables = ?, internalNotes = ?, taxInclusive = ?, gstRate = ?
+		WHERE id = ?
+	`,
+		target.Name, target.ClientName, target.ClientID, target.Description, target.Type, target.StartDate,
+		target.Deadline, target.CompletedAt, target.DeliveredAt, target.TotalAmount, target.AdvanceReceived,
+		target.Currency, target.CompletionVideoLink, target.CompletionNotes, target.RepoLink, target.LiveLink,
+		target.DeliveryNotes, target.TechStack, target.Deliverables, target.InternalNotes, target.TaxInclusive, target.GSTRate,
+		id,
+	)
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to restore version")
+		return
+	}
+
+	var p models.Project
+	if err := p.Scan(tx.QueryRow(`SELECT `+projectColumns+` FROM projects WHERE id = ?`, id)); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to fetch restored project")
+		return
+	}
+
+	changes := diffProjectFields(oldProject, p)
+	if len(changes) == 0 {
+		respondError(w, http.StatusConflict, "The project already matches this version")
+		return
+	}
+
+	if err := models.ValidateTransition(&oldProject, &p); err != nil {
+		respondError(w, http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+
+	ts := time.Now().UTC().Format(time.RFC3339)
+	for _, change := range changes {
+		if err := db.InsertAuditLog(tx, auditActor(r), uuid.New().String(), id, "PROJECT_VERSION_RESTORED", &change.Field, change.OldValue, change.NewValue, ts); err != nil {
+			respondError(w, http.StatusInternalServerError, "Failed to write audit log")
+			return
+		}
+	}
+	if err := db.InsertProjectVersion(tx, auditActor(r), &p, ts); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to record project version")
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to restore version")
+		return
+	}
+
+	presentProject(r, &p)
+	setProjectETag(w, &p)
+	respondJSON(w, http.StatusOK, p)
+}
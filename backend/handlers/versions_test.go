@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"project-tracker/db"
+	"project-tracker/models"
+)
+
+func TestProjectVersionHistory(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"name": "Site", "internalNotes": "Margin is thin"}`)
+	id := project["id"].(string)
+	vars := map[string]string{"id": id}
+	decode(t, updateProject(testAdmin, vars, `{"name": "Shop"}`), http.StatusOK, nil)
+	decode(t, updateProject(testAdmin, vars, `{"internalNotes": "Margin is fine", "deadline": "2027-03-31"}`), http.StatusOK, nil)
+
+	var versions []ProjectVersion
+	decode(t, serve(GetProjectVersions, "GET", vars, ""), http.StatusOK, &versions)
+	if len(versions) != 3 || versions[0].Version != 3 || versions[2].Version != 1 {
+		t.Fatalf("versions = %+v, want 3, 2, 1", versions)
+	}
+	if versions[2].Project.Name != "Site" || versions[1].Project.Name != "Shop" || show(versions[0].ActorID) != testAdmin.ID {
+		t.Errorf("versions hold %q, %q by %s", versions[2].Project.Name, versions[1].Project.Name, show(versions[0].ActorID))
+	}
+
+	var v ProjectVersion
+	decode(t, serve(GetProjectVersion, "GET", map[string]string{"id": id, "version": "1"}, ""), http.StatusOK, &v)
+	if v.Version != 1 || v.Project.Name != "Site" || v.Project.Status != models.StatusNotStarted {
+		t.Errorf("version 1 = %+v", v)
+	}
+	decode(t, serve(GetProjectVersion, "GET", map[string]string{"id": id, "version": "9"}, ""), http.StatusNotFound, nil)
+
+	var diff VersionDiff
+	decode(t, serveGET(DiffProjectVersions, "/?from=1&to=3", vars), http.StatusOK, &diff)
+	fields := []string{}
+	for _, c := range diff.Changes {
+		fields = append(fields, c.Field+": "+show(c.From)+" -> "+show(c.To))
+	}
+	want := []string{"deadline: 2026-12-31 -> 2027-03-31", "internalNotes: Margin is thin -> Margin is fine", "name: Site -> Shop"}
+	if len(fields) != len(want) {
+		t.Fatalf("diff = %q, want %q", fields, want)
+	}
+	for i := range want {
+		if fields[i] != want[i] {
+			t.Errorf("diff = %q, want %q", fields, want)
+			break
+		}
+	}
+
+	for _, target := range []string{"/?from=1", "/?from=0&to=2", "/?from=a&to=2"} {
+		decode(t, serveGET(DiffProjectVersions, target, vars), http.StatusBadRequest, nil)
+	}
+	decode(t, serveGET(DiffProjectVersions, "/?from=1&to=9", vars), http.StatusNotFound, nil)
+
+	// A trashed project's history is out of reach until it is restored
+	decode(t, serve(DeleteProject, "DELETE", vars, ""), http.StatusOK, nil)
+	decode(t, serve(GetProjectVersions, "GET", vars, ""), http.StatusNotFound, nil)
+}
+
+func TestVersionsHideInternalFieldsByRole(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"name": "Site", "internalNotes": "Margin is thin"}`)
+	vars := map[string]string{"id": project["id"].(string)}
+	decode(t, updateProject(testAdmin, vars, `{"name": "Shop", "internalNotes": "Margin is fine"}`), http.StatusOK, nil)
+
+	for _, user := range []*models.User{testViewer, testPartnerUser("p1")} {
+		var versions []ProjectVersion
+		decode(t, serveAs(user, GetProjectVersions, "GET", "/", vars, ""), http.StatusOK, &versions)
+		for _, v := range versions {
+			if v.Project.InternalNotes != nil || v.ActorID != nil {
+				t.Errorf("%s sees version %d with notes %s by %s", user.Role, v.Version, show(v.Project.InternalNotes), show(v.ActorID))
+			}
+		}
+
+		var v ProjectVersion
+		decode(t, serveAs(user, GetProjectVersion, "GET", "/", map[string]string{"id": vars["id"], "version": "1"}, ""), http.StatusOK, &v)
+		if v.Project.InternalNotes != nil || v.ActorID != nil {
+			t.Errorf("%s sees version 1 with notes %s by %s", user.Role, show(v.Project.InternalNotes), show(v.ActorID))
+		}
+
+		var diff VersionDiff
+		decode(t, serveAs(user, DiffProjectVersions, "GET", "/?from=1&to=2", vars, ""), http.StatusOK, &diff)
+		if len(diff.Changes) != 1 || diff.Changes[0].Field != "name" {
+			t.Errorf("%s diff = %+v, want only the name change", user.Role, diff.Changes)
+		}
+	}
+
+	var diff VersionDiff
+	decode(t, serveAs(testManager, DiffProjectVersions, "GET", "/?from=1&to=2", vars, ""), http.StatusOK, &diff)
+	if len(diff.Changes) != 2 {
+		t.Errorf("manager diff = %+v, want the name and internal notes changes", diff.Changes)
+	}
+}
+
+func TestRestoreProjectVersion(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"name": "Site", "totalAmount": 1000}`)
+	id := project["id"].(string)
+	vars := map[string]string{"id": id}
+	decode(t, updateProject(testAdmin, vars, `{"name": "Shop", "totalAmount": 2000}`), http.StatusOK, nil)
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 500}`), http.StatusCreated, nil)
+
+	restore := func(version, ifMatch string) *httptest.ResponseRecorder {
+		r := newRequest(testAdmin, "POST", "/", map[string]string{"id": id, "version": version}, "")
+		if ifMatch != "" {
+			r.Header.Set("If-Match", ifMatch)
+		}
+		w := httptest.NewRecorder()
+		RestoreProjectVersion(w, r)
+		return w
+	}
+
+	current := getProject(t, id)
+	decode(t, restore("1", ""), http.StatusPreconditionRequired, nil)
+	decode(t, restore("1", `"1"`), http.StatusPreconditionFailed, nil)
+	decode(t, restore("9", "*"), http.StatusNotFound, nil)
+
+	// The fields go back; the ledger-maintained total stays
+	var restored map[string]interface{}
+	w := restore("1", `"`+itoa(current["version"])+`"`)
+	decode(t, w, http.StatusOK, &restored)
+	if restored["name"] != "Site" || restored["totalAmount"] != 1000.0 || restored["totalReceived"] != 500.0 {
+		t.Errorf("restored project = %v", restored)
+	}
+	if w.Header().Get("ETag") != `"`+itoa(restored["version"])+`"` {
+		t.Errorf("restore ETag %s, version %v", w.Header().Get("ETag"), restored["version"])
+	}
+
+	decode(t, restore("1", "*"), http.StatusConflict, nil)
+
+	var page AuditPage
+	decode(t, serveGET(GetProjectAuditLogs, "/?action=PROJECT_VERSION_RESTORED", vars), http.StatusOK, &page)
+	if page.Total != 2 {
+		t.Errorf("%d restore audit entries, want name and totalAmount", page.Total)
+	}
+
+	var versions int
+	db.DB.QueryRow(`SELECT COUNT(*) FROM project_versions WHERE project_id = ? AND version = ?`, id, restored["version"]).Scan(&versions)
+	if versions != 1 {
+		t.Error("restore was not stored as a new version")
+	}
+}
+
+// itoa renders a JSON number as an integer string
+func itoa(n interface{}) string {
+	return strconv.FormatInt(int64(n.(float64)), 10)
+}
+
+func TestEveryVersionIsSnapshotted(t *testing.T) {
+	openTestDB(t)
+	var client map[string]interface{}
+	decode(t, serve(CreateClient, "POST", nil, `{"name": "Acme"}`), http.StatusCreated, &client)
+	clientVars := map[string]string{"clientId": client["id"].(string)}
+	project := createProject(t, `{"clientId": "`+client["id"].(string)+`"}`)
+	id := project["id"].(string)
+	vars := map[string]string{"id": id}
+
+	var payment map[string]interface{}
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 400}`), http.StatusCreated, &payment)
+	decode(t, serve(DeletePayment, "DELETE", map[string]string{"id": id, "paymentId": payment["id"].(string)}, ""), http.StatusOK, nil)
+	decode(t, serve(UpdateClient, "PUT", clientVars, `{"name": "Acme Ltd"}`), http.StatusOK, nil)
+	decode(t, serve(DeleteProject, "DELETE", vars, ""), http.StatusOK, nil)
+	decode(t, serve(RestoreProject, "POST", vars, ""), http.StatusOK, nil)
+
+	// Versions run without gaps up to the project's current one
+	current := getProject(t, id)["version"].(float64)
+	var versions []ProjectVersion
+	decode(t, serve(GetProjectVersions, "GET", vars, ""), http.StatusOK, &versions)
+	if len(versions) != 6 || float64(versions[0].Version) != current {
+		t.Fatalf("%d versions up to %d, want 6 up to %v", len(versions), versions[0].Version, current)
+	}
+
+	for _, tc := range []struct {
+		from, to string
+		want     []string
+	}{
+		{"1", "2", []string{"totalReceived: 0 -> 400"}},
+		{"2", "3", []string{"totalReceived: 400 -> 0"}},
+		{"3", "4", []string{"clientName: Acme -> Acme Ltd"}},
+		{"4", "5", []string{"deletedAt"}},
+		{"4", "6", []string{}},
+	} {
+		var diff VersionDiff
+		decode(t, serveGET(DiffProjectVersions, "/?from="+tc.from+"&to="+tc.to, vars), http.StatusOK, &diff)
+		got := []string{}
+		for _, c := range diff.Changes {
+			if c.Field == "deletedAt" {
+				got = append(got, c.Field)
+				continue
+			}
+			got = append(got, c.Field+": "+show(c.From)+" -> "+show(c.To))
+		}
+		if strings.Join(got, "; ") != strings.Join(tc.want, "; ") {
+			t.Errorf("diff %s..%s = %q, want %q", tc.from, tc.to, got, tc.want)
+		}
+	}
+}
+
+func TestVersionLinksFollowTheCurrentProject(t *testing.T) {
+	openTestDB(t)
+	project := createProject(t, `{"totalAmount": 1000, "repoLink": "https://git.example.com/site"}`)
+	id := project["id"].(string)
+	vars := map[string]string{"id": id}
+
+	var payment map[string]interface{}
+	decode(t, serve(CreatePayment, "POST", vars, `{"amount": 1000}`), http.StatusCreated, &payment)
+
+	// Paid up, every version shows the links
+	var versions []ProjectVersion
+	decode(t, serve(GetProjectVersions, "GET", vars, ""), http.StatusOK, &versions)
+	for _, v := range versions {
+		if show(v.Project.RepoLink) != "https://git.example.com/site" || v.Project.LinksRedacted {
+			t.Errorf("paid up: version %d link %s", v.Version, show(v.Project.RepoLink))
+		}
+	}
+
+	// Once the payment is gone, not even the version saved when it was paid up does
+	decode(t, serve(DeletePayment, "DELETE", map[string]string{"id": id, "paymentId": payment["id"].(string)}, ""), http.StatusOK, nil)
+	var redacted []ProjectVersion
+	decode(t, serve(GetProjectVersions, "GET", vars, ""), http.StatusOK, &redacted)
+	for _, v := range redacted {
+		if v.Project.RepoLink != nil || !v.Project.LinksRedacted {
+			t.Errorf("with dues: version %d link %s", v.Version, show(v.Project.RepoLink))
+		}
+	}
+	var paid ProjectVersion
+	decode(t, serve(GetProjectVersion, "GET", map[string]string{"id": id, "version": "2"}, ""), http.StatusOK, &paid)
+	if paid.Project.RepoLink != nil {
+		t.Errorf("paid-up version 2 shows its link %s", show(paid.Project.RepoLink))
+	}
+}
@@ -8,11 +8,14 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"syscall"
 	"time"
 
 	"project-tracker/db"
 	"project-tracker/handlers"
+	"project-tracker/models"
 
 	"github.com/gorilla/mux"
 )
@@ -50,21 +53,167 @@ func main() {
 
 	log.Println("Using database at:", dbPath)
 
+	// Maintenance commands run against the database and exit
+	if len(os.Args) > 1 {
+		if err := runCommand(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	// Initialize database
 	if err := db.InitDB(dbPath); err != nil {
 		log.Fatal("Failed to initialize database:", err)
 	}
 
+	// Currency that reports convert into; exchange rates are stored against it
+	baseCurrency := getEnv("BASE_CURRENCY", models.DefaultCurrency)
+	if !models.ValidCurrency(baseCurrency) {
+		log.Fatalf("unsupported BASE_CURRENCY %q", baseCurrency)
+	}
+	handlers.BaseCurrency = baseCurrency
+
+	// Set COOKIE_SECURE=true when serving over HTTPS
+	handlers.SecureCookies = getEnv("COOKIE_SECURE", "false") == "true"
+
+	// Set CLIENT_IP_HEADER (e.g. X-Forwarded-For) when behind a reverse proxy,
+	// so audit entries record the client's address rather than the proxy's
+	handlers.ClientIPHeader = getEnv("CLIENT_IP_HEADER", "")
+
+	// Invoices are numbered INVOICE_PREFIX/2025-26/0001 and issued by the
+	// business described by the BUSINESS_* variables. INVOICE_TEMPLATE points at
+	// a branded copy of templates/invoice.html.
+	handlers.InvoicePrefix = getEnv("INVOICE_PREFIX", "INV")
+	handlers.Issuer = handlers.InvoiceIssuer{
+		Name:    getEnv("BUSINESS_NAME", ""),
+		Address: strings.ReplaceAll(getEnv("BUSINESS_ADDRESS", ""), `\n`, "\n"),
+		Email:   getEnv("BUSINESS_EMAIL", ""),
+		Phone:   getEnv("BUSINESS_PHONE", ""),
+	}
+
+	// Setting BUSINESS_GSTIN makes invoices GST tax invoices, supplied from
+	// the state the GSTIN is registered in. Items without an HSN/SAC code get
+	// DEFAULT_SAC.
+	if gstin := getEnv("BUSINESS_GSTIN", ""); gstin != "" {
+		normalized, ok := models.NormalizeGSTIN(gstin)
+		if !ok {
+			log.Fatalf("BUSINESS_GSTIN %q is not a valid GSTIN", gstin)
+		}
+		handlers.Issuer.GSTIN = normalized
+		handlers.Issuer.State = normalized[:2]
+	}
+	handlers.DefaultSAC = getEnv("DEFAULT_SAC", "")
+	if path := getEnv("INVOICE_TEMPLATE", ""); path != "" {
+		if err := handlers.LoadInvoiceTemplate(path); err != nil {
+			log.Fatalf("failed to load INVOICE_TEMPLATE: %v", err)
+		}
+	}
+
+	// Trashed projects are purged after TRASH_RETENTION_DAYS (default 30);
+	// 0 keeps them until they are restored
+	retentionDays, err := strconv.Atoi(getEnv("TRASH_RETENTION_DAYS", "30"))
+	if err != nil || retentionDays < 0 {
+		log.Fatalf("TRASH_RETENTION_DAYS must be a non-negative number of days")
+	}
+	stopPurge := make(chan struct{})
+	if retentionDays > 0 {
+		go handlers.RunTrashPurge(time.Duration(retentionDays)*24*time.Hour, time.Hour, stopPurge)
+	}
+
 	// Setup routes
 	r := mux.NewRouter()
 
+	// Login is the only API route reachable without a session
+	r.HandleFunc("/api/auth/login", handlers.Login).Methods("POST")
+
 	// API routes
 	api := r.PathPrefix("/api").Subrouter()
+	api.Use(handlers.RequireAuth)
+	api.HandleFunc("/auth/logout", handlers.Logout).Methods("POST")
+	api.HandleFunc("/auth/me", handlers.GetCurrentUser).Methods("GET")
+	api.HandleFunc("/tokens", handlers.GetAPITokens).Methods("GET")
+	api.HandleFunc("/tokens", handlers.CreateAPIToken).Methods("POST")
+	api.HandleFunc("/tokens/{tokenId}", handlers.RevokeAPIToken).Methods("DELETE")
+
+	// User management
+	api.HandleFunc("/users", handlers.RequireRole(handlers.Admins, handlers.GetUsers)).Methods("GET")
+	api.HandleFunc("/users", handlers.RequireRole(handlers.Admins, handlers.CreateUser)).Methods("POST")
+	api.HandleFunc("/users/{userId}", handlers.RequireRole(handlers.Admins, handlers.UpdateUser)).Methods("PUT")
+	api.HandleFunc("/users/{userId}", handlers.RequireRole(handlers.Admins, handlers.DeleteUser)).Methods("DELETE")
+
+	// Project routes; writes are limited by role, see handlers/rbac.go
 	api.HandleFunc("/projects", handlers.GetProjects).Methods("GET")
 	api.HandleFunc("/projects/{id}", handlers.GetProject).Methods("GET")
-	api.HandleFunc("/projects", handlers.CreateProject).Methods("POST")
-	api.HandleFunc("/projects/{id}", handlers.UpdateProject).Methods("PUT")
-	api.HandleFunc("/projects/{id}", handlers.DeleteProject).Methods("DELETE")
+	api.HandleFunc("/search", handlers.SearchProjects).Methods("GET")
+	api.HandleFunc("/projects", handlers.RequireRole(handlers.Staff, handlers.CreateProject)).Methods("POST")
+	api.HandleFunc("/projects/{id}", handlers.RequireRole(handlers.Editors, handlers.UpdateProject)).Methods("PUT")
+	api.HandleFunc("/projects/{id}", handlers.RequireRole(handlers.Staff, handlers.DeleteProject)).Methods("DELETE")
+	api.HandleFunc("/projects/{id}/restore", handlers.RequireRole(handlers.Staff, handlers.RestoreProject)).Methods("POST")
+	api.HandleFunc("/trash", handlers.RequireRole(handlers.Staff, handlers.GetTrash)).Methods("GET")
+	api.HandleFunc("/projects/{id}/release", handlers.RequireRole(handlers.Staff, handlers.ReleaseLinks)).Methods("POST")
+	api.HandleFunc("/projects/{id}/audit", handlers.RequireRole(handlers.Staff, handlers.GetProjectAuditLogs)).Methods("GET")
+	api.HandleFunc("/projects/{id}/invoices", handlers.RequireRole(handlers.Staff, handlers.GetProjectInvoices)).Methods("GET")
+	api.HandleFunc("/projects/{id}/invoices", handlers.RequireRole(handlers.Staff, handlers.CreateInvoice)).Methods("POST")
+	api.HandleFunc("/projects/{id}/versions", handlers.GetProjectVersions).Methods("GET")
+	api.HandleFunc("/projects/{id}/versions/diff", handlers.DiffProjectVersions).Methods("GET")
+	api.HandleFunc("/projects/{id}/versions/{version:[0-9]+}", handlers.GetProjectVersion).Methods("GET")
+	api.HandleFunc("/projects/{id}/versions/{version:[0-9]+}/restore", handlers.RequireRole(handlers.Staff, handlers.RestoreProjectVersion)).Methods("POST")
+
+	// Payment ledger routes
+	api.HandleFunc("/projects/{id}/payments", handlers.GetPayments).Methods("GET")
+	api.HandleFunc("/projects/{id}/payments", handlers.RequireRole(handlers.Staff, handlers.CreatePayment)).Methods("POST")
+	api.HandleFunc("/projects/{id}/payments/{paymentId}", handlers.GetPayment).Methods("GET")
+	api.HandleFunc("/projects/{id}/payments/{paymentId}", handlers.RequireRole(handlers.Staff, handlers.UpdatePayment)).Methods("PUT")
+	api.HandleFunc("/projects/{id}/payments/{paymentId}", handlers.RequireRole(handlers.Staff, handlers.DeletePayment)).Methods("DELETE")
+
+	// Audit log routes
+	api.HandleFunc("/audit", handlers.RequireRole(handlers.Staff, handlers.GetAuditLogs)).Methods("GET")
+	api.HandleFunc("/audit/verify", handlers.RequireRole(handlers.Staff, handlers.VerifyAuditLog)).Methods("GET")
+
+	// Invoice routes; the rendered routes come first, as {invoiceId} would match them
+	api.HandleFunc("/invoices", handlers.RequireRole(handlers.Staff, handlers.GetInvoices)).Methods("GET")
+	api.HandleFunc("/invoices/{invoiceId:[^/.]+}.html", handlers.RequireRole(handlers.Staff, handlers.RenderInvoiceHTML)).Methods("GET")
+	api.HandleFunc("/invoices/{invoiceId}", handlers.RequireRole(handlers.Staff, handlers.GetInvoice)).Methods("GET")
+	api.HandleFunc("/invoices/{invoiceId}", handlers.RequireRole(handlers.Staff, handlers.UpdateInvoice)).Methods("PUT")
+	api.HandleFunc("/invoices/{invoiceId}", handlers.RequireRole(handlers.Staff, handlers.DeleteInvoice)).Methods("DELETE")
+	api.HandleFunc("/invoices/{invoiceId}/send", handlers.RequireRole(handlers.Staff, handlers.SendInvoice)).Methods("POST")
+	api.HandleFunc("/invoices/{invoiceId}/paid", handlers.RequireRole(handlers.Staff, handlers.MarkInvoicePaid)).Methods("POST")
+	api.HandleFunc("/invoices/{invoiceId}/void", handlers.RequireRole(handlers.Staff, handlers.VoidInvoice)).Methods("POST")
+
+	// Client routes
+	api.HandleFunc("/clients", handlers.GetClients).Methods("GET")
+	api.HandleFunc("/clients", handlers.RequireRole(handlers.Staff, handlers.CreateClient)).Methods("POST")
+	api.HandleFunc("/clients/{clientId}", handlers.GetClient).Methods("GET")
+	api.HandleFunc("/clients/{clientId}", handlers.RequireRole(handlers.Staff, handlers.UpdateClient)).Methods("PUT")
+	api.HandleFunc("/clients/{clientId}", handlers.RequireRole(handlers.Staff, handlers.DeleteClient)).Methods("DELETE")
+	api.HandleFunc("/clients/{clientId}/projects", handlers.GetClientProjects).Methods("GET")
+
+	// Partner routes
+	api.HandleFunc("/partners", handlers.GetPartners).Methods("GET")
+	api.HandleFunc("/partners", handlers.RequireRole(handlers.Staff, handlers.CreatePartner)).Methods("POST")
+	api.HandleFunc("/partners/{partnerId}", handlers.GetPartner).Methods("GET")
+	api.HandleFunc("/partners/{partnerId}", handlers.RequireRole(handlers.Staff, handlers.UpdatePartner)).Methods("PUT")
+	api.HandleFunc("/partners/{partnerId}", handlers.RequireRole(handlers.Staff, handlers.DeletePartner)).Methods("DELETE")
+	api.HandleFunc("/partners/{partnerId}/payouts", handlers.GetPartnerPayouts).Methods("GET")
+	api.HandleFunc("/projects/{id}/payouts", handlers.GetProjectPayouts).Methods("GET")
+	api.HandleFunc("/projects/{id}/payouts", handlers.RequireRole(handlers.Staff, handlers.CreatePayout)).Methods("POST")
+	api.HandleFunc("/projects/{id}/payouts/{payoutId}", handlers.RequireRole(handlers.Staff, handlers.UpdatePayout)).Methods("PUT")
+	api.HandleFunc("/projects/{id}/payouts/{payoutId}", handlers.RequireRole(handlers.Staff, handlers.DeletePayout)).Methods("DELETE")
+
+	// Currency routes
+	api.HandleFunc("/exchange-rates", handlers.GetExchangeRates).Methods("GET")
+	api.HandleFunc("/exchange-rates", handlers.RequireRole(handlers.Staff, handlers.CreateExchangeRate)).Methods("POST")
+	api.HandleFunc("/exchange-rates/{rateId}", handlers.RequireRole(handlers.Staff, handlers.UpdateExchangeRate)).Methods("PUT")
+	api.HandleFunc("/exchange-rates/{rateId}", handlers.RequireRole(handlers.Staff, handlers.DeleteExchangeRate)).Methods("DELETE")
+	api.HandleFunc("/reports/summary", handlers.GetSummaryReport).Methods("GET")
+	api.HandleFunc("/reports/gstr1.csv", handlers.RequireRole(handlers.Staff, handlers.GetGSTR1Report)).Methods("GET")
+	api.HandleFunc("/reports/tds", handlers.RequireRole(handlers.Staff, handlers.GetTDSReport)).Methods("GET")
+
+	// Export routes; like the audit trail, they are for staff only
+	api.HandleFunc("/export/projects.csv", handlers.RequireRole(handlers.Staff, handlers.ExportProjectsCSV)).Methods("GET")
+	api.HandleFunc("/export/projects.json", handlers.RequireRole(handlers.Staff, handlers.ExportProjectsJSON)).Methods("GET")
+	api.HandleFunc("/export/payments.csv", handlers.RequireRole(handlers.Staff, handlers.ExportPaymentsCSV)).Methods("GET")
+	api.HandleFunc("/export/audit.csv", handlers.RequireRole(handlers.Staff, handlers.ExportAuditCSV)).Methods("GET")
 
 	// Serve frontend static files
 	frontendDir := getEnv("FRONTEND_DIR", "../frontend/dist")
@@ -91,6 +240,7 @@ func main() {
 
 	<-quit
 	log.Println("Shutdown signal received")
+	close(stopPurge)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
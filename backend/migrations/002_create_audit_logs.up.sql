@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS audit_logs (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL,
+	action TEXT NOT NULL,
+	field_name TEXT,
+	old_value TEXT,
+	new_value TEXT,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_project_id ON audit_logs(project_id);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_created_at ON audit_logs(created_at);
@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS payments (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	amount REAL NOT NULL,
+	paid_at TEXT NOT NULL,
+	method TEXT,
+	reference TEXT,
+	note TEXT,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_payments_project_id ON payments(project_id);
+
+-- Seed the ledger for projects created before payments existed, so that the
+-- recomputed totalReceived matches what was previously typed in.
+INSERT INTO payments (id, project_id, amount, paid_at, note, created_at)
+SELECT lower(hex(randomblob(16))), p.id, p.totalReceived, substr(p.createdAt, 1, 10),
+       'Opening balance', strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+FROM projects p
+WHERE p.totalReceived > 0
+  AND NOT EXISTS (SELECT 1 FROM payments WHERE project_id = p.id);
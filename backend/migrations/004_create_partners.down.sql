@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS partner_payouts;
+DROP TABLE IF EXISTS partners;
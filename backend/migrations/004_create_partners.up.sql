@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS partners (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL UNIQUE,
+	email TEXT,
+	notes TEXT,
+	created_at TEXT NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS partner_payouts (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	partner_id TEXT NOT NULL REFERENCES partners(id),
+	amount REAL NOT NULL,
+	paid_at TEXT,
+	note TEXT,
+	created_at TEXT NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_partner_payouts_project_id ON partner_payouts(project_id);
+CREATE INDEX IF NOT EXISTS idx_partner_payouts_partner_id ON partner_payouts(partner_id);
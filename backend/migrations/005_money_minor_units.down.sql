@@ -0,0 +1,23 @@
+ALTER TABLE projects ADD COLUMN totalAmount_rupees REAL NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN advanceReceived_rupees REAL NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN totalReceived_rupees REAL NOT NULL DEFAULT 0;
+UPDATE projects SET
+	totalAmount_rupees = totalAmount / 100.0,
+	advanceReceived_rupees = advanceReceived / 100.0,
+	totalReceived_rupees = totalReceived / 100.0;
+ALTER TABLE projects DROP COLUMN totalAmount;
+ALTER TABLE projects DROP COLUMN advanceReceived;
+ALTER TABLE projects DROP COLUMN totalReceived;
+ALTER TABLE projects RENAME COLUMN totalAmount_rupees TO totalAmount;
+ALTER TABLE projects RENAME COLUMN advanceReceived_rupees TO advanceReceived;
+ALTER TABLE projects RENAME COLUMN totalReceived_rupees TO totalReceived;
+
+ALTER TABLE payments ADD COLUMN amount_rupees REAL NOT NULL DEFAULT 0;
+UPDATE payments SET amount_rupees = amount / 100.0;
+ALTER TABLE payments DROP COLUMN amount;
+ALTER TABLE payments RENAME COLUMN amount_rupees TO amount;
+
+ALTER TABLE partner_payouts ADD COLUMN amount_rupees REAL NOT NULL DEFAULT 0;
+UPDATE partner_payouts SET amount_rupees = amount / 100.0;
+ALTER TABLE partner_payouts DROP COLUMN amount;
+ALTER TABLE partner_payouts RENAME COLUMN amount_rupees TO amount;
@@ -0,0 +1,31 @@
+-- Convert money columns from REAL rupees to INTEGER paise. SQLite cannot change
+-- a column's type in place, and rebuilding projects would cascade-delete its
+-- payments, so each column is copied into a new INTEGER column and swapped in.
+
+ALTER TABLE projects ADD COLUMN totalAmount_paise INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN advanceReceived_paise INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN totalReceived_paise INTEGER NOT NULL DEFAULT 0;
+UPDATE projects SET
+	totalAmount_paise = CAST(ROUND(totalAmount * 100) AS INTEGER),
+	advanceReceived_paise = CAST(ROUND(advanceReceived * 100) AS INTEGER),
+	totalReceived_paise = CAST(ROUND(totalReceived * 100) AS INTEGER);
+ALTER TABLE projects DROP COLUMN totalAmount;
+ALTER TABLE projects DROP COLUMN advanceReceived;
+ALTER TABLE projects DROP COLUMN totalReceived;
+ALTER TABLE projects RENAME COLUMN totalAmount_paise TO totalAmount;
+ALTER TABLE projects RENAME COLUMN advanceReceived_paise TO advanceReceived;
+ALTER TABLE projects RENAME COLUMN totalReceived_paise TO totalReceived;
+
+ALTER TABLE payments ADD COLUMN amount_paise INTEGER NOT NULL DEFAULT 0;
+UPDATE payments SET amount_paise = CAST(ROUND(amount * 100) AS INTEGER);
+ALTER TABLE payments DROP COLUMN amount;
+ALTER TABLE payments RENAME COLUMN amount_paise TO amount;
+
+ALTER TABLE partner_payouts ADD COLUMN amount_paise INTEGER NOT NULL DEFAULT 0;
+UPDATE partner_payouts SET amount_paise = CAST(ROUND(amount * 100) AS INTEGER);
+ALTER TABLE partner_payouts DROP COLUMN amount;
+ALTER TABLE partner_payouts RENAME COLUMN amount_paise TO amount;
+
+-- totalReceived is the sum of the ledger; recompute it from the rounded payments
+UPDATE projects
+SET totalReceived = (SELECT COALESCE(SUM(amount), 0) FROM payments WHERE project_id = projects.id);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS exchange_rates;
+ALTER TABLE payments DROP COLUMN currency;
+ALTER TABLE projects DROP COLUMN currency;
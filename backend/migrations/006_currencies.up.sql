@@ -0,0 +1,15 @@
+-- Everything recorded before multi-currency support was in rupees
+ALTER TABLE projects ADD COLUMN currency TEXT NOT NULL DEFAULT 'INR';
+ALTER TABLE payments ADD COLUMN currency TEXT NOT NULL DEFAULT 'INR';
+
+-- rate is the value of one unit of currency in base_currency, kept as decimal
+-- text so conversions stay exact
+CREATE TABLE IF NOT EXISTS exchange_rates (
+	id TEXT PRIMARY KEY,
+	base_currency TEXT NOT NULL,
+	currency TEXT NOT NULL,
+	rate_date TEXT NOT NULL,
+	rate TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	UNIQUE (base_currency, currency, rate_date)
+);
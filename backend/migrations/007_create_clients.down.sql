@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_projects_client_id;
+ALTER TABLE projects DROP COLUMN clientId;
+DROP TABLE IF EXISTS clients;
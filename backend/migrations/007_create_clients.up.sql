@@ -0,0 +1,70 @@
+CREATE TABLE IF NOT EXISTS clients (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	email TEXT,
+	phone TEXT,
+	gstin TEXT,
+	billing_address TEXT,
+	notes TEXT,
+	created_at TEXT NOT NULL
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_clients_name ON clients(name COLLATE NOCASE);
+
+ALTER TABLE projects ADD COLUMN clientId TEXT REFERENCES clients(id);
+CREATE INDEX IF NOT EXISTS idx_projects_client_id ON projects(clientId);
+
+-- De-duplicate the free-text client names into client rows. Names are matched
+-- on a key that ignores case, punctuation, extra spaces and company suffixes,
+-- so "Acme", "acme" and "ACME Pvt. Ltd." become one client.
+CREATE TEMP TABLE client_names AS
+SELECT id AS project_id,
+       trim(clientName) AS name,
+       ' ' || lower(trim(clientName)) || ' ' AS key
+FROM projects
+WHERE clientName IS NOT NULL AND trim(clientName) != '';
+
+UPDATE client_names SET key = replace(replace(replace(replace(key, '.', ' '), ',', ' '), '&', ' and '), '-', ' ');
+UPDATE client_names SET key = replace(replace(replace(key, '   ', ' '), '  ', ' '), '  ', ' ');
+UPDATE client_names SET key = replace(key, ' private limited ', ' ');
+UPDATE client_names SET key = replace(key, ' pvt ltd ', ' ');
+UPDATE client_names SET key = replace(key, ' pvt ', ' ');
+UPDATE client_names SET key = replace(key, ' limited ', ' ');
+UPDATE client_names SET key = replace(key, ' ltd ', ' ');
+UPDATE client_names SET key = replace(key, ' llp ', ' ');
+UPDATE client_names SET key = replace(key, ' llc ', ' ');
+UPDATE client_names SET key = replace(key, ' inc ', ' ');
+UPDATE client_names SET key = trim(key);
+UPDATE client_names SET key = lower(name) WHERE key = '';
+
+-- Each client takes the spelling used by most of its projects, preferring the
+-- longer (usually more formal) spelling on a tie
+CREATE TEMP TABLE client_keys AS
+SELECT key, name, lower(hex(randomblob(16))) AS client_id
+FROM (
+	SELECT key, name,
+	       ROW_NUMBER() OVER (PARTITION BY key ORDER BY COUNT(*) DESC, length(name) DESC, name) AS rn
+	FROM client_names
+	GROUP BY key, name
+)
+WHERE rn = 1;
+
+INSERT INTO clients (id, name, created_at)
+SELECT client_id, name, strftime('%Y-%m-%dT%H:%M:%SZ', 'now') FROM client_keys;
+
+UPDATE projects
+SET clientId = (
+	SELECT k.client_id
+	FROM client_names n
+	JOIN client_keys k ON k.key = n.key
+	WHERE n.project_id = projects.id
+),
+clientName = (
+	SELECT k.name
+	FROM client_names n
+	JOIN client_keys k ON k.key = n.key
+	WHERE n.project_id = projects.id
+)
+WHERE id IN (SELECT project_id FROM client_names);
+
+DROP TABLE client_names;
+DROP TABLE client_keys;
@@ -0,0 +1,15 @@
+-- Personal API tokens. Only the SHA-256 of a token is stored; prefix keeps the
+-- first characters so users can tell their tokens apart.
+CREATE TABLE IF NOT EXISTS api_tokens (
+	id TEXT PRIMARY KEY,
+	user_id TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	token_hash TEXT NOT NULL UNIQUE,
+	prefix TEXT NOT NULL,
+	scope TEXT CHECK(scope IN ('read','write','admin')) NOT NULL,
+	created_at TEXT NOT NULL,
+	expires_at TEXT,
+	last_used_at TEXT,
+	revoked_at TEXT
+);
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens(user_id);
@@ -0,0 +1,2 @@
+-- Users with the partner role are linked to the partner whose payouts they may see
+ALTER TABLE users ADD COLUMN partner_id TEXT REFERENCES partners(id);
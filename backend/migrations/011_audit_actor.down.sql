@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_audit_logs_actor_id;
+ALTER TABLE audit_logs DROP COLUMN user_agent;
+ALTER TABLE audit_logs DROP COLUMN ip;
+ALTER TABLE audit_logs DROP COLUMN actor_type;
+ALTER TABLE audit_logs DROP COLUMN actor_id;
@@ -0,0 +1,7 @@
+-- Who made each change. Entries written before this migration have no known
+-- actor and are attributed to the system.
+ALTER TABLE audit_logs ADD COLUMN actor_id TEXT;
+ALTER TABLE audit_logs ADD COLUMN actor_type TEXT NOT NULL DEFAULT 'system';
+ALTER TABLE audit_logs ADD COLUMN ip TEXT;
+ALTER TABLE audit_logs ADD COLUMN user_agent TEXT;
+CREATE INDEX IF NOT EXISTS idx_audit_logs_actor_id ON audit_logs(actor_id);
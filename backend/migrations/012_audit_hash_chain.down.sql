@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_audit_logs_seq;
+ALTER TABLE audit_logs DROP COLUMN hash;
+ALTER TABLE audit_logs DROP COLUMN prev_hash;
+ALTER TABLE audit_logs DROP COLUMN seq;
@@ -0,0 +1,16 @@
+-- Each entry is numbered and carries a SHA-256 of its contents chained to the
+-- previous entry's hash, so editing or removing a row breaks the chain.
+-- Existing entries are numbered in the order they were written and hashed by
+-- the migration runner, since SQLite cannot compute SHA-256 itself.
+ALTER TABLE audit_logs ADD COLUMN seq INTEGER;
+ALTER TABLE audit_logs ADD COLUMN prev_hash TEXT;
+ALTER TABLE audit_logs ADD COLUMN hash TEXT;
+
+UPDATE audit_logs SET seq = (
+	SELECT n FROM (
+		SELECT id, ROW_NUMBER() OVER (ORDER BY created_at, rowid) AS n FROM audit_logs
+	) numbered
+	WHERE numbered.id = audit_logs.id
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_audit_logs_seq ON audit_logs(seq);
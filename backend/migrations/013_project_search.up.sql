@@ -0,0 +1,36 @@
+-- Full-text index over the projects' text fields. It is an external-content
+-- table, so it stores only the index and reads the text from projects by rowid;
+-- the triggers keep it in step with every insert, update and delete.
+CREATE VIRTUAL TABLE IF NOT EXISTS project_search USING fts5(
+	name,
+	clientName,
+	description,
+	completionNotes,
+	deliveryNotes,
+	internalNotes,
+	techStack,
+	deliverables,
+	content = 'projects',
+	content_rowid = 'rowid',
+	tokenize = 'unicode61 remove_diacritics 2'
+);
+
+CREATE TRIGGER IF NOT EXISTS projects_search_insert AFTER INSERT ON projects BEGIN
+	INSERT INTO project_search (rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES (new.rowid, new.name, new.clientName, new.description, new.completionNotes, new.deliveryNotes, new.internalNotes, new.techStack, new.deliverables);
+END;
+
+CREATE TRIGGER IF NOT EXISTS projects_search_delete AFTER DELETE ON projects BEGIN
+	INSERT INTO project_search (project_search, rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES ('delete', old.rowid, old.name, old.clientName, old.description, old.completionNotes, old.deliveryNotes, old.internalNotes, old.techStack, old.deliverables);
+END;
+
+CREATE TRIGGER IF NOT EXISTS projects_search_update AFTER UPDATE ON projects BEGIN
+	INSERT INTO project_search (project_search, rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES ('delete', old.rowid, old.name, old.clientName, old.description, old.completionNotes, old.deliveryNotes, old.internalNotes, old.techStack, old.deliverables);
+	INSERT INTO project_search (rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES (new.rowid, new.name, new.clientName, new.description, new.completionNotes, new.deliveryNotes, new.internalNotes, new.techStack, new.deliverables);
+END;
+
+-- Index the projects that already exist
+INSERT INTO project_search (project_search) VALUES ('rebuild');
@@ -0,0 +1,10 @@
+DROP TRIGGER IF EXISTS projects_bump_version;
+ALTER TABLE projects DROP COLUMN version;
+
+DROP TRIGGER IF EXISTS projects_search_update;
+CREATE TRIGGER projects_search_update AFTER UPDATE ON projects BEGIN
+	INSERT INTO project_search (project_search, rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES ('delete', old.rowid, old.name, old.clientName, old.description, old.completionNotes, old.deliveryNotes, old.internalNotes, old.techStack, old.deliverables);
+	INSERT INTO project_search (rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES (new.rowid, new.name, new.clientName, new.description, new.completionNotes, new.deliveryNotes, new.internalNotes, new.techStack, new.deliverables);
+END;
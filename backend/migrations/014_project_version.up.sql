@@ -0,0 +1,22 @@
+-- Optimistic concurrency: every change to a project row moves its version on,
+-- whichever code path makes it, so the version can back an ETag
+ALTER TABLE projects ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+CREATE TRIGGER IF NOT EXISTS projects_bump_version AFTER UPDATE ON projects
+WHEN new.version = old.version
+BEGIN
+	UPDATE projects SET version = old.version + 1 WHERE rowid = new.rowid;
+END;
+
+-- The version bump is a nested update that runs before the search trigger, so
+-- the search trigger must only react to the columns it indexes or it would
+-- remove text the index no longer holds
+DROP TRIGGER IF EXISTS projects_search_update;
+CREATE TRIGGER projects_search_update
+AFTER UPDATE OF name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables ON projects
+BEGIN
+	INSERT INTO project_search (project_search, rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES ('delete', old.rowid, old.name, old.clientName, old.description, old.completionNotes, old.deliveryNotes, old.internalNotes, old.techStack, old.deliverables);
+	INSERT INTO project_search (rowid, name, clientName, description, completionNotes, deliveryNotes, internalNotes, techStack, deliverables)
+	VALUES (new.rowid, new.name, new.clientName, new.description, new.completionNotes, new.deliveryNotes, new.internalNotes, new.techStack, new.deliverables);
+END;
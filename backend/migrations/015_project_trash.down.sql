@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_projects_deletedAt;
+ALTER TABLE projects DROP COLUMN deletedAt;
@@ -0,0 +1,4 @@
+-- Deleted projects move to the trash, keeping their ledger, until they are
+-- restored or purged
+ALTER TABLE projects ADD COLUMN deletedAt TEXT;
+CREATE INDEX IF NOT EXISTS idx_projects_deletedAt ON projects(deletedAt);
@@ -0,0 +1,11 @@
+-- Every create and edit of a project stores a full snapshot of it, keyed by
+-- the project version it produced, so old versions can be compared and restored
+CREATE TABLE IF NOT EXISTS project_versions (
+	project_id TEXT NOT NULL REFERENCES projects(id) ON DELETE CASCADE,
+	version INTEGER NOT NULL,
+	snapshot TEXT NOT NULL,
+	created_at TEXT NOT NULL,
+	actor_id TEXT,
+	actor_type TEXT NOT NULL,
+	PRIMARY KEY (project_id, version)
+);
@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS invoices_keep_number;
+DROP TRIGGER IF EXISTS invoices_keep_numbered;
+DROP TABLE IF EXISTS invoice_items;
+DROP TABLE IF EXISTS invoices;
@@ -0,0 +1,52 @@
+-- Invoices bill a project's client. A draft has no number; sending it takes
+-- the next number of its financial year. project_id has no foreign key, since
+-- issued invoices must outlive a project purged from the trash.
+CREATE TABLE IF NOT EXISTS invoices (
+	id TEXT PRIMARY KEY,
+	project_id TEXT NOT NULL,
+	number TEXT UNIQUE,
+	financial_year TEXT,
+	sequence INTEGER,
+	status TEXT NOT NULL DEFAULT 'draft' CHECK (status IN ('draft', 'sent', 'paid', 'void')),
+	client_id TEXT,
+	client_name TEXT,
+	billing_address TEXT,
+	currency TEXT NOT NULL,
+	total INTEGER NOT NULL DEFAULT 0,
+	issue_date TEXT,
+	due_date TEXT,
+	notes TEXT,
+	created_at TEXT NOT NULL,
+	sent_at TEXT,
+	paid_at TEXT,
+	voided_at TEXT,
+	void_reason TEXT,
+	UNIQUE (financial_year, sequence)
+);
+CREATE INDEX IF NOT EXISTS idx_invoices_project_id ON invoices(project_id);
+
+CREATE TABLE IF NOT EXISTS invoice_items (
+	invoice_id TEXT NOT NULL REFERENCES invoices(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL,
+	description TEXT NOT NULL,
+	quantity INTEGER NOT NULL,
+	unit_price INTEGER NOT NULL,
+	amount INTEGER NOT NULL,
+	PRIMARY KEY (invoice_id, position)
+);
+
+-- Numbers must stay gap-free: once issued, an invoice can be voided but never
+-- deleted or renumbered
+CREATE TRIGGER IF NOT EXISTS invoices_keep_numbered
+BEFORE DELETE ON invoices
+WHEN old.number IS NOT NULL
+BEGIN
+	SELECT RAISE(ABORT, 'issued invoices cannot be deleted');
+END;
+
+CREATE TRIGGER IF NOT EXISTS invoices_keep_number
+BEFORE UPDATE OF number, financial_year, sequence ON invoices
+WHEN old.number IS NOT NULL
+BEGIN
+	SELECT RAISE(ABORT, 'issued invoices cannot be renumbered');
+END;
@@ -0,0 +1,26 @@
+DROP INDEX IF EXISTS idx_invoices_issue_date;
+
+ALTER TABLE invoice_items DROP COLUMN igst;
+ALTER TABLE invoice_items DROP COLUMN sgst;
+ALTER TABLE invoice_items DROP COLUMN cgst;
+ALTER TABLE invoice_items DROP COLUMN taxable_value;
+ALTER TABLE invoice_items DROP COLUMN gst_rate;
+ALTER TABLE invoice_items DROP COLUMN hsn_sac;
+
+-- total goes back to being the sum of the items
+UPDATE invoices SET total = subtotal;
+ALTER TABLE invoices DROP COLUMN igst;
+ALTER TABLE invoices DROP COLUMN sgst;
+ALTER TABLE invoices DROP COLUMN cgst;
+ALTER TABLE invoices DROP COLUMN taxable_value;
+ALTER TABLE invoices DROP COLUMN subtotal;
+ALTER TABLE invoices DROP COLUMN place_of_supply;
+ALTER TABLE invoices DROP COLUMN client_gstin;
+ALTER TABLE invoices DROP COLUMN supplier_state;
+ALTER TABLE invoices DROP COLUMN supplier_gstin;
+ALTER TABLE invoices DROP COLUMN tax_inclusive;
+
+ALTER TABLE projects DROP COLUMN gstRate;
+ALTER TABLE projects DROP COLUMN taxInclusive;
+
+ALTER TABLE clients DROP COLUMN state;
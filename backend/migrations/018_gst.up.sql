@@ -0,0 +1,42 @@
+-- GST: clients carry the state that decides the place of supply, projects
+-- say whether their amounts include tax and at what rate, and invoices keep
+-- the tax worked out for each item
+ALTER TABLE clients ADD COLUMN state TEXT;
+UPDATE clients SET state = substr(gstin, 1, 2) WHERE gstin IS NOT NULL;
+
+-- Existing projects were priced without GST in mind, so they are put on 0%
+-- rather than quietly taxed; new projects are given 18% by the API unless
+-- they set a rate
+ALTER TABLE projects ADD COLUMN taxInclusive INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE projects ADD COLUMN gstRate INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE invoices ADD COLUMN tax_inclusive INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN supplier_gstin TEXT;
+ALTER TABLE invoices ADD COLUMN supplier_state TEXT;
+ALTER TABLE invoices ADD COLUMN client_gstin TEXT;
+ALTER TABLE invoices ADD COLUMN place_of_supply TEXT;
+ALTER TABLE invoices ADD COLUMN subtotal INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN taxable_value INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN cgst INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN sgst INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoices ADD COLUMN igst INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE invoice_items ADD COLUMN hsn_sac TEXT;
+ALTER TABLE invoice_items ADD COLUMN gst_rate INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoice_items ADD COLUMN taxable_value INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoice_items ADD COLUMN cgst INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoice_items ADD COLUMN sgst INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE invoice_items ADD COLUMN igst INTEGER NOT NULL DEFAULT 0;
+
+-- Invoices issued so far stay untaxed; drafts pick up the project rate and
+-- have their tax worked out when they are next saved or sent
+UPDATE invoices SET subtotal = total, taxable_value = total;
+UPDATE invoice_items SET taxable_value = amount;
+UPDATE invoice_items SET gst_rate = (
+	SELECT p.gstRate FROM invoices i JOIN projects p ON p.id = i.project_id WHERE i.id = invoice_items.invoice_id
+)
+WHERE invoice_id IN (
+	SELECT i.id FROM invoices i JOIN projects p ON p.id = i.project_id WHERE i.status = 'draft'
+);
+
+CREATE INDEX IF NOT EXISTS idx_invoices_issue_date ON invoices(issue_date);
@@ -0,0 +1,10 @@
+ALTER TABLE clients DROP COLUMN tan;
+
+ALTER TABLE projects DROP COLUMN totalTds;
+
+-- totalReceived goes back to the sum of the amounts banked
+UPDATE projects
+SET totalReceived = (SELECT COALESCE(SUM(amount), 0) FROM payments WHERE project_id = projects.id);
+
+ALTER TABLE payments DROP COLUMN tds_section;
+ALTER TABLE payments DROP COLUMN tds_amount;
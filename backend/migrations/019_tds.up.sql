@@ -0,0 +1,10 @@
+-- TDS: clients that deduct tax at source pay the net amount and credit the
+-- rest to us as TDS. A payment settles amount + tds_amount of the project
+-- total; projects keep the TDS part of totalReceived in totalTds.
+ALTER TABLE payments ADD COLUMN tds_amount INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE payments ADD COLUMN tds_section TEXT;
+
+ALTER TABLE projects ADD COLUMN totalTds INTEGER NOT NULL DEFAULT 0;
+
+-- The deductor's TAN, as Form 26AS lists it
+ALTER TABLE clients ADD COLUMN tan TEXT;
@@ -0,0 +1,10 @@
+// Package migrations holds the numbered SQL schema migrations applied by db.Migrate.
+//
+// Each migration is a pair of files named NNN_description.up.sql and
+// NNN_description.down.sql. Versions must be unique and are applied in order.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -0,0 +1,37 @@
+package models
+
+import "database/sql"
+
+// API token scopes, from least to most privileged
+const (
+	ScopeRead  = "read"  // GET requests only
+	ScopeWrite = "write" // Any request except token and user management
+	ScopeAdmin = "admin" // Everything the owning user can do
+)
+
+// ValidScope reports whether scope is one of the token scopes
+func ValidScope(scope string) bool {
+	return scope == ScopeRead || scope == ScopeWrite || scope == ScopeAdmin
+}
+
+// APIToken is a revocable personal token used as a Bearer credential by
+// scripts. The secret itself is only shown once, when the token is created.
+type APIToken struct {
+	ID         string  `json:"id"`
+	UserID     string  `json:"userId"`
+	Name       string  `json:"name"`
+	Prefix     string  `json:"prefix"` // First characters of the token, for recognition
+	Scope      string  `json:"scope"`
+	CreatedAt  string  `json:"createdAt"`            // ISO 8601 format (RFC3339)
+	ExpiresAt  *string `json:"expiresAt,omitempty"`  // ISO 8601 format (RFC3339)
+	LastUsedAt *string `json:"lastUsedAt,omitempty"` // ISO 8601 format (RFC3339)
+	RevokedAt  *string `json:"revokedAt,omitempty"`  // ISO 8601 format (RFC3339)
+}
+
+func (t *APIToken) Scan(row *sql.Row) error {
+	return row.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.Scope, &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt)
+}
+
+func (t *APIToken) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(&t.ID, &t.UserID, &t.Name, &t.Prefix, &t.Scope, &t.CreatedAt, &t.ExpiresAt, &t.LastUsedAt, &t.RevokedAt)
+}
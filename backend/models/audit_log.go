@@ -1,8 +1,59 @@
 package models
 
+import "database/sql"
+
+// Actor types recorded on audit entries
+const (
+	ActorUser   = "user"   // A signed-in user, by session; ID is the user's
+	ActorToken  = "token"  // A script using an API token; ID is the token's
+	ActorSystem = "system" // The server itself, such as a scheduled job
+)
+
+// Actor is whoever made an audited change, with where the request came from
+type Actor struct {
+	ID        *string
+	Type      string
+	IP        *string
+	UserAgent *string
+}
+
+// SystemActor is the actor for changes the server makes on its own
+var SystemActor = Actor{Type: ActorSystem}
+
+// AuditLog is a single recorded change. Field-level entries carry the field
+// name with its old and new values; lifecycle entries leave them empty.
 type AuditLog struct {
-	ID        string
-	ProjectID string
-	Action    string
-	CreatedAt string
+	ID        string  `json:"id"`
+	ProjectID string  `json:"projectId"`
+	Action    string  `json:"action"`
+	FieldName *string `json:"fieldName,omitempty"`
+	OldValue  *string `json:"oldValue,omitempty"`
+	NewValue  *string `json:"newValue,omitempty"`
+	CreatedAt string  `json:"createdAt"` // ISO 8601 format (RFC3339)
+	ActorID   *string `json:"actorId,omitempty"`
+	ActorType string  `json:"actorType"`
+	IP        *string `json:"ip,omitempty"`
+	UserAgent *string `json:"userAgent,omitempty"`
+	Seq       int64   `json:"seq"`      // Position in the audit hash chain
+	PrevHash  string  `json:"prevHash"` // Hash of the entry before this one
+	Hash      string  `json:"hash"`     // SHA-256 of PrevHash and this entry's contents
+}
+
+func (a *AuditLog) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(
+		&a.ID,
+		&a.ProjectID,
+		&a.Action,
+		&a.FieldName,
+		&a.OldValue,
+		&a.NewValue,
+		&a.CreatedAt,
+		&a.ActorID,
+		&a.ActorType,
+		&a.IP,
+		&a.UserAgent,
+		&a.Seq,
+		&a.PrevHash,
+		&a.Hash,
+	)
 }
@@ -0,0 +1,26 @@
+package models
+
+import "database/sql"
+
+// Client is a customer that projects are delivered for. Projects link to a
+// client through ClientID; ClientName on a linked project mirrors Client.Name.
+type Client struct {
+	ID             string  `json:"id"`
+	Name           string  `json:"name"`
+	Email          *string `json:"email,omitempty"`
+	Phone          *string `json:"phone,omitempty"`
+	GSTIN          *string `json:"gstin,omitempty"` // 15-character Indian GST identification number
+	State          *string `json:"state,omitempty"` // GST state code, e.g. 29; defaults to the GSTIN's first two digits
+	TAN            *string `json:"tan,omitempty"`   // Tax deduction account number, for clients that deduct TDS
+	BillingAddress *string `json:"billingAddress,omitempty"`
+	Notes          *string `json:"notes,omitempty"`
+	CreatedAt      string  `json:"createdAt"` // ISO 8601 format (RFC3339)
+}
+
+func (c *Client) Scan(row *sql.Row) error {
+	return row.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.GSTIN, &c.BillingAddress, &c.Notes, &c.CreatedAt, &c.State, &c.TAN)
+}
+
+func (c *Client) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(&c.ID, &c.Name, &c.Email, &c.Phone, &c.GSTIN, &c.BillingAddress, &c.Notes, &c.CreatedAt, &c.State, &c.TAN)
+}
@@ -0,0 +1,75 @@
+package models
+
+import (
+	"database/sql"
+	"encoding/json"
+	"math/big"
+	"strings"
+)
+
+// DefaultCurrency is used for projects and payments that predate multi-currency support
+const DefaultCurrency = "INR"
+
+// supportedCurrencies lists the accepted ISO 4217 codes. Only currencies with
+// two-decimal minor units are listed, since Money always holds hundredths.
+var supportedCurrencies = map[string]bool{
+	"INR": true,
+	"USD": true,
+	"EUR": true,
+	"GBP": true,
+	"AUD": true,
+	"CAD": true,
+	"SGD": true,
+	"AED": true,
+	"CHF": true,
+	"NZD": true,
+}
+
+// ValidCurrency reports whether code is a supported ISO 4217 currency code
+func ValidCurrency(code string) bool {
+	return supportedCurrencies[code]
+}
+
+// ExchangeRate is the value of one unit of Currency in BaseCurrency on Date
+type ExchangeRate struct {
+	ID           string      `json:"id"`
+	BaseCurrency string      `json:"baseCurrency"`
+	Currency     string      `json:"currency"`
+	Date         string      `json:"date"` // YYYY-MM-DD
+	Rate         json.Number `json:"rate"` // Stored as decimal TEXT to stay exact
+	CreatedAt    string      `json:"createdAt"`
+}
+
+func (e *ExchangeRate) Scan(row *sql.Row) error {
+	return row.Scan(
+		&e.ID,
+		&e.BaseCurrency,
+		&e.Currency,
+		&e.Date,
+		&e.Rate,
+		&e.CreatedAt,
+	)
+}
+
+func (e *ExchangeRate) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(
+		&e.ID,
+		&e.BaseCurrency,
+		&e.Currency,
+		&e.Date,
+		&e.Rate,
+		&e.CreatedAt,
+	)
+}
+
+// ParseRate parses a positive decimal exchange rate exactly
+func ParseRate(s string) (*big.Rat, bool) {
+	if strings.Contains(s, "/") {
+		return nil, false
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || r.Sign() <= 0 {
+		return nil, false
+	}
+	return r, true
+}
@@ -0,0 +1,111 @@
+package models
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Percent is a rate in hundredths of a percent, stored as an INTEGER column
+// and encoded in JSON as a decimal percentage, so 1800 is sent as 18
+type Percent int64
+
+func (p Percent) String() string {
+	return Money(p).String()
+}
+
+func (p Percent) MarshalJSON() ([]byte, error) {
+	return Money(p).MarshalJSON()
+}
+
+func (p *Percent) UnmarshalJSON(data []byte) error {
+	return (*Money)(p).UnmarshalJSON(data)
+}
+
+// DefaultGSTRate is the rate for services, given to new projects that do not set one
+const DefaultGSTRate Percent = 1800
+
+// ValidGSTRate reports whether rate is one of the GST slabs
+func ValidGSTRate(rate Percent) bool {
+	switch rate {
+	case 0, 25, 300, 500, 1200, 1800, 2800, 4000:
+		return true
+	}
+	return false
+}
+
+// gstinPattern matches a GSTIN: state code, PAN, entity number, 'Z' and a check character
+var gstinPattern = regexp.MustCompile(`^\d{2}[A-Z]{5}\d{4}[A-Z][1-9A-Z]Z[0-9A-Z]$`)
+
+// NormalizeGSTIN upper-cases and trims a GSTIN, reporting whether the result
+// is well formed
+func NormalizeGSTIN(s string) (string, bool) {
+	gstin := strings.ToUpper(strings.TrimSpace(s))
+	return gstin, gstinPattern.MatchString(gstin)
+}
+
+// GSTStates maps GST state codes, the first two digits of a GSTIN, to the
+// state or union territory they stand for. 96 is used for supplies to
+// recipients outside India.
+var GSTStates = map[string]string{
+	"01": "Jammu and Kashmir", "02": "Himachal Pradesh", "03": "Punjab", "04": "Chandigarh",
+	"05": "Uttarakhand", "06": "Haryana", "07": "Delhi", "08": "Rajasthan", "09": "Uttar Pradesh",
+	"10": "Bihar", "11": "Sikkim", "12": "Arunachal Pradesh", "13": "Nagaland", "14": "Manipur",
+	"15": "Mizoram", "16": "Tripura", "17": "Meghalaya", "18": "Assam", "19": "West Bengal",
+	"20": "Jharkhand", "21": "Odisha", "22": "Chhattisgarh", "23": "Madhya Pradesh", "24": "Gujarat",
+	"26": "Dadra and Nagar Haveli and Daman and Diu", "27": "Maharashtra", "29": "Karnataka",
+	"30": "Goa", "31": "Lakshadweep", "32": "Kerala", "33": "Tamil Nadu", "34": "Puducherry",
+	"35": "Andaman and Nicobar Islands", "36": "Telangana", "37": "Andhra Pradesh", "38": "Ladakh",
+	"96": "Other Country", "97": "Other Territory",
+}
+
+// GSTStateLabel writes a state code the way GST returns do, e.g. 29-Karnataka
+func GSTStateLabel(code string) string {
+	return code + "-" + GSTStates[code]
+}
+
+// GSTAmounts is the tax on an amount, split into its components. Within a
+// state, CGST and SGST each take half the rate; across states IGST takes all.
+type GSTAmounts struct {
+	Taxable Money
+	CGST    Money
+	SGST    Money
+	IGST    Money
+}
+
+// Tax returns the total of the tax components
+func (g GSTAmounts) Tax() Money {
+	return g.CGST + g.SGST + g.IGST
+}
+
+// ComputeGST works out the tax on amount at rate. When inclusive is set the
+// amount already contains the tax, which is taken out of it so that taxable
+// value plus tax equals the amount exactly. Components are rounded to the
+// paisa; CGST and SGST are always equal.
+func ComputeGST(amount Money, rate Percent, inclusive, interState bool) GSTAmounts {
+	taxable := amount
+	if inclusive {
+		taxable = roundDiv(amount*10000, Money(10000+rate))
+	}
+
+	g := GSTAmounts{}
+	if interState {
+		g.IGST = roundDiv(taxable*Money(rate), 10000)
+	} else {
+		g.CGST = roundDiv(taxable*Money(rate), 20000)
+		g.SGST = g.CGST
+	}
+
+	g.Taxable = taxable
+	if inclusive {
+		g.Taxable = amount - g.Tax()
+	}
+	return g
+}
+
+// roundDiv divides, rounding half away from zero
+func roundDiv(a, b Money) Money {
+	if a < 0 {
+		return -roundDiv(-a, b)
+	}
+	return (2*a + b) / (2 * b)
+}
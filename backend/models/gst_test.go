@@ -0,0 +1,75 @@
+package models
+
+import "testing"
+
+func TestComputeGST(t *testing.T) {
+	tests := []struct {
+		name       string
+		amount     Money
+		rate       Percent
+		inclusive  bool
+		interState bool
+		want       GSTAmounts
+	}{
+		{"intra-state on top", 100000, 1800, false, false, GSTAmounts{Taxable: 100000, CGST: 9000, SGST: 9000}},
+		{"inter-state on top", 100000, 1800, false, true, GSTAmounts{Taxable: 100000, IGST: 18000}},
+		{"intra-state included", 118000, 1800, true, false, GSTAmounts{Taxable: 100000, CGST: 9000, SGST: 9000}},
+		{"inter-state included", 118000, 1800, true, true, GSTAmounts{Taxable: 100000, IGST: 18000}},
+		{"halves rounded alike", 33333, 1800, false, false, GSTAmounts{Taxable: 33333, CGST: 3000, SGST: 3000}},
+		{"included with rounding", 10000, 1800, true, false, GSTAmounts{Taxable: 8474, CGST: 763, SGST: 763}},
+		{"fractional rate", 100000, 25, false, true, GSTAmounts{Taxable: 100000, IGST: 250}},
+		{"under a paisa of tax", 1, 1800, false, false, GSTAmounts{Taxable: 1}},
+		{"zero rate", 100000, 0, true, false, GSTAmounts{Taxable: 100000}},
+		{"credit", -100000, 1800, false, true, GSTAmounts{Taxable: -100000, IGST: -18000}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ComputeGST(tt.amount, tt.rate, tt.inclusive, tt.interState)
+			if got != tt.want {
+				t.Errorf("ComputeGST(%d, %d, %v, %v) = %+v, want %+v", tt.amount, tt.rate, tt.inclusive, tt.interState, got, tt.want)
+			}
+			if got.CGST != got.SGST {
+				t.Errorf("CGST %d and SGST %d differ", got.CGST, got.SGST)
+			}
+			if tt.inclusive && got.Taxable+got.Tax() != tt.amount {
+				t.Errorf("taxable %d plus tax %d does not add up to %d", got.Taxable, got.Tax(), tt.amount)
+			}
+		})
+	}
+}
+
+func TestValidGSTRate(t *testing.T) {
+	for _, rate := range []Percent{0, 25, 300, 500, 1200, 1800, 2800, 4000} {
+		if !ValidGSTRate(rate) {
+			t.Errorf("ValidGSTRate(%d) = false, want true", rate)
+		}
+	}
+	for _, rate := range []Percent{-1800, 100, 1000, 1801, 10000} {
+		if ValidGSTRate(rate) {
+			t.Errorf("ValidGSTRate(%d) = true, want false", rate)
+		}
+	}
+}
+
+func TestNormalizeGSTIN(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"29ABCDE1234F1Z5", "29ABCDE1234F1Z5", true},
+		{" 29abcde1234f1z5 ", "29ABCDE1234F1Z5", true},
+		{"29ABCDE1234F1Y5", "29ABCDE1234F1Y5", false},
+		{"29ABCDE1234F0Z5", "29ABCDE1234F0Z5", false},
+		{"ABCDE1234F1Z5", "ABCDE1234F1Z5", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeGSTIN(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeGSTIN(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package models
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Invoice statuses. A draft can be edited or deleted; sending it issues it
+// with a number, after which it can only be marked paid or voided.
+const (
+	InvoiceDraft = "draft"
+	InvoiceSent  = "sent"
+	InvoicePaid  = "paid"
+	InvoiceVoid  = "void"
+)
+
+// Invoice bills a project's client. The client details are copied onto the
+// invoice when it is sent, so later edits to the client do not change it.
+//
+// Item amounts are entered as the project bills them: with the tax added on
+// top, or with it already included when TaxInclusive is set. Subtotal is their
+// sum and counts against the project total; Total is what the client pays.
+type Invoice struct {
+	ID             string        `json:"id"`
+	ProjectID      string        `json:"projectId"`
+	Number         *string       `json:"number,omitempty"`        // Assigned when sent, e.g. INV/2025-26/0001
+	FinancialYear  *string       `json:"financialYear,omitempty"` // e.g. 2025-26, the year of IssueDate
+	Status         string        `json:"status"`
+	ClientID       *string       `json:"clientId,omitempty"`
+	ClientName     *string       `json:"clientName,omitempty"`
+	BillingAddress *string       `json:"billingAddress,omitempty"`
+	Currency       string        `json:"currency"`            // Always the project's currency
+	Total          Money         `json:"total"`               // INTEGER type - taxable value plus tax, in MINOR UNITS
+	IssueDate      *string       `json:"issueDate,omitempty"` // YYYY-MM-DD
+	DueDate        *string       `json:"dueDate,omitempty"`   // YYYY-MM-DD
+	Notes          *string       `json:"notes,omitempty"`
+	Items          []InvoiceItem `json:"items"`
+	CreatedAt      string        `json:"createdAt"`          // ISO 8601 format (RFC3339)
+	SentAt         *string       `json:"sentAt,omitempty"`   // ISO 8601 format (RFC3339)
+	PaidAt         *string       `json:"paidAt,omitempty"`   // YYYY-MM-DD
+	VoidedAt       *string       `json:"voidedAt,omitempty"` // ISO 8601 format (RFC3339)
+	VoidReason     *string       `json:"voidReason,omitempty"`
+
+	// GST, charged only when the issuer has a GSTIN. The place of supply is a
+	// state code; when it differs from the supplier's state IGST is charged,
+	// otherwise CGST and SGST.
+	TaxInclusive  bool    `json:"taxInclusive"`
+	SupplierGSTIN *string `json:"supplierGstin,omitempty"`
+	SupplierState *string `json:"supplierState,omitempty"`
+	ClientGSTIN   *string `json:"clientGstin,omitempty"`
+	PlaceOfSupply *string `json:"placeOfSupply,omitempty"`
+	Subtotal      Money   `json:"subtotal"`     // INTEGER type - sum of the item amounts, in MINOR UNITS
+	TaxableValue  Money   `json:"taxableValue"` // INTEGER type - stored in MINOR UNITS
+	CGST          Money   `json:"cgst"`         // INTEGER type - stored in MINOR UNITS
+	SGST          Money   `json:"sgst"`         // INTEGER type - stored in MINOR UNITS
+	IGST          Money   `json:"igst"`         // INTEGER type - stored in MINOR UNITS
+}
+
+// InvoiceItem is one line of an invoice. Amount is Quantity × UnitPrice; the
+// tax on it is worked out at the item's GSTRate.
+type InvoiceItem struct {
+	Description  string  `json:"description"`
+	HSNSAC       *string `json:"hsnSac,omitempty"` // HSN code for goods, SAC for services
+	Quantity     int64   `json:"quantity"`
+	UnitPrice    Money   `json:"unitPrice"`    // INTEGER type - stored in MINOR UNITS
+	Amount       Money   `json:"amount"`       // INTEGER type - stored in MINOR UNITS
+	GSTRate      Percent `json:"gstRate"`      // INTEGER type - hundredths of a percent
+	TaxableValue Money   `json:"taxableValue"` // INTEGER type - stored in MINOR UNITS
+	CGST         Money   `json:"cgst"`         // INTEGER type - stored in MINOR UNITS
+	SGST         Money   `json:"sgst"`         // INTEGER type - stored in MINOR UNITS
+	IGST         Money   `json:"igst"`         // INTEGER type - stored in MINOR UNITS
+}
+
+// Tax returns the GST charged on the invoice
+func (inv *Invoice) Tax() Money {
+	return inv.CGST + inv.SGST + inv.IGST
+}
+
+// Total returns the item's taxable value plus the tax on it
+func (item *InvoiceItem) Total() Money {
+	return item.TaxableValue + item.CGST + item.SGST + item.IGST
+}
+
+// InterState reports whether the supply crosses states and so attracts IGST
+func (inv *Invoice) InterState() bool {
+	return inv.SupplierState != nil && inv.PlaceOfSupply != nil && *inv.SupplierState != *inv.PlaceOfSupply
+}
+
+func (inv *Invoice) fields() []interface{} {
+	return []interface{}{
+		&inv.ID, &inv.ProjectID, &inv.Number, &inv.FinancialYear, &inv.Status,
+		&inv.ClientID, &inv.ClientName, &inv.BillingAddress, &inv.Currency, &inv.Total,
+		&inv.IssueDate, &inv.DueDate, &inv.Notes, &inv.CreatedAt,
+		&inv.SentAt, &inv.PaidAt, &inv.VoidedAt, &inv.VoidReason,
+		&inv.TaxInclusive, &inv.SupplierGSTIN, &inv.SupplierState, &inv.ClientGSTIN, &inv.PlaceOfSupply,
+		&inv.Subtotal, &inv.TaxableValue, &inv.CGST, &inv.SGST, &inv.IGST,
+	}
+}
+
+// Scan reads an invoice without its items
+func (inv *Invoice) Scan(row *sql.Row) error {
+	return row.Scan(inv.fields()...)
+}
+
+// ScanRows reads an invoice without its items
+func (inv *Invoice) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(inv.fields()...)
+}
+
+// FinancialYear returns the Indian financial year, April to March, that t
+// falls in, written as 2025-26
+func FinancialYear(t time.Time) string {
+	start := t.Year()
+	if t.Month() < time.April {
+		start--
+	}
+	return fmt.Sprintf("%d-%02d", start, (start+1)%100)
+}
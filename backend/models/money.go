@@ -0,0 +1,95 @@
+package models
+
+import (
+	"errors"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Money is an amount in minor currency units (paise, cents). It is stored as an
+// INTEGER column and encoded in JSON as a decimal number of major units, so
+// 123450 paise is sent and received as 1234.5.
+type Money int64
+
+// ErrInvalidMoney is returned for amounts that are not plain numbers or that
+// carry a fraction smaller than one paisa
+var ErrInvalidMoney = errors.New("amounts must be numbers with at most 2 decimal places")
+
+var hundred = big.NewRat(100, 1)
+
+// ParseMoney converts a decimal string such as "1234.50" into Money without
+// going through float64, rejecting anything finer than a paisa
+func ParseMoney(s string) (Money, error) {
+	if strings.ContainsAny(s, "/ ") {
+		return 0, ErrInvalidMoney
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, ErrInvalidMoney
+	}
+
+	r.Mul(r, hundred)
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return 0, ErrInvalidMoney
+	}
+	return Money(r.Num().Int64()), nil
+}
+
+// String formats the amount in major units with no trailing zeros, e.g. "1234.5"
+func (m Money) String() string {
+	sign := ""
+	paise := int64(m)
+	if paise < 0 {
+		sign = "-"
+		paise = -paise
+	}
+
+	s := sign + strconv.FormatInt(paise/100, 10)
+	if frac := paise % 100; frac != 0 {
+		s += strings.TrimRight("."+strconv.FormatInt(100+frac, 10)[1:], "0")
+	}
+	return s
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+func (m *Money) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
+	}
+	if strings.HasPrefix(s, `"`) {
+		return ErrInvalidMoney
+	}
+
+	parsed, err := ParseMoney(s)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// Convert multiplies m by rate, rounding half away from zero to the nearest
+// minor unit
+func (m Money) Convert(rate *big.Rat) Money {
+	r := new(big.Rat).Mul(big.NewRat(int64(m), 1), rate)
+
+	num := new(big.Int).Set(r.Num())
+	den := r.Denom()
+	neg := num.Sign() < 0
+	num.Abs(num)
+
+	q, rem := new(big.Int).QuoRem(num, den, new(big.Int))
+	if rem.Lsh(rem, 1).Cmp(den) >= 0 {
+		q.Add(q, big.NewInt(1))
+	}
+	if neg {
+		q.Neg(q)
+	}
+	return Money(q.Int64())
+}
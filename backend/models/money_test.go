@@ -0,0 +1,121 @@
+package models
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{"0", 0, false},
+		{"1234", 123400, false},
+		{"1234.5", 123450, false},
+		{"1234.50", 123450, false},
+		{"0.01", 1, false},
+		{"-12.34", -1234, false},
+		{"1e2", 10000, false},
+		{"0.1", 10, false},
+		{"0.29", 29, false}, // 0.29*100 is 28.999… as a float64
+		{"92233720368547758.07", 9223372036854775807, false},
+
+		{"0.001", 0, true},
+		{"1.005", 0, true},
+		{"92233720368547758.08", 0, true},
+		{"", 0, true},
+		{"abc", 0, true},
+		{"1/2", 0, true},
+		{"1 000", 0, true},
+		{"12,50", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMoney(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseMoney(%q) = %d, want %d", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{0, "0"},
+		{5, "0.05"},
+		{50, "0.5"},
+		{123400, "1234"},
+		{123450, "1234.5"},
+		{123456, "1234.56"},
+		{-1, "-0.01"},
+		{-123450, "-1234.5"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("Money(%d).String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyJSON(t *testing.T) {
+	var v struct {
+		Amount Money `json:"amount"`
+	}
+
+	if err := json.Unmarshal([]byte(`{"amount": 1234.56}`), &v); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if v.Amount != 123456 {
+		t.Errorf("unmarshalled %d, want 123456", v.Amount)
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+	if string(raw) != `{"amount":1234.56}` {
+		t.Errorf("marshalled %s", raw)
+	}
+
+	for _, body := range []string{`{"amount": "12.5"}`, `{"amount": 0.001}`} {
+		if err := json.Unmarshal([]byte(body), &v); err == nil {
+			t.Errorf("unmarshal %s: expected an error", body)
+		}
+	}
+}
+
+func TestMoneyConvert(t *testing.T) {
+	tests := []struct {
+		amount Money
+		rate   string
+		want   Money
+	}{
+		{10000, "83.25", 832500},
+		{1, "0.5", 1},   // Half a cent rounds up
+		{1, "0.49", 0},  // Under half rounds down
+		{-1, "0.5", -1}, // and away from zero for refunds
+		{12345, "1", 12345},
+		{100, "0.012", 1},
+		{999999, "83.123456", 83123373},
+	}
+
+	for _, tt := range tests {
+		rate, ok := new(big.Rat).SetString(tt.rate)
+		if !ok {
+			t.Fatalf("bad rate %s", tt.rate)
+		}
+		if got := tt.amount.Convert(rate); got != tt.want {
+			t.Errorf("Money(%d).Convert(%s) = %d, want %d", tt.amount, tt.rate, got, tt.want)
+		}
+	}
+}
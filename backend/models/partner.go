@@ -0,0 +1,40 @@
+package models
+
+import "database/sql"
+
+// Partner is an internal collaborator who receives a share of project revenue.
+type Partner struct {
+	ID        string  `json:"id"`
+	Name      string  `json:"name"`
+	Email     *string `json:"email,omitempty"`
+	Notes     *string `json:"notes,omitempty"`
+	CreatedAt string  `json:"createdAt"` // ISO 8601 format (RFC3339)
+}
+
+func (p *Partner) Scan(row *sql.Row) error {
+	return row.Scan(&p.ID, &p.Name, &p.Email, &p.Notes, &p.CreatedAt)
+}
+
+func (p *Partner) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(&p.ID, &p.Name, &p.Email, &p.Notes, &p.CreatedAt)
+}
+
+// PartnerPayout records a share paid out to a partner for a project.
+// Payouts are internal splits and never affect the project's client totals.
+type PartnerPayout struct {
+	ID        string  `json:"id"`
+	ProjectID string  `json:"projectId"`
+	PartnerID string  `json:"partnerId"`
+	Amount    Money   `json:"amount"`         // INTEGER type - stored in MINOR UNITS
+	Date      *string `json:"date,omitempty"` // ISO 8601 format (YYYY-MM-DD or RFC3339)
+	Note      *string `json:"note,omitempty"`
+	CreatedAt string  `json:"createdAt"` // ISO 8601 format (RFC3339)
+}
+
+func (p *PartnerPayout) Scan(row *sql.Row) error {
+	return row.Scan(&p.ID, &p.ProjectID, &p.PartnerID, &p.Amount, &p.Date, &p.Note, &p.CreatedAt)
+}
+
+func (p *PartnerPayout) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(&p.ID, &p.ProjectID, &p.PartnerID, &p.Amount, &p.Date, &p.Note, &p.CreatedAt)
+}
@@ -0,0 +1,60 @@
+package models
+
+import "database/sql"
+
+// Payment is a single client installment recorded against a project.
+// Amount is what was actually received; a client deducting tax at source
+// pays TDSAmount less and credits it to us instead. The payment settles
+// GrossAmount, their sum, of the project total: the project's TotalReceived
+// is the sum of its payments' gross amounts and is maintained by the backend
+// whenever the ledger changes.
+type Payment struct {
+	ID          string  `json:"id"`
+	ProjectID   string  `json:"projectId"`
+	Amount      Money   `json:"amount"`               // INTEGER type - stored in MINOR UNITS
+	TDSAmount   Money   `json:"tdsAmount"`            // INTEGER type - stored in MINOR UNITS
+	TDSSection  *string `json:"tdsSection,omitempty"` // e.g. 194J, required with a TDSAmount
+	GrossAmount Money   `json:"grossAmount"`          // Amount + TDSAmount, never stored
+	Currency    string  `json:"currency"`             // Always the project's currency
+	Date        string  `json:"date"`                 // ISO 8601 format (YYYY-MM-DD or RFC3339)
+	Method      *string `json:"method,omitempty"`
+	Reference   *string `json:"reference,omitempty"`
+	Note        *string `json:"note,omitempty"`
+	CreatedAt   string  `json:"createdAt"` // ISO 8601 format (RFC3339)
+}
+
+func (p *Payment) Scan(row *sql.Row) error {
+	err := row.Scan(
+		&p.ID,
+		&p.ProjectID,
+		&p.Amount,
+		&p.Date,
+		&p.Method,
+		&p.Reference,
+		&p.Note,
+		&p.CreatedAt,
+		&p.Currency,
+		&p.TDSAmount,
+		&p.TDSSection,
+	)
+	p.GrossAmount = p.Amount + p.TDSAmount
+	return err
+}
+
+func (p *Payment) ScanRows(rows *sql.Rows) error {
+	err := rows.Scan(
+		&p.ID,
+		&p.ProjectID,
+		&p.Amount,
+		&p.Date,
+		&p.Method,
+		&p.Reference,
+		&p.Note,
+		&p.CreatedAt,
+		&p.Currency,
+		&p.TDSAmount,
+		&p.TDSSection,
+	)
+	p.GrossAmount = p.Amount + p.TDSAmount
+	return err
+}
@@ -5,32 +5,36 @@ import "database/sql"
 // Project represents a project in the tracker.
 //
 // IMPORTANT MONEY FIELD RULE:
-// - Money fields (TotalAmount, AdvanceReceived, TotalReceived, PartnerShareGiven) use INTEGER type
-// - Values are stored in minor units (e.g., cents, paise)
-// - Backend NEVER calculates derived amounts (e.g., dueAmount = totalAmount - totalReceived)
-// - All money calculations MUST be done in the frontend only
-// - This ensures backend remains a simple data store without business logic
+// - Money fields (TotalAmount, AdvanceReceived, TotalReceived) use the Money type
+// - Values are stored as INTEGER minor units (e.g. paise) and sent as decimals
+// - All amounts on a project and its payments are in the project's Currency
+// - TotalReceived is the sum of the project's payments, TDS included, and is maintained by the backend
+// - The backend derives dues only to compute Status and enforce transitions (see status.go)
+// - Payment gross amounts (amount + TDS) and report totals and currency conversion are computed by the backend
+// - Invoice amounts and GST (see gst.go) are computed by the backend
+// - All other money calculations MUST be done in the frontend only
 type Project struct {
-	ID                string   `json:"id"`
-	Name              string   `json:"name"`
-	ClientName        *string  `json:"clientName,omitempty"`
-	Description       *string  `json:"description,omitempty"`
-	Type              string   `json:"type"`
-	CreatedAt         string   `json:"createdAt"`                   // ISO 8601 format (RFC3339)
-	StartDate         *string  `json:"startDate,omitempty"`         // ISO 8601 format (YYYY-MM-DD or RFC3339)
-	Deadline          string   `json:"deadline"`                    // ISO 8601 format (YYYY-MM-DD or RFC3339)
-	CompletedAt       *string  `json:"completedAt,omitempty"`       // ISO 8601 format (YYYY-MM-DD or RFC3339)
-	DeliveredAt       *string  `json:"deliveredAt,omitempty"`       // ISO 8601 format (YYYY-MM-DD or RFC3339)
-	TotalAmount       float64  `json:"totalAmount"`                 // REAL type - stored in DECIMAL RUPEES
-	AdvanceReceived   float64  `json:"advanceReceived"`             // REAL type - stored in DECIMAL RUPEES
-	TotalReceived     float64  `json:"totalReceived"`               // REAL type - stored in DECIMAL RUPEES
-	PartnerShareGiven *float64 `json:"partnerShareGiven,omitempty"` // REAL type - stored in DECIMAL RUPEES
-	PartnerShareDate  *string  `json:"partnerShareDate,omitempty"`  // ISO 8601 format
-	// New Explicit Partner Shares
-	HarshkShareGiven *float64 `json:"harshkShareGiven,omitempty"` // REAL
-	HarshkShareDate  *string  `json:"harshkShareDate,omitempty"`  // ISO 8601
-	NikkuShareGiven  *float64 `json:"nikkuShareGiven,omitempty"`  // REAL
-	NikkuShareDate   *string  `json:"nikkuShareDate,omitempty"`   // ISO 8601
+	ID              string  `json:"id"`
+	Name            string  `json:"name"`
+	ClientName      *string `json:"clientName,omitempty"` // Mirrors the linked client's name when ClientID is set
+	ClientID        *string `json:"clientId,omitempty"`
+	Description     *string `json:"description,omitempty"`
+	Type            string  `json:"type"`
+	CreatedAt       string  `json:"createdAt"`             // ISO 8601 format (RFC3339)
+	StartDate       *string `json:"startDate,omitempty"`   // ISO 8601 format (YYYY-MM-DD or RFC3339)
+	Deadline        string  `json:"deadline"`              // ISO 8601 format (YYYY-MM-DD or RFC3339)
+	CompletedAt     *string `json:"completedAt,omitempty"` // ISO 8601 format (YYYY-MM-DD or RFC3339)
+	DeliveredAt     *string `json:"deliveredAt,omitempty"` // ISO 8601 format (YYYY-MM-DD or RFC3339)
+	TotalAmount     Money   `json:"totalAmount"`           // INTEGER type - stored in MINOR UNITS
+	AdvanceReceived Money   `json:"advanceReceived"`       // INTEGER type - stored in MINOR UNITS
+	TotalReceived   Money   `json:"totalReceived"`         // INTEGER type - sum of payments, read-only
+	TotalTDS        Money   `json:"totalTds"`              // INTEGER type - part of TotalReceived deducted as TDS, read-only
+	Currency        string  `json:"currency"`              // ISO 4217 code all the amounts above are in
+	Status          string  `json:"status"`                // Computed by ComputeStatus, never stored
+
+	// GST: TotalAmount either includes the tax or has it added on invoices
+	TaxInclusive bool    `json:"taxInclusive"`
+	GSTRate      Percent `json:"gstRate"` // INTEGER type - hundredths of a percent, sent as e.g. 18
 
 	CompletionVideoLink *string `json:"completionVideoLink,omitempty"`
 	CompletionNotes     *string `json:"completionNotes,omitempty"`
@@ -40,10 +44,49 @@ type Project struct {
 	TechStack           *string `json:"techStack,omitempty"`
 	Deliverables        *string `json:"deliverables,omitempty"`
 	InternalNotes       *string `json:"internalNotes,omitempty"`
+
+	// Delivery escrow: links are redacted until the project is fully paid or
+	// manually released (see RedactLinks)
+	LinksReleasedAt *string `json:"linksReleasedAt,omitempty"` // ISO 8601 format (RFC3339)
+	LinksReleasedBy *string `json:"linksReleasedBy,omitempty"`
+	LinksRedacted   bool    `json:"linksRedacted"` // Computed, never stored
+
+	// Version increases with every change to the project; it is the ETag
+	Version int64 `json:"version"`
+
+	DeletedAt *string `json:"deletedAt,omitempty"` // Set while the project is in the trash (RFC3339)
 }
 
+// LinksReleased reports whether the delivery links may be shown: either the
+// client has paid in full or someone has recorded a manual release.
+func (p *Project) LinksReleased() bool {
+	return p.DueAmount() == 0 || p.LinksReleasedAt != nil
+}
+
+// RedactLinks clears the repo, live and video links unless they have been released
+func (p *Project) RedactLinks() {
+	if p.LinksReleased() {
+		return
+	}
+	p.ClearLinks()
+}
+
+// ClearLinks clears the repo, live and video links and marks them redacted
+func (p *Project) ClearLinks() {
+	p.RepoLink = nil
+	p.LiveLink = nil
+	p.CompletionVideoLink = nil
+	p.LinksRedacted = true
+}
+
+// ProjectColumns lists the projects table columns in the order Scan reads them
+const ProjectColumns = `id, name, clientName, description, type, createdAt, startDate, deadline,
+	completedAt, deliveredAt, totalAmount, advanceReceived, totalReceived,
+	completionVideoLink, completionNotes, repoLink, liveLink, deliveryNotes,
+	techStack, deliverables, internalNotes, linksReleasedAt, linksReleasedBy, currency, clientId, version, deletedAt, taxInclusive, gstRate, totalTds`
+
 func (p *Project) Scan(row *sql.Row) error {
-	return row.Scan(
+	err := row.Scan(
 		&p.ID,
 		&p.Name,
 		&p.ClientName,
@@ -57,12 +100,6 @@ func (p *Project) Scan(row *sql.Row) error {
 		&p.TotalAmount,
 		&p.AdvanceReceived,
 		&p.TotalReceived,
-		&p.PartnerShareGiven,
-		&p.PartnerShareDate,
-		&p.HarshkShareGiven, // New
-		&p.HarshkShareDate,  // New
-		&p.NikkuShareGiven,  // New
-		&p.NikkuShareDate,   // New
 		&p.CompletionVideoLink,
 		&p.CompletionNotes,
 		&p.RepoLink,
@@ -71,11 +108,26 @@ func (p *Project) Scan(row *sql.Row) error {
 		&p.TechStack,
 		&p.Deliverables,
 		&p.InternalNotes,
+		&p.LinksReleasedAt,
+		&p.LinksReleasedBy,
+		&p.Currency,
+		&p.ClientID,
+		&p.Version,
+		&p.DeletedAt,
+		&p.TaxInclusive,
+		&p.GSTRate,
+		&p.TotalTDS,
 	)
+	if err != nil {
+		return err
+	}
+
+	p.Status = p.ComputeStatus()
+	return nil
 }
 
 func (p *Project) ScanRows(rows *sql.Rows) error {
-	return rows.Scan(
+	err := rows.Scan(
 		&p.ID,
 		&p.Name,
 		&p.ClientName,
@@ -89,12 +141,6 @@ func (p *Project) ScanRows(rows *sql.Rows) error {
 		&p.TotalAmount,
 		&p.AdvanceReceived,
 		&p.TotalReceived,
-		&p.PartnerShareGiven,
-		&p.PartnerShareDate,
-		&p.HarshkShareGiven, // New
-		&p.HarshkShareDate,  // New
-		&p.NikkuShareGiven,  // New
-		&p.NikkuShareDate,   // New
 		&p.CompletionVideoLink,
 		&p.CompletionNotes,
 		&p.RepoLink,
@@ -103,5 +149,20 @@ func (p *Project) ScanRows(rows *sql.Rows) error {
 		&p.TechStack,
 		&p.Deliverables,
 		&p.InternalNotes,
+		&p.LinksReleasedAt,
+		&p.LinksReleasedBy,
+		&p.Currency,
+		&p.ClientID,
+		&p.Version,
+		&p.DeletedAt,
+		&p.TaxInclusive,
+		&p.GSTRate,
+		&p.TotalTDS,
 	)
+	if err != nil {
+		return err
+	}
+
+	p.Status = p.ComputeStatus()
+	return nil
 }
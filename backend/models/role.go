@@ -0,0 +1,50 @@
+package models
+
+// ValidRole reports whether role is one of the user roles
+func ValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleManager, RoleViewer, RolePartner:
+		return true
+	}
+	return false
+}
+
+// partnerEditableFields are the project fields a partner may change: progress
+// and delivery details, but nothing about money or the client
+var partnerEditableFields = map[string]bool{
+	"description":         true,
+	"startDate":           true,
+	"completedAt":         true,
+	"completionVideoLink": true,
+	"completionNotes":     true,
+	"repoLink":            true,
+	"liveLink":            true,
+	"deliveryNotes":       true,
+	"techStack":           true,
+	"deliverables":        true,
+}
+
+// CanEditProjectField reports whether role may change the given project JSON field
+func CanEditProjectField(role, field string) bool {
+	switch role {
+	case RoleAdmin, RoleManager:
+		return true
+	case RolePartner:
+		return partnerEditableFields[field]
+	}
+	return false
+}
+
+// CanSeeInternal reports whether role may see internal notes, partner payouts
+// and the audit trail
+func CanSeeInternal(role string) bool {
+	return role == RoleAdmin || role == RoleManager
+}
+
+// RedactForRole clears the fields the given role may not see. Call it on
+// every project before it is sent to a client.
+func (p *Project) RedactForRole(role string) {
+	if !CanSeeInternal(role) {
+		p.InternalNotes = nil
+	}
+}
@@ -0,0 +1,110 @@
+package models
+
+import (
+	"errors"
+	"strings"
+)
+
+// Project statuses, mirroring the lifecycle shown in the frontend
+const (
+	StatusNotStarted     = "Not Started"
+	StatusInProgress     = "In Progress"
+	StatusPaymentPending = "Completed (Payment Pending)"
+	StatusReadyToDeliver = "Ready to Deliver"
+	StatusDelivered      = "Delivered"
+)
+
+// DueAmount returns the amount still owed by the client, never below zero.
+// The status engine needs it to gate completion and delivery.
+func (p *Project) DueAmount() Money {
+	due := p.TotalAmount - p.TotalReceived
+	if due < 0 {
+		return 0
+	}
+	return due
+}
+
+// ComputeStatus derives the project status from its dates and payments
+func (p *Project) ComputeStatus() string {
+	switch {
+	case p.DeliveredAt != nil:
+		return StatusDelivered
+	case p.CompletedAt != nil && p.DueAmount() == 0:
+		return StatusReadyToDeliver
+	case p.CompletedAt != nil:
+		return StatusPaymentPending
+	case p.TotalReceived > 0:
+		return StatusInProgress
+	default:
+		return StatusNotStarted
+	}
+}
+
+// MissingCompletionRequirements lists the details a project needs before it can be completed
+func (p *Project) MissingCompletionRequirements() []string {
+	missing := []string{}
+	if isBlank(p.ClientName) {
+		missing = append(missing, "client name")
+	}
+	if isBlankList(p.TechStack) {
+		missing = append(missing, "tech stack")
+	}
+	if isBlankList(p.Deliverables) {
+		missing = append(missing, "deliverables")
+	}
+	return missing
+}
+
+// MissingDeliveryRequirements lists the delivery artifacts a project needs before it can be delivered
+func (p *Project) MissingDeliveryRequirements() []string {
+	missing := []string{}
+	if isBlank(p.RepoLink) {
+		missing = append(missing, "repository link")
+	}
+	if isBlank(p.LiveLink) {
+		missing = append(missing, "live link")
+	}
+	if isBlank(p.CompletionVideoLink) {
+		missing = append(missing, "completion video")
+	}
+	return missing
+}
+
+// ValidateTransition checks that moving a project from old to updated respects
+// the escrow rules. Use a zero Project as old when validating a new project.
+func ValidateTransition(old, updated *Project) error {
+	if updated.DeliveredAt != nil && updated.CompletedAt == nil {
+		return errors.New("A delivered project must have a completion date")
+	}
+
+	if updated.CompletedAt != nil && old.CompletedAt == nil {
+		if missing := updated.MissingCompletionRequirements(); len(missing) > 0 {
+			return errors.New("Cannot complete project without " + strings.Join(missing, ", "))
+		}
+	}
+
+	if updated.DeliveredAt != nil && old.DeliveredAt == nil {
+		if updated.DueAmount() > 0 {
+			return errors.New("Cannot deliver project while payment is due")
+		}
+		if missing := updated.MissingDeliveryRequirements(); len(missing) > 0 {
+			return errors.New("Cannot deliver project without " + strings.Join(missing, ", "))
+		}
+	}
+
+	return nil
+}
+
+func isBlank(s *string) bool {
+	return s == nil || strings.TrimSpace(*s) == ""
+}
+
+// isBlankList treats nil, empty strings and empty JSON arrays as blank, since
+// list fields such as techStack are stored as JSON-encoded strings
+func isBlankList(s *string) bool {
+	if isBlank(s) {
+		return true
+	}
+	v := strings.TrimSpace(*s)
+	return v == "[]" || v == "null"
+}
@@ -0,0 +1,73 @@
+package models
+
+import "testing"
+
+func strPtr(s string) *string { return &s }
+
+func TestComputeStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		project Project
+		want    string
+	}{
+		{"nothing received", Project{TotalAmount: 1000}, StatusNotStarted},
+		{"part paid", Project{TotalAmount: 1000, TotalReceived: 100}, StatusInProgress},
+		{"completed with dues", Project{TotalAmount: 1000, TotalReceived: 100, CompletedAt: strPtr("2026-01-01")}, StatusPaymentPending},
+		{"completed and paid", Project{TotalAmount: 1000, TotalReceived: 1000, CompletedAt: strPtr("2026-01-01")}, StatusReadyToDeliver},
+		{"completed and overpaid", Project{TotalAmount: 1000, TotalReceived: 1200, CompletedAt: strPtr("2026-01-01")}, StatusReadyToDeliver},
+		{"delivered", Project{TotalAmount: 1000, TotalReceived: 1000, CompletedAt: strPtr("2026-01-01"), DeliveredAt: strPtr("2026-01-02")}, StatusDelivered},
+	}
+
+	for _, tt := range tests {
+		if got := tt.project.ComputeStatus(); got != tt.want {
+			t.Errorf("%s: ComputeStatus() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	ready := Project{
+		TotalAmount:         1000,
+		TotalReceived:       1000,
+		ClientName:          strPtr("Acme"),
+		TechStack:           strPtr(`["Go"]`),
+		Deliverables:        strPtr(`["API"]`),
+		RepoLink:            strPtr("https://example.com/repo"),
+		LiveLink:            strPtr("https://example.com"),
+		CompletionVideoLink: strPtr("https://example.com/video"),
+	}
+	completed := ready
+	completed.CompletedAt = strPtr("2026-01-01")
+	delivered := completed
+	delivered.DeliveredAt = strPtr("2026-01-02")
+
+	with := func(p Project, change func(*Project)) *Project {
+		change(&p)
+		return &p
+	}
+
+	tests := []struct {
+		name     string
+		old, new *Project
+		ok       bool
+	}{
+		{"complete when ready", &ready, &completed, true},
+		{"deliver when paid", &completed, &delivered, true},
+		{"edit a delivered project", &delivered, with(delivered, func(p *Project) { p.Name = "Renamed" }), true},
+		{"complete without a client", &ready, with(completed, func(p *Project) { p.ClientName = strPtr(" ") }), false},
+		{"complete with an empty tech stack", &ready, with(completed, func(p *Project) { p.TechStack = strPtr("[]") }), false},
+		{"complete without deliverables", &ready, with(completed, func(p *Project) { p.Deliverables = nil }), false},
+		{"deliver with dues", &completed, with(delivered, func(p *Project) { p.TotalReceived = 900 }), false},
+		{"deliver without a live link", &completed, with(delivered, func(p *Project) { p.LiveLink = nil }), false},
+		{"deliver without completing", &ready, with(delivered, func(p *Project) { p.CompletedAt = nil }), false},
+		{"create already delivered", &Project{}, &delivered, true},
+		{"create delivered with dues", &Project{}, with(delivered, func(p *Project) { p.TotalReceived = 0 }), false},
+	}
+
+	for _, tt := range tests {
+		err := ValidateTransition(tt.old, tt.new)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: ValidateTransition() = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tdsSectionPattern matches an Income Tax Act section under which TDS is
+// deducted, such as 194C, 194J or 194-I(b)
+var tdsSectionPattern = regexp.MustCompile(`^19[2-6]-?[A-Z]{0,2}(\([a-z]\))?$`)
+
+// NormalizeTDSSection trims a TDS section and upper-cases its letters, leaving
+// a sub-clause such as (b) as written, and reports whether it is well formed
+func NormalizeTDSSection(s string) (string, bool) {
+	section := strings.TrimSpace(s)
+	if i := strings.Index(section, "("); i >= 0 {
+		section = strings.ToUpper(section[:i]) + strings.ToLower(section[i:])
+	} else {
+		section = strings.ToUpper(section)
+	}
+	return section, tdsSectionPattern.MatchString(section)
+}
+
+// tanPattern matches a TAN: four letters, five digits and a check letter
+var tanPattern = regexp.MustCompile(`^[A-Z]{4}\d{5}[A-Z]$`)
+
+// NormalizeTAN upper-cases and trims a tax deduction account number,
+// reporting whether the result is well formed
+func NormalizeTAN(s string) (string, bool) {
+	tan := strings.ToUpper(strings.TrimSpace(s))
+	return tan, tanPattern.MatchString(tan)
+}
+
+// TDSQuarter returns the financial year and quarter, 1 (April to June) to 4
+// (January to March), that t falls in, as TDS returns are filed
+func TDSQuarter(t time.Time) (string, int) {
+	return FinancialYear(t), (int(t.Month())+8)%12/3 + 1
+}
+
+// TDSQuarterDates returns the first and last dates (YYYY-MM-DD) of a quarter
+// of a financial year written as 2025-26, or ok=false if either is invalid
+func TDSQuarterDates(financialYear string, quarter int) (from, to string, ok bool) {
+	var start int
+	if _, err := fmt.Sscanf(financialYear, "%4d-", &start); err != nil || FinancialYear(time.Date(start, time.April, 1, 0, 0, 0, 0, time.UTC)) != financialYear {
+		return "", "", false
+	}
+	if quarter < 1 || quarter > 4 {
+		return "", "", false
+	}
+	first := time.Date(start, time.April+time.Month(3*(quarter-1)), 1, 0, 0, 0, 0, time.UTC)
+	last := first.AddDate(0, 3, -1)
+	return first.Format("2006-01-02"), last.Format("2006-01-02"), true
+}
@@ -0,0 +1,93 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTDSQuarterDates(t *testing.T) {
+	tests := []struct {
+		financialYear string
+		quarter       int
+		from, to      string
+		ok            bool
+	}{
+		{"2026-27", 1, "2026-04-01", "2026-06-30", true},
+		{"2026-27", 2, "2026-07-01", "2026-09-30", true},
+		{"2026-27", 3, "2026-10-01", "2026-12-31", true},
+		{"2026-27", 4, "2027-01-01", "2027-03-31", true},
+		{"2027-28", 4, "2028-01-01", "2028-03-31", true},
+		{"1999-00", 1, "1999-04-01", "1999-06-30", true},
+
+		{"2026-27", 0, "", "", false},
+		{"2026-27", 5, "", "", false},
+		{"2026-28", 1, "", "", false},
+		{"2026", 1, "", "", false},
+		{"2026-2027", 1, "", "", false},
+		{"FY26-27", 1, "", "", false},
+		{"", 1, "", "", false},
+	}
+
+	for _, tt := range tests {
+		from, to, ok := TDSQuarterDates(tt.financialYear, tt.quarter)
+		if from != tt.from || to != tt.to || ok != tt.ok {
+			t.Errorf("TDSQuarterDates(%q, %d) = %q, %q, %v, want %q, %q, %v",
+				tt.financialYear, tt.quarter, from, to, ok, tt.from, tt.to, tt.ok)
+		}
+	}
+}
+
+func TestTDSQuarter(t *testing.T) {
+	tests := []struct {
+		date          string
+		financialYear string
+		quarter       int
+	}{
+		{"2026-04-01", "2026-27", 1},
+		{"2026-06-30", "2026-27", 1},
+		{"2026-07-01", "2026-27", 2},
+		{"2026-12-31", "2026-27", 3},
+		{"2027-01-01", "2026-27", 4},
+		{"2027-03-31", "2026-27", 4},
+	}
+
+	for _, tt := range tests {
+		day, _ := time.Parse("2006-01-02", tt.date)
+		fy, q := TDSQuarter(day)
+		if fy != tt.financialYear || q != tt.quarter {
+			t.Errorf("TDSQuarter(%s) = %s Q%d, want %s Q%d", tt.date, fy, q, tt.financialYear, tt.quarter)
+		}
+
+		// Every day falls inside the dates of its own quarter
+		from, to, ok := TDSQuarterDates(fy, q)
+		if !ok || tt.date < from || tt.date > to {
+			t.Errorf("%s is outside its quarter %s to %s", tt.date, from, to)
+		}
+	}
+}
+
+func TestNormalizeTDSSection(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{"194J", "194J", true},
+		{" 194j ", "194J", true},
+		{"194C", "194C", true},
+		{"194-I(b)", "194-I(b)", true},
+		{"194-i(B)", "194-I(b)", true},
+		{"192", "192", true},
+		{"191", "191", false},
+		{"197A", "197A", false},
+		{"194JJJ", "194JJJ", false},
+		{"", "", false},
+	}
+
+	for _, tt := range tests {
+		got, ok := NormalizeTDSSection(tt.in)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("NormalizeTDSSection(%q) = %q, %v, want %q, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"database/sql"
+	"errors"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// User roles, see role.go for what each may see and change
+const (
+	RoleAdmin   = "admin"   // Everything, including user management
+	RoleManager = "manager" // Every project, ledger and client change
+	RoleViewer  = "viewer"  // Read-only, without internal notes or partner payouts
+	RolePartner = "partner" // Read-only except delivery fields; sees only their own payouts
+)
+
+// MinPasswordLength is the shortest password accepted for a user
+const MinPasswordLength = 8
+
+// User is a person who can sign in. PasswordHash is a bcrypt hash and is never
+// sent to clients.
+type User struct {
+	ID           string  `json:"id"`
+	Email        string  `json:"email"`
+	Name         string  `json:"name"`
+	PasswordHash string  `json:"-"`
+	Role         string  `json:"role"`
+	PartnerID    *string `json:"partnerId,omitempty"` // Set for the partner role
+	CreatedAt    string  `json:"createdAt"`           // ISO 8601 format (RFC3339)
+}
+
+func (u *User) Scan(row *sql.Row) error {
+	return row.Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.PartnerID)
+}
+
+func (u *User) ScanRows(rows *sql.Rows) error {
+	return rows.Scan(&u.ID, &u.Email, &u.Name, &u.PasswordHash, &u.Role, &u.CreatedAt, &u.PartnerID)
+}
+
+// HashPassword returns a bcrypt hash of password, enforcing the minimum length
+func HashPassword(password string) (string, error) {
+	if len(password) < MinPasswordLength {
+		return "", errors.New("password must be at least 8 characters")
+	}
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash), err
+}
+
+// CheckPassword reports whether password matches the user's hash
+func (u *User) CheckPassword(password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(password)) == nil
+}
@@ -0,0 +1,83 @@
+package models
+
+import "strings"
+
+// currencyWords names the major and minor units of each supported currency,
+// as written on invoices
+var currencyWords = map[string][2]string{
+	"INR": {"Indian Rupees", "Paise"},
+	"USD": {"US Dollars", "Cents"},
+	"EUR": {"Euros", "Cents"},
+	"GBP": {"Pounds Sterling", "Pence"},
+	"AUD": {"Australian Dollars", "Cents"},
+	"CAD": {"Canadian Dollars", "Cents"},
+	"SGD": {"Singapore Dollars", "Cents"},
+	"AED": {"UAE Dirhams", "Fils"},
+	"CHF": {"Swiss Francs", "Rappen"},
+	"NZD": {"New Zealand Dollars", "Cents"},
+}
+
+var smallNumberWords = []string{
+	"Zero", "One", "Two", "Three", "Four", "Five", "Six", "Seven", "Eight", "Nine", "Ten",
+	"Eleven", "Twelve", "Thirteen", "Fourteen", "Fifteen", "Sixteen", "Seventeen", "Eighteen", "Nineteen",
+}
+
+var tensWords = []string{"", "", "Twenty", "Thirty", "Forty", "Fifty", "Sixty", "Seventy", "Eighty", "Ninety"}
+
+// AmountInWords spells out an amount as invoices print it, e.g. "Indian Rupees
+// One Lakh Eighteen Thousand and Fifty Paise Only". Rupees are counted in
+// lakhs and crores, other currencies in thousands and millions.
+func AmountInWords(m Money, currency string) string {
+	units, ok := currencyWords[currency]
+	if !ok {
+		units = [2]string{currency, "Hundredths"}
+	}
+
+	minor := int64(m)
+	sign := ""
+	if minor < 0 {
+		sign = "Minus "
+		minor = -minor
+	}
+
+	major := numberInWords(minor/100, currency == "INR")
+	words := units[0] + " " + sign + major
+	if frac := minor % 100; frac != 0 {
+		words += " and " + numberInWords(frac, false) + " " + units[1]
+	}
+	return words + " Only"
+}
+
+// numberInWords spells out n, grouping in lakhs and crores when indian is set
+func numberInWords(n int64, indian bool) string {
+	if n < 20 {
+		return smallNumberWords[n]
+	}
+
+	type scale struct {
+		size int64
+		name string
+	}
+	scales := []scale{{1_000_000_000_000, "Trillion"}, {1_000_000_000, "Billion"}, {1_000_000, "Million"}, {1000, "Thousand"}, {100, "Hundred"}}
+	if indian {
+		scales = []scale{{10_000_000, "Crore"}, {100_000, "Lakh"}, {1000, "Thousand"}, {100, "Hundred"}}
+	}
+
+	parts := []string{}
+	for _, s := range scales {
+		if n >= s.size {
+			parts = append(parts, numberInWords(n/s.size, indian)+" "+s.name)
+			n %= s.size
+		}
+	}
+	if n >= 20 {
+		tens := tensWords[n/10]
+		if n%10 != 0 {
+			tens += "-" + smallNumberWords[n%10]
+		}
+		parts = append(parts, tens)
+	} else if n > 0 {
+		parts = append(parts, smallNumberWords[n])
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestAmountInWords(t *testing.T) {
+	tests := []struct {
+		amount   Money
+		currency string
+		want     string
+	}{
+		{0, "INR", "Indian Rupees Zero Only"},
+		{100, "INR", "Indian Rupees One Only"},
+		{1900, "INR", "Indian Rupees Nineteen Only"},
+		{2100, "INR", "Indian Rupees Twenty-One Only"},
+		{10000, "INR", "Indian Rupees One Hundred Only"},
+		{11800000, "INR", "Indian Rupees One Lakh Eighteen Thousand Only"},
+		{11800050, "INR", "Indian Rupees One Lakh Eighteen Thousand and Fifty Paise Only"},
+		{1234567800, "INR", "Indian Rupees One Crore Twenty-Three Lakh Forty-Five Thousand Six Hundred Seventy-Eight Only"},
+		{100000000000, "INR", "Indian Rupees One Hundred Crore Only"},
+		{-500, "INR", "Indian Rupees Minus Five Only"},
+		{123456789, "USD", "US Dollars One Million Two Hundred Thirty-Four Thousand Five Hundred Sixty-Seven and Eighty-Nine Cents Only"},
+		{10000000, "GBP", "Pounds Sterling One Hundred Thousand Only"},
+		{150, "XYZ", "XYZ One and Fifty Hundredths Only"},
+	}
+
+	for _, tt := range tests {
+		if got := AmountInWords(tt.amount, tt.currency); got != tt.want {
+			t.Errorf("AmountInWords(%d, %s) = %q, want %q", tt.amount, tt.currency, got, tt.want)
+		}
+	}
+}
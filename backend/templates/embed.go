@@ -0,0 +1,9 @@
+// Package templates holds the default document templates, such as the invoice
+// rendered by handlers.RenderInvoiceHTML. Each can be replaced at startup with
+// a branded copy.
+package templates
+
+import "embed"
+
+//go:embed *.html
+var FS embed.FS
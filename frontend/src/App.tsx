@@ -3,6 +3,7 @@ import { BrowserRouter as Router, Routes, Route, Navigate, useLocation } from 'r
 import { AnimatePresence } from 'motion/react';
 import { PageTransition } from './components/PageTransition';
 import { ProjectProvider } from './context/ProjectContext';
+import { AuthProvider, useAuth } from './context/AuthContext';
 import { ToastProvider, useToast } from './context/ToastContext';
 import { Toaster } from './components/ui/toaster';
 
@@ -10,6 +11,7 @@ import { Toaster } from './components/ui/toaster';
 const ProjectList = lazy(() => import('./pages/ProjectList').then(module => ({ default: module.ProjectList })));
 const ProjectDetail = lazy(() => import('./pages/ProjectDetail').then(module => ({ default: module.ProjectDetail })));
 const ProjectForm = lazy(() => import('./pages/ProjectForm').then(module => ({ default: module.ProjectForm })));
+const Login = lazy(() => import('./pages/Login').then(module => ({ default: module.Login })));
 
 function ProjectProviderWithToast({ children }: { children: React.ReactNode }) {
   const toast = useToast();
@@ -24,6 +26,20 @@ function LoadingSpinner() {
   );
 }
 
+// RequireAuth sends visitors without a session to the login page
+function RequireAuth({ children }: { children: React.ReactNode }) {
+  const { user, checking } = useAuth();
+  const location = useLocation();
+
+  if (checking) {
+    return <LoadingSpinner />;
+  }
+  if (!user) {
+    return <Navigate to="/login" replace state={{ from: location.pathname }} />;
+  }
+  return <>{children}</>;
+}
+
 function AppRoutes() {
   const location = useLocation();
 
@@ -31,26 +47,39 @@ function AppRoutes() {
     <AnimatePresence mode="wait">
       <Suspense fallback={<LoadingSpinner />}>
         <Routes location={location} key={location.pathname}>
-          <Route path="/" element={<Navigate to="/projects" replace />} />
-          <Route path="/projects" element={
+          <Route path="/login" element={
             <PageTransition>
-              <ProjectList />
+              <Login />
             </PageTransition>
           } />
+          <Route path="/" element={<Navigate to="/projects" replace />} />
+          <Route path="/projects" element={
+            <RequireAuth>
+              <PageTransition>
+                <ProjectList />
+              </PageTransition>
+            </RequireAuth>
+          } />
           <Route path="/projects/new" element={
-            <PageTransition>
-              <ProjectForm />
-            </PageTransition>
+            <RequireAuth>
+              <PageTransition>
+                <ProjectForm />
+              </PageTransition>
+            </RequireAuth>
           } />
           <Route path="/projects/:id" element={
-            <PageTransition>
-              <ProjectDetail />
-            </PageTransition>
+            <RequireAuth>
+              <PageTransition>
+                <ProjectDetail />
+              </PageTransition>
+            </RequireAuth>
           } />
           <Route path="/projects/:id/edit" element={
-            <PageTransition>
-              <ProjectForm />
-            </PageTransition>
+            <RequireAuth>
+              <PageTransition>
+                <ProjectForm />
+              </PageTransition>
+            </RequireAuth>
           } />
           <Route path="*" element={<Navigate to="/" replace />} />
         </Routes>
@@ -62,12 +91,14 @@ function AppRoutes() {
 function App() {
   return (
     <ToastProvider>
-      <ProjectProviderWithToast>
-        <Router future={{ v7_startTransition: true, v7_relativeSplatPath: true }}>
-          <AppRoutes />
-        </Router>
-        <Toaster />
-      </ProjectProviderWithToast>
+      <AuthProvider>
+        <ProjectProviderWithToast>
+          <Router future={{ v7_startTransition: true, v7_relativeSplatPath: true }}>
+            <AppRoutes />
+          </Router>
+          <Toaster />
+        </ProjectProviderWithToast>
+      </AuthProvider>
     </ToastProvider>
   );
 }
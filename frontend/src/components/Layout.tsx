@@ -1,6 +1,9 @@
 import { ReactNode } from 'react';
 import { Link } from 'react-router-dom';
+import { LogOut } from 'lucide-react';
 import { Footer } from './Footer';
+import { Button } from './ui/button';
+import { useAuth } from '../context/AuthContext';
 import Logo from '../assets/logo.webp';
 
 interface LayoutProps {
@@ -10,6 +13,8 @@ interface LayoutProps {
 }
 
 export function Layout({ children, title, actions }: LayoutProps) {
+    const { user, logout } = useAuth();
+
     return (
         <div className="min-h-screen bg-background text-foreground font-sans flex flex-col">
             {/* Static Header */}
@@ -27,6 +32,17 @@ export function Layout({ children, title, actions }: LayoutProps) {
                             />
                         </Link>
                     </div>
+                    {user && (
+                        <div className="flex items-center gap-3">
+                            <span className="text-sm text-muted-foreground hidden sm:inline">
+                                {user.name}
+                            </span>
+                            <Button variant="ghost" size="sm" onClick={logout}>
+                                <LogOut className="w-4 h-4 mr-2" />
+                                Sign out
+                            </Button>
+                        </div>
+                    )}
                 </div>
             </header>
 
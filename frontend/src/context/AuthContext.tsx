@@ -0,0 +1,72 @@
+import { createContext, useContext, useState, useEffect, useCallback, useMemo, ReactNode } from 'react';
+import { User } from '../models/User';
+import { apiFetch, UNAUTHORIZED_EVENT } from '../utils/api';
+
+interface AuthContextType {
+  user: User | null;
+  // True until the existing session, if any, has been checked
+  checking: boolean;
+  login: (email: string, password: string) => Promise<void>;
+  logout: () => Promise<void>;
+}
+
+const AuthContext = createContext<AuthContextType | undefined>(undefined);
+
+export function AuthProvider({ children }: { children: ReactNode }) {
+  const [user, setUser] = useState<User | null>(null);
+  const [checking, setChecking] = useState(true);
+
+  useEffect(() => {
+    apiFetch('/auth/me')
+      .then((response) => (response.ok ? response.json() : null))
+      .then((data) => setUser(data))
+      .catch(() => setUser(null))
+      .finally(() => setChecking(false));
+  }, []);
+
+  // Any request rejected for a missing or expired session signs the user out
+  useEffect(() => {
+    const handleUnauthorized = () => setUser(null);
+    window.addEventListener(UNAUTHORIZED_EVENT, handleUnauthorized);
+    return () => window.removeEventListener(UNAUTHORIZED_EVENT, handleUnauthorized);
+  }, []);
+
+  const login = useCallback(async (email: string, password: string) => {
+    const response = await apiFetch('/auth/login', {
+      method: 'POST',
+      headers: { 'Content-Type': 'application/json' },
+      body: JSON.stringify({ email, password }),
+    });
+    if (!response.ok) {
+      const errorData = await response.json().catch(() => ({}));
+      throw new Error(errorData.error || 'Failed to sign in');
+    }
+    setUser(await response.json());
+  }, []);
+
+  const logout = useCallback(async () => {
+    await apiFetch('/auth/logout', { method: 'POST' }).catch(() => undefined);
+    setUser(null);
+  }, []);
+
+  const value = useMemo(() => ({
+    user,
+    checking,
+    login,
+    logout,
+  }), [user, checking, login, logout]);
+
+  return (
+    <AuthContext.Provider value={value}>
+      {children}
+    </AuthContext.Provider>
+  );
+}
+
+export function useAuth() {
+  const context = useContext(AuthContext);
+  if (context === undefined) {
+    throw new Error('useAuth must be used within an AuthProvider');
+  }
+  return context;
+}
@@ -1,7 +1,7 @@
-import { createContext, useContext, useState, useEffect, useCallback, useMemo, ReactNode } from 'react';
+import { createContext, useContext, useState, useEffect, useCallback, useMemo, useRef, ReactNode } from 'react';
 import { Project } from '../models/Project';
-
-const API_BASE_URL = '/api';
+import { apiFetch } from '../utils/api';
+import { useAuth } from './AuthContext';
 
 // Helper to normalize backend data (JSON strings -> Arrays)
 const normalizeProject = (data: any): Project => {
@@ -70,12 +70,16 @@ export function ProjectProvider({ children, toast }: ProjectProviderProps) {
   const [projects, setProjects] = useState<Project[]>([]);
   const [loading, setLoading] = useState(false);
   const [error, setError] = useState<string | null>(null);
+  const { user } = useAuth();
+  // ETag of the version each project was last loaded at, sent back as
+  // If-Match so an edit cannot overwrite changes it has not seen
+  const etags = useRef<Record<string, string>>({});
 
   const fetchProjects = useCallback(async () => {
     setLoading(true);
     setError(null);
     try {
-      const response = await fetch(`${API_BASE_URL}/projects`);
+      const response = await apiFetch('/projects');
       if (!response.ok) throw new Error('Failed to fetch projects');
       const data = await response.json();
       setProjects(Array.isArray(data) ? data.map(normalizeProject) : []);
@@ -90,8 +94,10 @@ export function ProjectProvider({ children, toast }: ProjectProviderProps) {
     setLoading(true);
     setError(null);
     try {
-      const response = await fetch(`${API_BASE_URL}/projects/${id}`);
+      const response = await apiFetch(`/projects/${id}`);
       if (!response.ok) throw new Error('Failed to fetch project');
+      const etag = response.headers.get('ETag');
+      if (etag) etags.current[id] = etag;
       const data = await response.json();
       return normalizeProject(data);
     } catch (err) {
@@ -106,7 +112,7 @@ export function ProjectProvider({ children, toast }: ProjectProviderProps) {
     setLoading(true);
     setError(null);
     try {
-      const response = await fetch(`${API_BASE_URL}/projects`, {
+      const response = await apiFetch('/projects', {
         method: 'POST',
         headers: { 'Content-Type': 'application/json' },
         body: JSON.stringify(serializeProject(project)),
@@ -131,18 +137,27 @@ export function ProjectProvider({ children, toast }: ProjectProviderProps) {
     setLoading(true);
     setError(null);
     try {
+      const headers: Record<string, string> = { 'Content-Type': 'application/json' };
+      if (etags.current[id]) headers['If-Match'] = etags.current[id];
+
       // Send only the changed fields (partial update)
-      const response = await fetch(`${API_BASE_URL}/projects/${id}`, {
+      const response = await apiFetch(`/projects/${id}`, {
         method: 'PUT',
-        headers: { 'Content-Type': 'application/json' },
+        headers,
         body: JSON.stringify(serializeProject(projectUpdates)),
       });
 
+      if (response.status === 412) {
+        throw new Error('This project was changed by someone else. Reload it to see their changes, then make your edit again.');
+      }
       if (!response.ok) {
         const errorData = await response.json().catch(() => ({}));
         throw new Error(errorData.error || 'Failed to update project');
       }
 
+      const etag = response.headers.get('ETag');
+      if (etag) etags.current[id] = etag;
+
       // Get updated project from response
       const rawUpdatedProject = await response.json();
       const updatedProject = normalizeProject(rawUpdatedProject);
@@ -168,7 +183,7 @@ export function ProjectProvider({ children, toast }: ProjectProviderProps) {
     setLoading(true);
     setError(null);
     try {
-      const response = await fetch(`${API_BASE_URL}/projects/${id}`, {
+      const response = await apiFetch(`/projects/${id}`, {
         method: 'DELETE',
       });
       if (!response.ok) throw new Error('Failed to delete project');
@@ -185,9 +200,14 @@ export function ProjectProvider({ children, toast }: ProjectProviderProps) {
     }
   }, [fetchProjects, toast]);
 
+  // Load projects once signed in, and forget them on sign-out
   useEffect(() => {
-    fetchProjects();
-  }, [fetchProjects]);
+    if (user) {
+      fetchProjects();
+    } else {
+      setProjects([]);
+    }
+  }, [user, fetchProjects]);
 
   const value = useMemo(() => ({
     projects,
@@ -27,5 +27,6 @@ export interface Project {
   techStack?: string[];
   deliverables?: string[];
   internalNotes?: string;
+  version?: number;
 }
 
@@ -0,0 +1,16 @@
+const API_BASE_URL = '/api';
+
+// Fired when the API rejects the session, so the app can return to the login page
+export const UNAUTHORIZED_EVENT = 'auth:unauthorized';
+
+// apiFetch calls the backend with the session cookie attached
+export async function apiFetch(path: string, init?: RequestInit): Promise<Response> {
+  const response = await fetch(`${API_BASE_URL}${path}`, {
+    credentials: 'same-origin',
+    ...init,
+  });
+  if (response.status === 401) {
+    window.dispatchEvent(new Event(UNAUTHORIZED_EVENT));
+  }
+  return response;
+}